@@ -0,0 +1,199 @@
+// Package astutil contains the cursor and list handling that is shared by the Apply functions of the yok and sh ASTs.
+// Each AST only needs to provide a function that applies to the children of its nodes
+package astutil
+
+import "slices"
+
+// ApplyFunc is called for each node visited by Apply. The cursor is only valid until the function returns
+type ApplyFunc[N any] func(*Cursor[N]) bool
+
+// ChildrenFunc applies to each child of the node by calling Application.Field and List
+type ChildrenFunc[N any] func(a *Application[N], n N)
+
+// Apply traverses the AST rooted at root, calling pre before a node's children are visited and post after.
+// children is called for each node to visit its children. Apply returns the root, which may have been replaced
+func Apply[N any](root N, pre, post ApplyFunc[N], children ChildrenFunc[N]) (result N) {
+	a := &Application[N]{pre: pre, post: post, children: children}
+	defer func() {
+		if r := recover(); r != nil && r != errAbort {
+			panic(r)
+		}
+		result = root
+	}()
+
+	var parent N
+	a.apply(parent, "Root", nil, nil, root, func(n N) { root = n })
+	return root
+}
+
+// abort is used to stop the traversal when post returns false
+type abort struct{}
+
+var errAbort = abort{}
+
+// Cursor describes a node visited by Apply
+type Cursor[N any] struct {
+	parent N
+	name   string
+	node   N
+	// set replaces the node when it's a field of the parent
+	set func(N)
+	// list and iter are set when the node is in a list (e.g. the statements of a block)
+	list nodeList[N]
+	iter *iterator
+}
+
+// Node returns the current node, it's nil if the node was deleted
+func (c *Cursor[N]) Node() N {
+	return c.node
+}
+
+// Parent returns the parent of the current node, it's nil for the root node
+func (c *Cursor[N]) Parent() N {
+	return c.parent
+}
+
+// Name returns the name of the parent field that contains the current node (e.g. Statements or Value).
+// Fields of structs in a list are prefixed with the list name (e.g. ElseIfs.Test)
+func (c *Cursor[N]) Name() string {
+	return c.name
+}
+
+// Index returns the index of the current node in its list, it's -1 if the node is not in a list
+func (c *Cursor[N]) Index() int {
+	if c.iter == nil {
+		return -1
+	}
+
+	return c.iter.index
+}
+
+// Replace replaces the current node. The new node must be valid in the parent field
+// (e.g. a Stmt in a list of statements), otherwise Replace panics
+func (c *Cursor[N]) Replace(n N) {
+	if c.iter != nil {
+		c.list.set(c.iter.index, n)
+	} else {
+		c.set(n)
+	}
+
+	c.node = n
+}
+
+// Delete deletes the current node from its list. It panics if the node is not in a list
+func (c *Cursor[N]) Delete() {
+	if c.iter == nil {
+		panic("Delete node not contained in a list")
+	}
+
+	c.list.delete(c.iter.index)
+	c.iter.step--
+
+	var deleted N
+	c.node = deleted
+}
+
+// InsertBefore inserts the node before the current node in its list. It panics if the node is not in a list
+func (c *Cursor[N]) InsertBefore(n N) {
+	if c.iter == nil {
+		panic("InsertBefore node not contained in a list")
+	}
+
+	c.list.insert(c.iter.index, n)
+	c.iter.index++
+}
+
+// InsertAfter inserts the node after the current node in its list. It panics if the node is not in a list
+func (c *Cursor[N]) InsertAfter(n N) {
+	if c.iter == nil {
+		panic("InsertAfter node not contained in a list")
+	}
+
+	c.list.insert(c.iter.index+1, n)
+	c.iter.step++
+}
+
+// iterator is the position of the cursor in a list, step is how far to move once the current node is done
+type iterator struct {
+	index int
+	step  int
+}
+
+// nodeList is a list of nodes that can be changed by a cursor
+type nodeList[N any] interface {
+	len() int
+	get(i int) N
+	set(i int, n N)
+	insert(i int, n N)
+	delete(i int)
+}
+
+// list is a nodeList for a slice of statements or expressions, E is the type of the elements in the slice
+type list[N, E any] struct {
+	nodes *[]E
+}
+
+func (l list[N, E]) len() int {
+	return len(*l.nodes)
+}
+
+func (l list[N, E]) get(i int) N {
+	return any((*l.nodes)[i]).(N)
+}
+
+func (l list[N, E]) set(i int, n N) {
+	(*l.nodes)[i] = any(n).(E)
+}
+
+func (l list[N, E]) insert(i int, n N) {
+	*l.nodes = slices.Insert(*l.nodes, i, any(n).(E))
+}
+
+func (l list[N, E]) delete(i int) {
+	*l.nodes = slices.Delete(*l.nodes, i, i+1)
+}
+
+// Application is the state of a call to Apply
+type Application[N any] struct {
+	pre      ApplyFunc[N]
+	post     ApplyFunc[N]
+	children ChildrenFunc[N]
+	cursor   Cursor[N]
+}
+
+// Field applies to a node that is a field of the parent, set replaces the field
+func (a *Application[N]) Field(parent N, name string, n N, set func(N)) {
+	a.apply(parent, name, nil, nil, n, set)
+}
+
+func (a *Application[N]) apply(parent N, name string, l nodeList[N], iter *iterator, n N, set func(N)) {
+	saved := a.cursor
+	a.cursor = Cursor[N]{parent: parent, name: name, node: n, set: set, list: l, iter: iter}
+	defer func() { a.cursor = saved }()
+
+	if a.pre != nil && !a.pre(&a.cursor) {
+		return
+	}
+
+	// the node may have been replaced or deleted by pre
+	n = a.cursor.node
+	if any(n) == nil {
+		return
+	}
+
+	a.children(a, n)
+
+	if a.post != nil && !a.post(&a.cursor) {
+		panic(errAbort)
+	}
+}
+
+// List applies to each node in the list. Deleted and inserted nodes are tracked by the iterator
+func List[N, E any](a *Application[N], parent N, name string, nodes *[]E) {
+	l := list[N, E]{nodes: nodes}
+	iter := &iterator{}
+	for iter.index = 0; iter.index < l.len(); iter.index += iter.step {
+		iter.step = 1
+		a.apply(parent, name, l, iter, l.get(iter.index), nil)
+	}
+}
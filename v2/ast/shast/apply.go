@@ -0,0 +1,90 @@
+package shast
+
+import (
+	"fmt"
+
+	"github.com/bjatkin/yok/ast/internal/astutil"
+)
+
+// ApplyFunc is called for each node visited by Apply. The cursor is only valid until the function returns
+type ApplyFunc = astutil.ApplyFunc[Node]
+
+// Cursor describes a node visited by Apply
+type Cursor = astutil.Cursor[Node]
+
+// Apply traverses the AST rooted at root, calling pre before a node's children are visited and post after.
+// If pre returns false the children are skipped and post is not called for that node. If post returns false
+// the traversal stops and Apply returns immediately. pre and post may be nil.
+//
+// The cursor can be used to replace the current node, or to delete and insert nodes in lists of statements
+// and arguments. Nodes added with Replace are traversed but nodes that are inserted are not.
+// Apply returns the root, which may have been replaced
+func Apply(root Node, pre, post ApplyFunc) Node {
+	return astutil.Apply(root, pre, post, applyChildren)
+}
+
+// applyChildren applies to each child of the node
+func applyChildren(a *astutil.Application[Node], n Node) {
+	switch n := n.(type) {
+	case *Script:
+		astutil.List[Node](a, n, "Statements", &n.Statements)
+	case *Comment:
+		// nothing to apply
+	case *NewLine:
+		// nothing to apply
+	case *Assign:
+		a.Field(n, "Value", n.Value, func(r Node) { n.Value = r.(Expr) })
+	case *If:
+		a.Field(n, "Test", n.Test, func(r Node) { n.Test = r.(*TestCommand) })
+		astutil.List[Node](a, n, "Statements", &n.Statements)
+		for i := range n.ElseIfs {
+			elseIf := &n.ElseIfs[i]
+			a.Field(n, "ElseIfs.Test", elseIf.Test, func(r Node) { elseIf.Test = r.(*TestCommand) })
+			astutil.List[Node](a, n, "ElseIfs.Statements", &elseIf.Statements)
+		}
+		if n.ElseStatements != nil {
+			astutil.List[Node](a, n, "ElseStatements", &n.ElseStatements)
+		}
+	case *StmtExpr:
+		a.Field(n, "Expression", n.Expression, func(r Node) { n.Expression = r.(Expr) })
+	case *Function:
+		// the body is raw sh code so there is nothing to apply
+	case *String:
+		// nothing to apply
+	case *Pattern:
+		// nothing to apply
+	case *Exec:
+		for i := range n.Env {
+			env := &n.Env[i]
+			a.Field(n, "Env.Value", env.Value, func(r Node) { env.Value = r.(Expr) })
+		}
+		astutil.List[Node](a, n, "Arguments", &n.Arguments)
+	case *Identifier:
+		// nothing to apply
+	case *TestCommand:
+		a.Field(n, "Expression", n.Expression, func(r Node) { n.Expression = r.(Expr) })
+	case *ArithmeticCommand:
+		a.Field(n, "Expression", n.Expression, func(r Node) { n.Expression = r.(Expr) })
+	case *InfixExpr:
+		a.Field(n, "Left", n.Left, func(r Node) { n.Left = r.(Expr) })
+		a.Field(n, "Right", n.Right, func(r Node) { n.Right = r.(Expr) })
+	case *GroupExpr:
+		a.Field(n, "Expression", n.Expression, func(r Node) { n.Expression = r.(Expr) })
+	case *CommandSub:
+		a.Field(n, "Expression", n.Expression, func(r Node) { n.Expression = r.(Expr) })
+	case *ParamaterExpansion:
+		a.Field(n, "Expression", n.Expression, func(r Node) { n.Expression = r.(ParamaterExpr) })
+	case *ParameterLength:
+		a.Field(n, "Paramater", n.Paramater, func(r Node) { n.Paramater = r.(*Identifier) })
+	case *ParamaterRemoveFix:
+		a.Field(n, "Paramater", n.Paramater, func(r Node) { n.Paramater = r.(*Identifier) })
+		a.Field(n, "Remove", n.Remove, func(r Node) { n.Remove = r.(Expr) })
+	case *ParamaterDefault:
+		a.Field(n, "Paramater", n.Paramater, func(r Node) { n.Paramater = r.(*Identifier) })
+		a.Field(n, "Default", n.Default, func(r Node) { n.Default = r.(Expr) })
+	case *ParamaterUnset:
+		a.Field(n, "Paramater", n.Paramater, func(r Node) { n.Paramater = r.(*Identifier) })
+	default:
+		panic(fmt.Sprintf("failed to apply to the AST, unknown node %T", n))
+	}
+}
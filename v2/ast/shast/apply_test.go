@@ -0,0 +1,237 @@
+package shast
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// tracer is a Visitor that records every node it visits
+type tracer struct {
+	nodes []string
+}
+
+func (t *tracer) Visit(node Node) Visitor {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *String:
+		t.nodes = append(t.nodes, fmt.Sprintf("String(%s)", n.Value))
+	case *Identifier:
+		t.nodes = append(t.nodes, fmt.Sprintf("Identifier(%s)", n.Value))
+	default:
+		t.nodes = append(t.nodes, fmt.Sprintf("%T", n)[len("*shast."):])
+	}
+
+	return t
+}
+
+func trace(node Node) []string {
+	t := &tracer{}
+	Walk(t, node)
+	return t.nodes
+}
+
+// testScript contains every kind of node
+func testScript() *Script {
+	return &Script{
+		Statements: []Stmt{
+			&Comment{Value: "# test"},
+			&Function{Name: "_yok_upper", Body: []string{"printf '%s' \"$1\""}},
+			&Assign{Identifier: "A", Value: &ArithmeticCommand{
+				Expression: &InfixExpr{
+					Left:     &GroupExpr{Expression: &String{Value: "1"}},
+					Operator: "+",
+					Right:    &Identifier{Value: "B"},
+				},
+			}},
+			&NewLine{},
+			&If{
+				Test: &TestCommand{Expression: &Identifier{Value: "A"}},
+				Statements: []Stmt{
+					&StmtExpr{Expression: &Exec{
+						Command:   "printf",
+						Arguments: []Expr{&ParamaterExpansion{Expression: &ParameterLength{Paramater: &Identifier{Value: "A"}}}},
+					}},
+				},
+				ElseIfs: []ElseIf{{
+					Test: &TestCommand{Expression: &Pattern{Value: "*.go"}},
+					Statements: []Stmt{
+						&StmtExpr{Expression: &CommandSub{Expression: &Exec{
+							Command: "ls",
+							Env:     []EnvAssign{{Name: "CC", Value: &String{Value: "clang"}}},
+						}}},
+					},
+				}},
+				ElseStatements: []Stmt{
+					&Assign{Identifier: "C", Value: &ParamaterExpansion{Expression: &ParamaterRemoveFix{
+						Paramater: &Identifier{Value: "C"},
+						Remove:    &ParamaterExpansion{Expression: &ParamaterDefault{Paramater: &Identifier{Value: "D"}, Default: &String{Value: "d"}}},
+					}}},
+					&Assign{Identifier: "E", Value: &ParamaterExpansion{Expression: &ParamaterUnset{Paramater: &Identifier{Value: "E"}}}},
+				},
+			},
+		},
+	}
+}
+
+func TestWalk(t *testing.T) {
+	want := []string{
+		"Script",
+		"Comment",
+		"Function",
+		"Assign", "ArithmeticCommand", "InfixExpr", "GroupExpr", "String(1)", "Identifier(B)",
+		"NewLine",
+		"If", "TestCommand", "Identifier(A)",
+		"StmtExpr", "Exec", "ParamaterExpansion", "ParameterLength", "Identifier(A)",
+		"TestCommand", "Pattern",
+		"StmtExpr", "CommandSub", "Exec", "String(clang)",
+		"Assign", "ParamaterExpansion", "ParamaterRemoveFix", "Identifier(C)",
+		"ParamaterExpansion", "ParamaterDefault", "Identifier(D)", "String(d)",
+		"Assign", "ParamaterExpansion", "ParamaterUnset", "Identifier(E)",
+	}
+
+	got := trace(testScript())
+	if !slices.Equal(got, want) {
+		t.Errorf("Walk() visited\n%v\nwant\n%v", got, want)
+	}
+}
+
+// smallScript contains the first few statements of the test script
+func smallScript() *Script {
+	return &Script{Statements: testScript().Statements[:4]}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		pre  ApplyFunc
+		want []string
+	}{
+		{
+			name: "no changes",
+			pre:  func(c *Cursor) bool { return true },
+			want: trace(smallScript()),
+		},
+		{
+			name: "replace",
+			pre: func(c *Cursor) bool {
+				if ident, ok := c.Node().(*Identifier); ok && ident.Value == "B" {
+					c.Replace(&String{Value: "2"})
+				}
+				return true
+			},
+			want: []string{
+				"Script",
+				"Comment",
+				"Function",
+				"Assign", "ArithmeticCommand", "InfixExpr", "GroupExpr", "String(1)", "String(2)",
+				"NewLine",
+			},
+		},
+		{
+			name: "delete",
+			pre: func(c *Cursor) bool {
+				switch c.Node().(type) {
+				case *Comment, *Function, *NewLine:
+					c.Delete()
+				}
+				return true
+			},
+			want: []string{
+				"Script",
+				"Assign", "ArithmeticCommand", "InfixExpr", "GroupExpr", "String(1)", "Identifier(B)",
+			},
+		},
+		{
+			name: "insert before and after",
+			pre: func(c *Cursor) bool {
+				if _, ok := c.Node().(*Assign); ok {
+					c.InsertBefore(&NewLine{})
+					c.InsertAfter(&Comment{Value: "# after"})
+				}
+				return true
+			},
+			want: []string{
+				"Script",
+				"Comment",
+				"Function",
+				"NewLine",
+				"Assign", "ArithmeticCommand", "InfixExpr", "GroupExpr", "String(1)", "Identifier(B)",
+				"Comment",
+				"NewLine",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := smallScript()
+			Apply(script, tt.pre, nil)
+
+			got := trace(script)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Apply() script is\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply_Cursor(t *testing.T) {
+	want := []string{
+		"Script Root -1",
+		"Comment Statements 0",
+		"Function Statements 1",
+		"Assign Statements 2",
+		"ArithmeticCommand Value -1",
+		"NewLine Statements 3",
+		"If Statements 4",
+		"TestCommand Test -1",
+		"StmtExpr Statements 0",
+		"Exec Expression -1",
+		"TestCommand ElseIfs.Test -1",
+		"StmtExpr ElseIfs.Statements 0",
+		"CommandSub Expression -1",
+		"Assign ElseStatements 0",
+		"ParamaterExpansion Value -1",
+		"Assign ElseStatements 1",
+		"ParamaterExpansion Value -1",
+	}
+
+	got := []string{}
+	Apply(testScript(), func(c *Cursor) bool {
+		got = append(got, fmt.Sprintf("%T %s %d", c.Node(), c.Name(), c.Index())[len("*shast."):])
+
+		// the children of expressions are skipped so only statements and their direct children are visited
+		_, ok := c.Node().(Stmt)
+		return ok || c.Parent() == nil
+	}, nil)
+
+	if !slices.Equal(got, want) {
+		t.Errorf("Apply() visited\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestApply_Abort(t *testing.T) {
+	visited := 0
+	Apply(testScript(), nil, func(c *Cursor) bool {
+		visited++
+		_, ok := c.Node().(*Assign)
+		return !ok
+	})
+
+	// the comment, the function, the string, the identifier, the group, the infix, the arithmetic and the assign
+	if visited != 8 {
+		t.Errorf("Apply() called post %d times after it was stopped, want 8", visited)
+	}
+}
+
+func TestApply_ReplaceRoot(t *testing.T) {
+	got := Apply(&String{Value: "a"}, func(c *Cursor) bool {
+		c.Replace(&Identifier{Value: "A"})
+		return true
+	}, nil)
+
+	if ident, ok := got.(*Identifier); !ok || ident.Value != "A" {
+		t.Errorf("Apply() = %v, want the replaced root", got)
+	}
+}
@@ -3,6 +3,8 @@ package shast
 import (
 	"fmt"
 	"strconv"
+
+	"github.com/bjatkin/yok/token"
 )
 
 // Node is a valid AST node
@@ -10,7 +12,8 @@ type Node interface {
 	node()
 }
 
-// Stmt is a statement in an AST
+// Stmt is a statement in an AST. Statements carry the position (Pos) of the yok code they were compiled from
+// so generated sh can be mapped back to the original source, statements created by the compiler use token.NoPos
 type Stmt interface {
 	Node
 	stmt()
@@ -22,35 +25,46 @@ type Script struct {
 	Statements []Stmt
 }
 
-// Comment is a line comment
+// Comment is a line comment, it can also be attached to the end of a statement as a trailing comment
 type Comment struct {
 	Stmt
+	Pos   token.Pos
 	Value string
 }
 
 // NewLine represents a new line in an sh script
 type NewLine struct {
 	Stmt
+	Pos token.Pos
 }
 
 // Assign is an variable assignment
 type Assign struct {
 	Stmt
+	Pos        token.Pos
 	Identifier string
 	Value      Expr
+	Comment    *Comment
+	// Export exports the variable to the environment of any commands run by the script
+	Export bool
+	// Readonly prevents the variable from being changed after it's assigned
+	Readonly bool
 }
 
 // If is an sh if statement
 type If struct {
 	Stmt
+	Pos            token.Pos
 	Test           *TestCommand
 	Statements     []Stmt
 	ElseIfs        []ElseIf
 	ElseStatements []Stmt
+	Comment        *Comment
 }
 
 // ElseIf is the 'elif' fragment in an if statement
 type ElseIf struct {
+	Pos        token.Pos
 	Test       *TestCommand
 	Statements []Stmt
 }
@@ -58,7 +72,17 @@ type ElseIf struct {
 // StmtExpr is any statement that consists of a single expression
 type StmtExpr struct {
 	Stmt
+	Pos        token.Pos
 	Expression Expr
+	Comment    *Comment
+}
+
+// Function is an sh function definition. The body is raw sh code, one line per element,
+// it's used for the helper functions that implement yok builtins
+type Function struct {
+	Stmt
+	Name string
+	Body []string
 }
 
 // Expr is an expression in an AST
@@ -67,12 +91,20 @@ type Expr interface {
 	expr()
 }
 
-// String is a string literal
+// String is a string literal. Value is the raw value of the string,
+// it is quoted as needed when the sh code is generated
 type String struct {
 	Expr
 	Value string
 }
 
+// Pattern is a glob pattern. The glob characters are left unquoted when the
+// sh code is generated and the rest of the pattern is quoted so it matches literally
+type Pattern struct {
+	Expr
+	Value string
+}
+
 // Redirect is a file redirect for an exec call
 type Redirect struct {
 	LeftFd  int
@@ -95,6 +127,14 @@ type Exec struct {
 	Command   string
 	Arguments []Expr
 	Redirects []Redirect
+	// Env are environment variables that are only set for this command
+	Env []EnvAssign
+}
+
+// EnvAssign sets an environment variable for a single command (e.g. CC=clang make)
+type EnvAssign struct {
+	Name  string
+	Value Expr
 }
 
 // Identifier is a sh identifier
@@ -133,6 +173,7 @@ type GroupExpr struct {
 // CommandSub represents a command substitution
 type CommandSub struct {
 	Expr
+	Quoted     bool
 	Expression Expr
 }
 
@@ -145,6 +186,7 @@ type ParamaterExpr interface {
 // ParameterExpations is a paramater expasion shell call
 type ParamaterExpansion struct {
 	Expr
+	Quoted     bool
 	Expression ParamaterExpr
 }
 
@@ -154,10 +196,26 @@ type ParameterLength struct {
 	Paramater *Identifier
 }
 
+// ParamaterDefault is a ParamaterExpr used to provide a default value for unset or empty paramaters
+type ParamaterDefault struct {
+	ParamaterExpr
+	Paramater *Identifier
+	Default   Expr
+}
+
+// ParamaterUnset is a ParamaterExpr that expands to an empty string if the paramater is unset,
+// it's used to read environment variables when set -u is enabled
+type ParamaterUnset struct {
+	ParamaterExpr
+	Paramater *Identifier
+}
+
 // ParamaterRemoveFix is a ParamaterExpr used to remove the prefix or suffix of a string
 type ParamaterRemoveFix struct {
 	ParamaterExpr
 	RemovePrefix bool
-	Paramater    *Identifier
-	Remove       Expr
+	// Shortest removes the shortest match rather than the longest match
+	Shortest  bool
+	Paramater *Identifier
+	Remove    Expr
 }
@@ -2,6 +2,8 @@ package shast
 
 import "fmt"
 
+// Visitor visits each node in the AST. If Visit returns nil the children of the node are skipped,
+// otherwise the children are walked with the returned visitor and then Visit(nil) is called
 type Visitor interface {
 	Visit(Node) Visitor
 }
@@ -12,6 +14,7 @@ func walkSlice[N Node](v Visitor, slice []N) {
 	}
 }
 
+// Walk traverses the AST in depth first order. Trailing comments and function bodies are not walked
 func Walk(v Visitor, node Node) {
 	if v = v.Visit(node); v == nil {
 		return
@@ -38,9 +41,16 @@ func Walk(v Visitor, node Node) {
 		}
 	case *StmtExpr:
 		Walk(v, n.Expression)
+	case *Function:
+		// the body is raw sh code so there is nothing to walk
 	case *String:
 		// nothing to walk
+	case *Pattern:
+		// nothing to walk
 	case *Exec:
+		for _, assign := range n.Env {
+			Walk(v, assign.Value)
+		}
 		walkSlice(v, n.Arguments)
 	case *Identifier:
 		// nothing to walk
@@ -53,6 +63,20 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Right)
 	case *GroupExpr:
 		Walk(v, n.Expression)
+	case *CommandSub:
+		Walk(v, n.Expression)
+	case *ParamaterExpansion:
+		Walk(v, n.Expression)
+	case *ParameterLength:
+		Walk(v, n.Paramater)
+	case *ParamaterRemoveFix:
+		Walk(v, n.Paramater)
+		Walk(v, n.Remove)
+	case *ParamaterDefault:
+		Walk(v, n.Paramater)
+		Walk(v, n.Default)
+	case *ParamaterUnset:
+		Walk(v, n.Paramater)
 	default:
 		panic(fmt.Sprintf("failed to walk the AST, uknown node %T", n))
 	}
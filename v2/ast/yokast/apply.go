@@ -0,0 +1,97 @@
+package yokast
+
+import (
+	"fmt"
+
+	"github.com/bjatkin/yok/ast/internal/astutil"
+)
+
+// ApplyFunc is called for each node visited by Apply. The cursor is only valid until the function returns
+type ApplyFunc = astutil.ApplyFunc[Node]
+
+// Cursor describes a node visited by Apply
+type Cursor = astutil.Cursor[Node]
+
+// Apply traverses the AST rooted at root, calling pre before a node's children are visited and post after.
+// If pre returns false the children are skipped and post is not called for that node. If post returns false
+// the traversal stops and Apply returns immediately. pre and post may be nil.
+//
+// The cursor can be used to replace the current node, or to delete and insert nodes in lists of statements
+// and call arguments. Nodes added with Replace are traversed but nodes that are inserted are not.
+// Apply returns the root, which may have been replaced
+func Apply(root Node, pre, post ApplyFunc) Node {
+	return astutil.Apply(root, pre, post, applyChildren)
+}
+
+// applyChildren applies to each child of the node
+func applyChildren(a *astutil.Application[Node], n Node) {
+	switch n := n.(type) {
+	case *Script:
+		astutil.List[Node](a, n, "Statements", &n.Statements)
+	case *Comment:
+		// nothing to apply
+	case *NewLine:
+		// nothing to apply
+	case *Assign:
+		a.Field(n, "Identifier", n.Identifier, func(r Node) { n.Identifier = r.(*Identifier) })
+		if n.Type != nil {
+			a.Field(n, "Type", n.Type, func(r Node) { n.Type = r.(*Identifier) })
+		}
+		a.Field(n, "Value", n.Value, func(r Node) { n.Value = r.(Expr) })
+	case *Reassign:
+		a.Field(n, "Identifier", n.Identifier, func(r Node) { n.Identifier = r.(*Identifier) })
+		a.Field(n, "Value", n.Value, func(r Node) { n.Value = r.(Expr) })
+	case *EnvAssign:
+		a.Field(n, "Variable", n.Variable, func(r Node) { n.Variable = r.(*EnvVar) })
+		a.Field(n, "Value", n.Value, func(r Node) { n.Value = r.(Expr) })
+	case *If:
+		a.Field(n, "Test", n.Test, func(r Node) { n.Test = r.(Expr) })
+		a.Field(n, "Body", n.Body, func(r Node) { n.Body = r.(*Block) })
+		for i := range n.ElseIfs {
+			elseIf := &n.ElseIfs[i]
+			a.Field(n, "ElseIfs.Test", elseIf.Test, func(r Node) { elseIf.Test = r.(Expr) })
+			a.Field(n, "ElseIfs.Body", elseIf.Body, func(r Node) { elseIf.Body = r.(*Block) })
+		}
+		if n.ElseBody != nil {
+			a.Field(n, "ElseBody", n.ElseBody, func(r Node) { n.ElseBody = r.(*Block) })
+		}
+	case *Block:
+		astutil.List[Node](a, n, "Statements", &n.Statements)
+	case *StmtExpr:
+		a.Field(n, "Expression", n.Expression, func(r Node) { n.Expression = r.(Expr) })
+	case *String:
+		// nothing to apply
+	case *Pattern:
+		// nothing to apply
+	case *Atom:
+		// nothing to apply
+	case *Call:
+		a.Field(n, "Identifier", n.Identifier, func(r Node) { n.Identifier = r.(*Identifier) })
+		astutil.List[Node](a, n, "Arguments", &n.Arguments)
+		for i := range n.NamedArguments {
+			arg := &n.NamedArguments[i]
+			a.Field(n, "NamedArguments.Name", arg.Name, func(r Node) { arg.Name = r.(*Identifier) })
+			a.Field(n, "NamedArguments.Value", arg.Value, func(r Node) { arg.Value = r.(Expr) })
+		}
+	case *Dict:
+		for i := range n.Entries {
+			entry := &n.Entries[i]
+			a.Field(n, "Entries.Value", entry.Value, func(r Node) { entry.Value = r.(Expr) })
+		}
+	case *Identifier:
+		// nothing to apply
+	case *EnvVar:
+		// nothing to apply
+	case *InfixExpr:
+		a.Field(n, "Left", n.Left, func(r Node) { n.Left = r.(Expr) })
+		a.Field(n, "Right", n.Right, func(r Node) { n.Right = r.(Expr) })
+	case *GroupExpr:
+		a.Field(n, "Expression", n.Expression, func(r Node) { n.Expression = r.(Expr) })
+	case *PrefixExpr:
+		a.Field(n, "Expression", n.Expression, func(r Node) { n.Expression = r.(Expr) })
+	case *NestedCall:
+		a.Field(n, "Call", n.Call, func(r Node) { n.Call = r.(*Call) })
+	default:
+		panic(fmt.Sprintf("failed to apply to the AST, unknown node %T", n))
+	}
+}
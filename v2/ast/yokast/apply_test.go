@@ -0,0 +1,168 @@
+package yokast
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/bjatkin/yok/token"
+)
+
+// tracer is a Visitor that records every node it visits
+type tracer struct {
+	nodes []string
+}
+
+func (t *tracer) Visit(node Node) Visitor {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *String:
+		t.nodes = append(t.nodes, fmt.Sprintf("String(%s)", n.Decoded()))
+	case *Identifier:
+		t.nodes = append(t.nodes, fmt.Sprintf("Identifier(%s)", n.Name(nil)))
+	default:
+		t.nodes = append(t.nodes, fmt.Sprintf("%T", n)[len("*yokast."):])
+	}
+
+	return t
+}
+
+func trace(node Node) []string {
+	t := &tracer{}
+	Walk(t, node)
+	return t.nodes
+}
+
+func ident(name string) *Identifier {
+	return NewInternalIdentifier(name, token.Token{})
+}
+
+func str(value string) *String {
+	return NewInternalString(value, token.Token{})
+}
+
+// testScript contains every kind of node
+func testScript() *Script {
+	return &Script{
+		Statements: []Stmt{
+			&Comment{},
+			&Assign{Identifier: ident("a"), Type: ident("int"), Value: &InfixExpr{
+				Left:  &GroupExpr{Expression: &Atom{}},
+				Right: &PrefixExpr{Expression: str("1")},
+			}},
+			&NewLine{},
+			&If{
+				Test: &Call{Identifier: ident("contains"), Arguments: []Expr{ident("a"), &Pattern{}}},
+				Body: &Block{Statements: []Stmt{
+					&Reassign{Identifier: ident("a"), Value: &NestedCall{Call: &Call{Identifier: ident("upper")}}},
+				}},
+				ElseIfs: []ElseIf{{
+					Test: &EnvVar{},
+					Body: &Block{Statements: []Stmt{
+						&EnvAssign{Variable: &EnvVar{}, Value: str("b")},
+					}},
+				}},
+				ElseBody: &Block{Statements: []Stmt{
+					&StmtExpr{Expression: &Call{
+						Identifier:     ident("exec"),
+						NamedArguments: []NamedArg{{Name: ident("env"), Value: &Dict{Entries: []DictEntry{{Value: str("c")}}}}},
+					}},
+				}},
+			},
+		},
+	}
+}
+
+func TestWalk(t *testing.T) {
+	want := []string{
+		"Script",
+		"Comment",
+		"Assign", "Identifier(a)", "Identifier(int)", "InfixExpr", "GroupExpr", "Atom", "PrefixExpr", "String(1)",
+		"NewLine",
+		"If", "Call", "Identifier(contains)", "Identifier(a)", "Pattern",
+		"Block", "Reassign", "Identifier(a)", "NestedCall", "Call", "Identifier(upper)",
+		"EnvVar",
+		"Block", "EnvAssign", "EnvVar", "String(b)",
+		"Block", "StmtExpr", "Call", "Identifier(exec)", "Identifier(env)", "Dict", "String(c)",
+	}
+
+	got := trace(testScript())
+	if !slices.Equal(got, want) {
+		t.Errorf("Walk() visited\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestWalk_AssignWithoutValue(t *testing.T) {
+	// let h str
+	assign := &Assign{Identifier: ident("h"), Type: ident("str")}
+	want := []string{"Assign", "Identifier(h)", "Identifier(str)"}
+
+	got := trace(assign)
+	if !slices.Equal(got, want) {
+		t.Errorf("Walk() visited\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		pre  ApplyFunc
+		post ApplyFunc
+		want []string
+	}{
+		{
+			name: "replace",
+			pre: func(c *Cursor) bool {
+				if _, ok := c.Node().(*Pattern); ok {
+					c.Replace(str("*.go"))
+				}
+				return true
+			},
+			want: []string{"If", "Call", "Identifier(contains)", "Identifier(a)", "String(*.go)"},
+		},
+		{
+			name: "delete",
+			pre: func(c *Cursor) bool {
+				if _, ok := c.Node().(*Identifier); ok && c.Name() == "Arguments" {
+					c.Delete()
+				}
+				return true
+			},
+			want: []string{"If", "Call", "Identifier(contains)", "Pattern"},
+		},
+		{
+			name: "insert before",
+			post: func(c *Cursor) bool {
+				if _, ok := c.Node().(*Pattern); ok {
+					c.InsertBefore(str("b"))
+				}
+				return true
+			},
+			want: []string{"If", "Call", "Identifier(contains)", "Identifier(a)", "String(b)", "Pattern"},
+		},
+		{
+			name: "parent",
+			pre: func(c *Cursor) bool {
+				if _, ok := c.Parent().(*Call); ok && c.Name() == "Identifier" {
+					c.Replace(ident("starts_with"))
+				}
+				return true
+			},
+			want: []string{"If", "Call", "Identifier(starts_with)", "Identifier(a)", "Pattern"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ifStmt := testScript().Statements[3].(*If)
+			ifStmt.Body, ifStmt.ElseIfs, ifStmt.ElseBody = &Block{}, nil, nil
+			Apply(ifStmt, tt.pre, tt.post)
+
+			got := trace(ifStmt)
+			got = slices.DeleteFunc(got, func(node string) bool { return node == "Block" })
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Apply() if statement is\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
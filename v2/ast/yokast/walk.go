@@ -0,0 +1,93 @@
+package yokast
+
+import "fmt"
+
+// Visitor visits each node in the AST. If Visit returns nil the children of the node are skipped,
+// otherwise the children are walked with the returned visitor and then Visit(nil) is called
+type Visitor interface {
+	Visit(Node) Visitor
+}
+
+func walkSlice[N Node](v Visitor, slice []N) {
+	for _, node := range slice {
+		Walk(v, node)
+	}
+}
+
+// Walk traverses the AST in depth first order. Trailing comments are not walked
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Script:
+		walkSlice(v, n.Statements)
+	case *Comment:
+		// nothing to walk
+	case *NewLine:
+		// nothing to walk
+	case *Assign:
+		Walk(v, n.Identifier)
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *Reassign:
+		Walk(v, n.Identifier)
+		Walk(v, n.Value)
+	case *EnvAssign:
+		Walk(v, n.Variable)
+		Walk(v, n.Value)
+	case *If:
+		Walk(v, n.Test)
+		Walk(v, n.Body)
+		for _, elseIf := range n.ElseIfs {
+			Walk(v, elseIf.Test)
+			Walk(v, elseIf.Body)
+		}
+		if n.ElseBody != nil {
+			Walk(v, n.ElseBody)
+		}
+	case *Block:
+		walkSlice(v, n.Statements)
+	case *StmtExpr:
+		Walk(v, n.Expression)
+	case *String:
+		// nothing to walk
+	case *Pattern:
+		// nothing to walk
+	case *Atom:
+		// nothing to walk
+	case *Call:
+		Walk(v, n.Identifier)
+		walkSlice(v, n.Arguments)
+		for _, arg := range n.NamedArguments {
+			Walk(v, arg.Name)
+			Walk(v, arg.Value)
+		}
+	case *Dict:
+		for _, entry := range n.Entries {
+			Walk(v, entry.Value)
+		}
+	case *Identifier:
+		// nothing to walk
+	case *EnvVar:
+		// nothing to walk
+	case *InfixExpr:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *GroupExpr:
+		Walk(v, n.Expression)
+	case *PrefixExpr:
+		Walk(v, n.Expression)
+	case *NestedCall:
+		Walk(v, n.Call)
+	default:
+		panic(fmt.Sprintf("failed to walk the AST, unknown node %T", n))
+	}
+
+	v.Visit(nil)
+}
@@ -1,6 +1,8 @@
 package yokast
 
 import (
+	"strings"
+
 	"github.com/bjatkin/yok/token"
 )
 
@@ -21,7 +23,8 @@ type Script struct {
 	Statements []Stmt
 }
 
-// Comment is a line comment
+// Comment is a line comment. Comments can be statements on their own or be attached
+// to the end of another statement as a trailing comment
 type Comment struct {
 	Stmt
 	Token token.Token
@@ -30,24 +33,56 @@ type Comment struct {
 // NewLine is a solo new line
 type NewLine struct {
 	Stmt
+	Pos token.Pos
 }
 
 // Assign is a let statement
 type Assign struct {
 	Stmt
+	Pos        token.Pos
+	Identifier *Identifier
+	Value      Expr
+	Comment    *Comment
+	// Export is set for 'export let' statements
+	Export bool
+	// Const is set for 'const' statements
+	Const bool
+	// Type is the optional type annotation (e.g. let a int = :1)
+	Type *Identifier
+}
+
+// Reassign sets a new value for a variable that was already declared with a let statement
+type Reassign struct {
+	Stmt
+	Pos        token.Pos
 	Identifier *Identifier
 	Value      Expr
+	Comment    *Comment
 }
 
+// EnvAssign sets the value of an environment variable
+type EnvAssign struct {
+	Stmt
+	Pos      token.Pos
+	Variable *EnvVar
+	Value    Expr
+	Comment  *Comment
+}
+
+// If is an if statement with optional else if and else branches
 type If struct {
 	Stmt
+	Pos      token.Pos
 	Test     Expr
 	Body     *Block
 	ElseIfs  []ElseIf
 	ElseBody *Block
+	Comment  *Comment
 }
 
+// ElseIf is the 'else if' fragment in an if statement
 type ElseIf struct {
+	Pos  token.Pos
 	Test Expr
 	Body *Block
 }
@@ -60,7 +95,9 @@ type Block struct {
 // StmtExpr is any statement that consists of a single expression
 type StmtExpr struct {
 	Stmt
+	Pos        token.Pos
 	Expression Expr
+	Comment    *Comment
 }
 
 // Expr is an expression in an AST
@@ -72,14 +109,25 @@ type Expr interface {
 // String is a string literal
 type String struct {
 	Expr
-	value string
-	Token token.Token
+	value   string
+	decoded string
+	Token   token.Token
+}
+
+// NewString creates a new string literal, decoded is the value of the string
+// after all the escape sequences in the literal have been decoded
+func NewString(decoded string, token token.Token) *String {
+	return &String{
+		decoded: decoded,
+		Token:   token,
+	}
 }
 
 func NewInternalString(value string, token token.Token) *String {
 	return &String{
-		value: value,
-		Token: token,
+		value:   value,
+		decoded: value,
+		Token:   token,
 	}
 }
 
@@ -91,6 +139,23 @@ func (s *String) Value(source []byte) string {
 	return s.Token.Value(source)
 }
 
+// Decoded returns the value of the string after all the escape sequences have been decoded
+func (s *String) Decoded() string {
+	return s.decoded
+}
+
+// Pattern is a glob pattern literal (e.g. '*.log')
+type Pattern struct {
+	Expr
+	Token token.Token
+}
+
+// Value returns the pattern without the surrounding quotes
+func (p *Pattern) Value(source []byte) string {
+	value := p.Token.Value(source)
+	return value[1 : len(value)-1]
+}
+
 // Atom is an atom
 type Atom struct {
 	Expr
@@ -100,8 +165,28 @@ type Atom struct {
 // Call is a call expression
 type Call struct {
 	Expr
-	Identifier *Identifier
-	Arguments  []Expr
+	Identifier     *Identifier
+	Arguments      []Expr
+	NamedArguments []NamedArg
+}
+
+// NamedArg is a named argument in a call expression (e.g. env={CC: "clang"})
+type NamedArg struct {
+	Name  *Identifier
+	Value Expr
+}
+
+// Dict is a dictionary literal (e.g. {CC: "clang", CFLAGS: "-O2"})
+type Dict struct {
+	Expr
+	Token   token.Token
+	Entries []DictEntry
+}
+
+// DictEntry is a single key value pair in a dictionary literal
+type DictEntry struct {
+	Key   token.Token
+	Value Expr
 }
 
 // Identifier is a yok identifier
@@ -127,6 +212,25 @@ func (i *Identifier) Name(source []byte) string {
 	return i.Token.Value(source)
 }
 
+// EnvVar is an environment variable that is accessed explicitly with the env keyword (e.g. env.PATH or env("PATH")).
+// Unlike identifiers, the name of an environment variable is used in the generated sh code exactly as written
+type EnvVar struct {
+	Expr
+	Token token.Token
+	// Name is either an identifier or a string literal token
+	Name token.Token
+}
+
+// VarName returns the name of the environment variable
+func (e *EnvVar) VarName(source []byte) string {
+	name := e.Name.Value(source)
+	if e.Name.Type == token.StringLiteral {
+		name = strings.Trim(name, "\"")
+	}
+
+	return name
+}
+
 // InfixExpr is a yok infix expression
 type InfixExpr struct {
 	Expr
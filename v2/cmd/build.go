@@ -1,62 +1,289 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 
+	"github.com/bjatkin/yok/ast/shast"
 	"github.com/bjatkin/yok/codegen/gensh"
+	"github.com/bjatkin/yok/codegen/target"
 	"github.com/bjatkin/yok/compiler"
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/optimize"
 	"github.com/bjatkin/yok/parser"
+	"github.com/bjatkin/yok/sourcemap"
 	"github.com/spf13/cobra"
 )
 
+// buildSourceMap is set by the --sourcemap flag
+var buildSourceMap bool
+
+// buildOptions are the compiler options set by the build flags
+var buildOptions compiler.Options
+
+// buildOptimize is the optimization level set by the -O flag
+var buildOptimize int
+
+// buildOutput is the output file or directory set by the -o flag
+var buildOutput string
+
+// buildJobs is the number of files that are compiled in parallel, set by the -j flag
+var buildJobs int
+
 func init() {
 	rootCmd.AddCommand(buildCmd)
+	buildCmd.Flags().BoolVar(&buildSourceMap, "sourcemap", false, "write a source map for each generated script to <dest>.map")
+	buildCmd.Flags().BoolVar(&buildOptions.ReadonlyConsts, "readonly-consts", false, "also declare constants as readonly variables so they exist at runtime")
+	buildCmd.Flags().Var(&buildOptions.Target, "target", "the shell to build the script for, one of "+target.Names())
+	buildCmd.Flags().IntVarP(&buildOptimize, "optimize", "O", 0, "the optimization level, -O0 disables optimizations and -O1 folds constants and removes dead code")
+	buildCmd.Flags().BoolVar(&buildOptions.Strict, "strict", false, "start the script with set -eu, and set -o pipefail if the target supports it")
+	buildCmd.Flags().BoolVar(&buildOptions.TypeCheck, "typecheck", false, "check the types of all the values in the script before building it")
+	buildCmd.Flags().StringVarP(&buildOutput, "output", "o", "", "the output directory, or the output file when building a single file, use - to write to stdout")
+	buildCmd.Flags().IntVarP(&buildJobs, "jobs", "j", runtime.NumCPU(), "the number of files to compile in parallel")
 }
 
 var buildCmd = &cobra.Command{
-	Use:   "build",
+	Use:   "build [paths...]",
 	Short: "transpile yok code into sh code",
-	Args:  cobra.ExactArgs(2),
+	Long: `transpile yok code into sh code
+
+Directories are searched recursively for .yok files. Each file is built to name.sh next to its source,
+or into the directory set with -o, keeping the directory structure of the source directory.
+When a single file is built -o can also be the path of the output file, or - to write the script to stdout.
+The 'yok build src dest' form can still be used if dest is not a .yok file or a directory.`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		srcFile := args[0]
-		destFile := args[1]
+		cmd.SilenceUsage = true
+		// errors are printed by Execute after the list of failures, so cobra does not need to print them as well
+		cmd.SilenceErrors = true
 
-		yokCode, err := os.ReadFile(srcFile)
+		jobs, err := planBuild(args, buildOutput)
 		if err != nil {
 			return err
 		}
 
-		shCode, err := complieYok(yokCode)
+		failures := buildAll(jobs, buildJobs, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		if len(failures) == 0 {
+			return nil
+		}
+
+		fmt.Fprintln(cmd.ErrOrStderr())
+		for _, failure := range failures {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", failure.src, failure.err)
+		}
+
+		return errors.New(fmt.Sprintf("failed to build %d of %d file(s)", len(failures), len(jobs)))
+	},
+}
+
+// buildJob is a single yok file that is built into an sh script
+type buildJob struct {
+	src string
+	// dest is the path of the sh script, or - to write the script to stdout
+	dest string
+}
+
+// buildFailure is a yok file that could not be built
+type buildFailure struct {
+	src string
+	err error
+}
+
+// planBuild finds all the yok files in paths and works out where each script should be written.
+// Files in directories keep their path relative to the directory when they're written into the output directory.
+// Without an output, 'src dest' is still treated as the source file and the script if dest is not a yok file or a directory
+func planBuild(paths []string, output string) ([]buildJob, error) {
+	isFile := output == "-" || filepath.Ext(output) == ".sh"
+	if output == "" && len(paths) == 2 && isLegacyDest(paths[1]) {
+		paths, output, isFile = paths[:1], paths[1], true
+	}
+
+	jobs := []buildJob{}
+	singleFile := len(paths) == 1
+	for _, path := range paths {
+		info, err := os.Stat(path)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			jobs = append(jobs, buildJob{src: path, dest: outputPath(path, filepath.Base(path), output)})
+			continue
+		}
+
+		singleFile = false
+		files, err := findYokFiles([]string{path})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			rel, err := filepath.Rel(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			jobs = append(jobs, buildJob{src: file, dest: outputPath(file, rel, output)})
+		}
+	}
+
+	switch {
+	case len(jobs) == 0:
+		return nil, errors.New(fmt.Sprintf("no .yok files found in %s", strings.Join(paths, ", ")))
+	case isFile && !singleFile:
+		return nil, errors.New(fmt.Sprintf("-o %s can only be used when building a single file, use a directory instead", output))
+	case isFile:
+		jobs[0].dest = output
+	}
+
+	dests := map[string]string{}
+	for _, job := range jobs {
+		if src, ok := dests[job.dest]; ok {
+			return nil, errors.New(fmt.Sprintf("%s and %s would both be built to %s", src, job.src, job.dest))
+		}
+		dests[job.dest] = job.src
+	}
+
+	return jobs, nil
+}
+
+// isLegacyDest returns true if the path is the dest of the original 'yok build src dest' form,
+// which is any path that is not a yok file or an existing directory
+func isLegacyDest(path string) bool {
+	if filepath.Ext(path) == ".yok" {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	return err != nil || !info.IsDir()
+}
+
+// outputPath returns the path of the script for the yok file. Scripts are written next to the yok file
+// unless there is an output directory, then rel is the path of the script inside the output directory
+func outputPath(src, rel, output string) string {
+	if output == "" {
+		return strings.TrimSuffix(src, ".yok") + ".sh"
+	}
+
+	return filepath.Join(output, strings.TrimSuffix(rel, ".yok")+".sh")
+}
+
+// buildAll builds all the jobs using a pool of workers. Compile errors and warnings for each file are printed
+// to stderr together once the file is built. The failures are returned in the same order as the jobs
+func buildAll(jobs []buildJob, workers int, stdout, stderr io.Writer) []buildFailure {
+	errs := make([]error, len(jobs))
+	next := make(chan int)
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for range max(1, min(workers, len(jobs))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				diagnostics := bytes.Buffer{}
+				errs[i] = buildFile(jobs[i], &diagnostics, stdout)
+
+				mu.Lock()
+				stderr.Write(diagnostics.Bytes())
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	failures := []buildFailure{}
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, buildFailure{src: jobs[i].src, err: err})
 		}
+	}
+
+	return failures
+}
 
-		err = os.WriteFile(destFile, shCode, 0o0755)
+// buildFile compiles a single yok file and writes the script, and the source map if it's enabled
+func buildFile(job buildJob, diagnostics, stdout io.Writer) error {
+	yokCode, err := os.ReadFile(job.src)
+	if err != nil {
+		return err
+	}
+
+	shCode, sourceMap, err := complieYok(diagnostics, job.src, yokCode, buildOptions, optimize.Level(buildOptimize))
+	if err != nil {
+		return err
+	}
+
+	if job.dest == "-" {
+		_, err = stdout.Write(shCode)
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(job.dest), 0o0755)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(job.dest, shCode, 0o0755)
+	if err != nil {
+		return err
+	}
+
+	if buildSourceMap {
+		err = os.WriteFile(job.dest+".map", sourceMap.Encode(), 0o0644)
 		if err != nil {
 			return err
 		}
+	}
 
-		return nil
-	},
+	return nil
 }
 
-func complieYok(yokCode []byte) ([]byte, error) {
+// complieYok compiles the yok code into an sh script. Errors and warnings are written to diagnostics
+func complieYok(diagnostics io.Writer, srcFile string, yokCode []byte, options compiler.Options, level optimize.Level) ([]byte, sourcemap.Map, error) {
+	shAst, err := compileShast(diagnostics, srcFile, yokCode, options, level)
+	if err != nil {
+		return nil, sourcemap.Map{}, err
+	}
+
+	shCode, positions := gensh.New(options.Target).GenerateWithSourceMap(shAst)
+	sourceMap := sourcemap.New(srcFile, yokCode, positions)
+	return []byte(shCode), sourceMap, nil
+}
+
+// compileShast parses, compiles and optimizes the yok code. Errors and warnings are written to diagnostics
+func compileShast(diagnostics io.Writer, srcFile string, yokCode []byte, options compiler.Options, level optimize.Level) (*shast.Script, error) {
 	p := parser.New(yokCode)
 	script, err := p.Parse()
 	if err != nil {
 		for _, e := range p.Errors {
-			fmt.Println(e)
+			fmt.Fprintln(diagnostics, errors.Format(e, srcFile, yokCode))
 		}
 		return nil, err
 	}
 
-	c := compiler.New(yokCode)
+	c := compiler.NewWithOptions(yokCode, options)
 	shAst, err := c.Compile(script)
+	for _, w := range c.Warnings() {
+		fmt.Fprintln(diagnostics, "warning:", errors.Format(w, srcFile, yokCode))
+	}
 	if err != nil {
+		for _, e := range c.Errors() {
+			fmt.Fprintln(diagnostics, errors.Format(e, srcFile, yokCode))
+		}
 		return nil, err
 	}
 
-	shCode := gensh.Generate(shAst)
-	return []byte(shCode), nil
+	optimize.Optimize(shAst, level)
+	return shAst, nil
 }
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// chdirTemp creates the files in a new temporary directory and changes into it for the rest of the test
+func chdirTemp(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o0755); err != nil {
+			t.Fatal("failed to create test directory", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o0644); err != nil {
+			t.Fatal("failed to create test file", err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal("failed to get the working directory", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal("failed to change directory", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestPlanBuild(t *testing.T) {
+	files := map[string]string{
+		"hello.yok":           "print(:hello)\n",
+		"scripts/deploy.yok":  "print(:deploy)\n",
+		"scripts/ci/test.yok": "print(:test)\n",
+		"scripts/README.md":   "not yok code\n",
+		"other/deploy.yok":    "print(:deploy)\n",
+		"empty/notes.txt":     "not yok code\n",
+	}
+
+	tests := []struct {
+		name    string
+		paths   []string
+		output  string
+		want    []buildJob
+		wantErr string
+	}{
+		{
+			name:  "file next to its source",
+			paths: []string{"hello.yok"},
+			want:  []buildJob{{src: "hello.yok", dest: "hello.sh"}},
+		},
+		{
+			name:  "directory next to its sources",
+			paths: []string{"scripts"},
+			want: []buildJob{
+				{src: filepath.Join("scripts", "ci", "test.yok"), dest: filepath.Join("scripts", "ci", "test.sh")},
+				{src: filepath.Join("scripts", "deploy.yok"), dest: filepath.Join("scripts", "deploy.sh")},
+			},
+		},
+		{
+			name:   "directory to an output directory",
+			paths:  []string{"scripts"},
+			output: "dist",
+			want: []buildJob{
+				{src: filepath.Join("scripts", "ci", "test.yok"), dest: filepath.Join("dist", "ci", "test.sh")},
+				{src: filepath.Join("scripts", "deploy.yok"), dest: filepath.Join("dist", "deploy.sh")},
+			},
+		},
+		{
+			name:   "files and directories to an output directory",
+			paths:  []string{"hello.yok", filepath.Join("scripts", "ci")},
+			output: "dist",
+			want: []buildJob{
+				{src: "hello.yok", dest: filepath.Join("dist", "hello.sh")},
+				{src: filepath.Join("scripts", "ci", "test.yok"), dest: filepath.Join("dist", "test.sh")},
+			},
+		},
+		{
+			name:   "single file to a script",
+			paths:  []string{"hello.yok"},
+			output: filepath.Join("bin", "greet.sh"),
+			want:   []buildJob{{src: "hello.yok", dest: filepath.Join("bin", "greet.sh")}},
+		},
+		{
+			name:   "single file to stdout",
+			paths:  []string{"hello.yok"},
+			output: "-",
+			want:   []buildJob{{src: "hello.yok", dest: "-"}},
+		},
+		{
+			name:    "stdout with multiple files",
+			paths:   []string{"hello.yok", filepath.Join("scripts", "deploy.yok")},
+			output:  "-",
+			wantErr: "-o - can only be used when building a single file, use a directory instead",
+		},
+		{
+			name:    "stdout with a directory",
+			paths:   []string{"scripts"},
+			output:  "-",
+			wantErr: "-o - can only be used when building a single file, use a directory instead",
+		},
+		{
+			name:    "script with a directory",
+			paths:   []string{"scripts"},
+			output:  "all.sh",
+			wantErr: "-o all.sh can only be used when building a single file, use a directory instead",
+		},
+		{
+			name:   "duplicate destinations",
+			paths:  []string{filepath.Join("scripts", "deploy.yok"), filepath.Join("other", "deploy.yok")},
+			output: "dist",
+			wantErr: filepath.Join("scripts", "deploy.yok") + " and " + filepath.Join("other", "deploy.yok") +
+				" would both be built to " + filepath.Join("dist", "deploy.sh"),
+		},
+		{
+			name:    "directory without yok files",
+			paths:   []string{"empty"},
+			wantErr: "no .yok files found in empty",
+		},
+		{
+			name:  "legacy src dest form",
+			paths: []string{"hello.yok", filepath.Join("legacy", "build", "hello.sh")},
+			want:  []buildJob{{src: "hello.yok", dest: filepath.Join("legacy", "build", "hello.sh")}},
+		},
+		{
+			name:  "legacy src dest form without an extension",
+			paths: []string{"hello.yok", filepath.Join("bin", "hello")},
+			want:  []buildJob{{src: "hello.yok", dest: filepath.Join("bin", "hello")}},
+		},
+		{
+			name:  "two yok files are not the legacy form",
+			paths: []string{"hello.yok", filepath.Join("scripts", "deploy.yok")},
+			want: []buildJob{
+				{src: "hello.yok", dest: "hello.sh"},
+				{src: filepath.Join("scripts", "deploy.yok"), dest: filepath.Join("scripts", "deploy.sh")},
+			},
+		},
+		{
+			name:  "a file and a directory are not the legacy form",
+			paths: []string{"hello.yok", filepath.Join("scripts", "ci")},
+			want: []buildJob{
+				{src: "hello.yok", dest: "hello.sh"},
+				{src: filepath.Join("scripts", "ci", "test.yok"), dest: filepath.Join("scripts", "ci", "test.sh")},
+			},
+		},
+		{
+			name:    "legacy form is not used with an output",
+			paths:   []string{"hello.yok", "hello.sh"},
+			output:  "dist",
+			wantErr: "stat hello.sh: no such file or directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chdirTemp(t, files)
+
+			got, err := planBuild(tt.paths, tt.output)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("planBuild() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("planBuild() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planBuild() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildAll(t *testing.T) {
+	chdirTemp(t, map[string]string{
+		"scripts/bad.yok":      "let a = \n",
+		"scripts/hello.yok":    "print(:hello)\n",
+		"scripts/unused.yok":   "let a = :1\n",
+		"scripts/sub/next.yok": "print(:next)\n",
+	})
+
+	jobs, err := planBuild([]string{"scripts"}, "dist")
+	if err != nil {
+		t.Fatalf("planBuild() error = %v", err)
+	}
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	failures := buildAll(jobs, 2, &stdout, &stderr)
+
+	// the failed file does not stop the other files from being built
+	if len(failures) != 1 || failures[0].src != filepath.Join("scripts", "bad.yok") {
+		t.Fatalf("buildAll() failures = %v, want only scripts/bad.yok", failures)
+	}
+	for _, script := range []string{"hello.sh", "unused.sh", filepath.Join("sub", "next.sh")} {
+		if _, err := os.Stat(filepath.Join("dist", script)); err != nil {
+			t.Errorf("buildAll() did not write %s: %v", script, err)
+		}
+	}
+
+	wantDiagnostics := []string{
+		filepath.Join("scripts", "bad.yok") + ": missing prefix function for token: \n",
+		"warning: " + filepath.Join("scripts", "unused.yok") + ":1:5: 'a' is declared but never used",
+	}
+	for _, want := range wantDiagnostics {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("buildAll() stderr = %q, want it to contain %q", stderr.String(), want)
+		}
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("buildAll() stdout = %q, want it to be empty", stdout.String())
+	}
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// findYokFiles expands the given paths into a list of files. Files are always included,
+// directories are searched recursively for files ending with the .yok extension
+func findYokFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() && filepath.Ext(path) == ".yok" {
+				files = append(files, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
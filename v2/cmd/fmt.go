@@ -1,45 +1,176 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bjatkin/yok/codegen/genyok"
+	"github.com/bjatkin/yok/diff"
+	"github.com/bjatkin/yok/errors"
 	"github.com/bjatkin/yok/parser"
 )
 
+var (
+	// fmtCheck is set by the --check flag
+	fmtCheck bool
+	// fmtDiff is set by the --diff flag
+	fmtDiff bool
+)
+
 func init() {
 	rootCmd.AddCommand(fmtCmd)
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "list files that are not formatted and exit with a non-zero status instead of rewriting them")
+	fmtCmd.Flags().BoolVar(&fmtDiff, "diff", false, "print a diff of the formatting changes instead of rewriting files")
 }
 
 var fmtCmd = &cobra.Command{
-	Use:   "fmt",
+	Use:   "fmt [paths...]",
 	Short: "auto-format your yok code",
+	Long: `auto-format your yok code
+
+Directories are searched recursively for .yok files.
+Use - as the only path to read yok code from stdin and write the formatted code to stdout.`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		srcFile := args[0]
+		cmd.SilenceUsage = true
 
-		yokCode, err := os.ReadFile(srcFile)
+		if slices.Contains(args, "-") {
+			if len(args) > 1 {
+				return errors.New("- can not be used with other paths")
+			}
+
+			return formatStdin(cmd.InOrStdin(), cmd.OutOrStdout())
+		}
+
+		files, err := findYokFiles(args)
 		if err != nil {
 			return err
 		}
 
-		p := parser.New(yokCode)
-		script, err := p.Parse()
-		if err != nil {
-			for _, e := range p.Errors {
-				fmt.Println(e)
+		failed := 0
+		unformatted := 0
+		for _, file := range files {
+			changed, err := formatFile(file, cmd.OutOrStdout())
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", file, err)
+				failed++
+				continue
+			}
+
+			if changed {
+				unformatted++
 			}
-			return err
 		}
 
-		formatedCode := genyok.Generate(script, yokCode)
-		err = os.WriteFile(srcFile, []byte(formatedCode), 0o0655)
-		if err != nil {
-			return err
+		if failed > 0 {
+			return errors.New(fmt.Sprintf("failed to format %d file(s)", failed))
+		}
+
+		if fmtCheck && unformatted > 0 {
+			return errors.New(fmt.Sprintf("%d file(s) are not formatted", unformatted))
 		}
 
 		return nil
 	},
 }
+
+// formatYok formats the yok code, all the parsing errors are joined into the returned error
+func formatYok(yokCode []byte) ([]byte, error) {
+	p := parser.New(yokCode)
+	script, err := p.Parse()
+	if err != nil {
+		msg := err.Error()
+		for _, e := range p.Errors {
+			msg += "\n\t" + e.Error()
+		}
+		return nil, errors.New(msg)
+	}
+
+	return []byte(genyok.Generate(script, yokCode)), nil
+}
+
+// formatFile formats the given file. It returns true if the formatted code is different from
+// the code in the file. Files are only rewritten if neither the --check or --diff flags are set
+func formatFile(file string, out io.Writer) (bool, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+
+	yokCode, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+
+	formatedCode, err := formatYok(yokCode)
+	if err != nil {
+		return false, err
+	}
+
+	if bytes.Equal(yokCode, formatedCode) {
+		return false, nil
+	}
+
+	if fmtCheck {
+		fmt.Fprintln(out, file)
+	}
+
+	if fmtDiff {
+		printDiff(out, file, yokCode, formatedCode)
+	}
+
+	if fmtCheck || fmtDiff {
+		return true, nil
+	}
+
+	// keep the original file mode so executable scripts stay executable
+	err = os.WriteFile(file, formatedCode, info.Mode().Perm())
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// formatStdin formats yok code from in and writes the formatted code to out
+func formatStdin(in io.Reader, out io.Writer) error {
+	yokCode, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	formatedCode, err := formatYok(yokCode)
+	if err != nil {
+		return err
+	}
+
+	changed := !bytes.Equal(yokCode, formatedCode)
+	switch {
+	case fmtDiff:
+		if changed {
+			printDiff(out, "<stdin>", yokCode, formatedCode)
+		}
+	case !fmtCheck:
+		_, err = out.Write(formatedCode)
+		if err != nil {
+			return err
+		}
+	}
+
+	if fmtCheck && changed {
+		return errors.New("<stdin> is not formatted")
+	}
+
+	return nil
+}
+
+// printDiff prints a unified diff between the original and formatted code
+func printDiff(out io.Writer, file string, original, formatted []byte) {
+	d := diff.Unified(file, file+" (formatted)", string(original), string(formatted), 3)
+	fmt.Fprint(out, d)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	unformattedYok = "let  a   =   :1\nprint( a )\n"
+	formattedYok   = "let a = :1\nprint(a)\n"
+)
+
+// runFmt runs yok fmt with the flags and arguments and returns what it wrote to stdout
+func runFmt(t *testing.T, check, diff bool, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	fmtCheck, fmtDiff = check, diff
+	t.Cleanup(func() { fmtCheck, fmtDiff = false, false })
+
+	stdout := bytes.Buffer{}
+	fmtCmd.SetIn(strings.NewReader(stdin))
+	fmtCmd.SetOut(&stdout)
+	fmtCmd.SetErr(&bytes.Buffer{})
+	t.Cleanup(func() {
+		fmtCmd.SetIn(nil)
+		fmtCmd.SetOut(nil)
+		fmtCmd.SetErr(nil)
+	})
+
+	err := fmtCmd.RunE(fmtCmd, args)
+	return stdout.String(), err
+}
+
+// readFile returns the content of the file or fails the test
+func readFile(t *testing.T, file string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal("failed to read file", err)
+	}
+
+	return string(content)
+}
+
+func TestFmt(t *testing.T) {
+	files := map[string]string{
+		"formatted.yok":        formattedYok,
+		"scripts/a.yok":        unformattedYok,
+		"scripts/nested/b.yok": unformattedYok,
+		"scripts/notes.txt":    unformattedYok,
+	}
+
+	tests := []struct {
+		name       string
+		check      bool
+		diff       bool
+		args       []string
+		wantStdout string
+		wantErr    string
+		// wantFiles is the content of the files after yok fmt has run
+		wantFiles map[string]string
+	}{
+		{
+			name: "directories are formatted recursively",
+			args: []string{"scripts", "formatted.yok"},
+			wantFiles: map[string]string{
+				"formatted.yok":        formattedYok,
+				"scripts/a.yok":        formattedYok,
+				"scripts/nested/b.yok": formattedYok,
+				"scripts/notes.txt":    unformattedYok,
+			},
+		},
+		{
+			name:       "check lists unformatted files",
+			check:      true,
+			args:       []string{"scripts", "formatted.yok"},
+			wantStdout: filepath.Join("scripts", "a.yok") + "\n" + filepath.Join("scripts", "nested", "b.yok") + "\n",
+			wantErr:    "2 file(s) are not formatted",
+			wantFiles:  files,
+		},
+		{
+			name:      "check passes formatted files",
+			check:     true,
+			args:      []string{"formatted.yok"},
+			wantFiles: files,
+		},
+		{
+			name: "diff prints the changes",
+			diff: true,
+			args: []string{filepath.Join("scripts", "a.yok"), "formatted.yok"},
+			wantStdout: "--- scripts/a.yok\n+++ scripts/a.yok (formatted)\n@@ -1,2 +1,2 @@\n" +
+				"-let  a   =   :1\n-print( a )\n+let a = :1\n+print(a)\n",
+			wantFiles: files,
+		},
+		{
+			name:      "stdin can not be mixed with paths",
+			args:      []string{"-", "scripts"},
+			wantErr:   "- can not be used with other paths",
+			wantFiles: files,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chdirTemp(t, files)
+
+			stdout, err := runFmt(t, tt.check, tt.diff, "", tt.args...)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("fmt error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("fmt error = %v", err)
+			}
+
+			if stdout != tt.wantStdout {
+				t.Errorf("fmt stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+
+			for file, want := range tt.wantFiles {
+				if got := readFile(t, file); got != want {
+					t.Errorf("fmt %s = %q, want %q", file, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFmt_Stdin(t *testing.T) {
+	stdout, err := runFmt(t, false, false, unformattedYok, "-")
+	if err != nil {
+		t.Fatalf("fmt error = %v", err)
+	}
+
+	if stdout != formattedYok {
+		t.Errorf("fmt stdout = %q, want %q", stdout, formattedYok)
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bjatkin/yok/repl"
+)
+
+// replShell is the shell set by the --shell flag
+var replShell string
+
+func init() {
+	rootCmd.AddCommand(replCmd)
+	replCmd.Flags().StringVar(&replShell, "shell", "/bin/sh", "the shell that runs the compiled code")
+}
+
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Short: "start an interactive yok session",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shell, err := repl.StartShell(replShell, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		defer shell.Close()
+
+		return repl.New(os.Stdin, os.Stdout, shell).Run()
+	},
+}
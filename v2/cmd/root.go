@@ -12,8 +12,19 @@ var rootCmd = &cobra.Command{
 	Short: "the command line to for working with the Yok programming language",
 }
 
+// exitStatus is returned by commands that run a script, yok exits with the same status as the script
+type exitStatus int
+
+func (e exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		if status, ok := err.(exitStatus); ok {
+			os.Exit(int(status))
+		}
+
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -7,10 +7,29 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/bjatkin/yok/codegen/target"
+	"github.com/bjatkin/yok/compiler"
+	"github.com/bjatkin/yok/interp"
+	"github.com/bjatkin/yok/optimize"
+	"github.com/bjatkin/yok/sourcemap"
 )
 
+// runOptions are the compiler options set by the run flags
+var runOptions compiler.Options
+
+// runOptimize is the optimization level set by the -O flag
+var runOptimize int
+
+// runInterp is set by the --interp flag
+var runInterp bool
+
 func init() {
 	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().IntVarP(&runOptimize, "optimize", "O", 0, "the optimization level, -O0 disables optimizations and -O1 folds constants and removes dead code")
+	runCmd.Flags().BoolVar(&runOptions.Strict, "strict", false, "start the script with set -eu, and set -o pipefail if the target supports it")
+	runCmd.Flags().Var(&runOptions.Target, "target", "the shell to run the script with, one of "+target.Names())
+	runCmd.Flags().BoolVar(&runInterp, "interp", false, "run the script with the built in interpreter instead of a shell, only builtin commands are available")
 }
 
 var runCmd = &cobra.Command{
@@ -25,7 +44,16 @@ var runCmd = &cobra.Command{
 			return err
 		}
 
-		shCode, err := complieYok(yokCode)
+		if runInterp {
+			status, err := interpYok(srcFile, yokCode)
+			if err != nil {
+				return err
+			}
+
+			return scriptStatus(cmd, status)
+		}
+
+		shCode, sourceMap, err := complieYok(os.Stderr, srcFile, yokCode, runOptions, optimize.Level(runOptimize))
 		if err != nil {
 			return err
 		}
@@ -34,23 +62,63 @@ var runCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		// try to remove the temp file
+		defer os.Remove(shFileName)
+
+		// translate sh error messages so they point back at the yok source
+		stderr := sourcemap.NewWriter(os.Stderr, shFileName, sourceMap)
+		defer stderr.Flush()
 
 		shCmd := exec.CommandContext(cmd.Context(), shFileName, args[1:]...)
 		shCmd.Stdout = os.Stdout
-		shCmd.Stderr = os.Stderr
+		shCmd.Stderr = stderr
 		shCmd.Stdin = os.Stdin
 		err = shCmd.Run()
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+			return scriptStatus(cmd, exitErr.ExitCode())
+		}
 		if err != nil {
 			return err
 		}
 
-		// try to remove the temp file
-		_ = os.Remove(shFileName)
-
 		return nil
 	},
 }
 
+// scriptStatus returns an error if the script failed so yok exits with the same status as the script.
+// The script has already reported why it failed, so cobra does not print the error or the usage
+func scriptStatus(cmd *cobra.Command, status int) error {
+	if status == 0 {
+		return nil
+	}
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return exitStatus(status)
+}
+
+// interpYok runs the yok code with the interpreter and returns the exit status of the script.
+// The script can read files in the working directory but can only run the commands that are built into the interpreter
+func interpYok(srcFile string, yokCode []byte) (int, error) {
+	shAst, err := compileShast(os.Stderr, srcFile, yokCode, runOptions, optimize.Level(runOptimize))
+	if err != nil {
+		return 0, err
+	}
+
+	env := map[string]string{}
+	for _, v := range os.Environ() {
+		name, value, _ := strings.Cut(v, "=")
+		env[name] = value
+	}
+
+	i := interp.New(env)
+	i.Stdin = os.Stdin
+	i.Stdout = os.Stdout
+	i.Stderr = os.Stderr
+	i.FS = os.DirFS(".")
+	return i.Run(shAst)
+}
+
 func writeTempScript(yokFileName string, shCode []byte) (string, error) {
 	yokBase := path.Base(yokFileName)
 	yokPrefix := strings.TrimSuffix(yokBase, ".yok")
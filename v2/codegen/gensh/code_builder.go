@@ -3,6 +3,8 @@ package gensh
 import (
 	"fmt"
 	"strings"
+
+	"github.com/bjatkin/yok/token"
 )
 
 // indentToken is used as the indent string for the rendered code
@@ -12,6 +14,8 @@ const indentToken = "    "
 // any number of children units
 type codeUnit struct {
 	line     string
+	pos      token.Pos
+	mapped   bool
 	children []codeUnit
 }
 
@@ -21,15 +25,31 @@ func newCodeUnitf(format string, a ...any) codeUnit {
 	return codeUnit{line: line}
 }
 
+// newMappedCodeUnitf creates a new codeUnit from a format string and arguments.
+// The unit is mapped back to the given position in the yok source, unless it's token.NoPos
+func newMappedCodeUnitf(pos token.Pos, format string, a ...any) codeUnit {
+	unit := newCodeUnitf(format, a...)
+	unit.pos = pos
+	unit.mapped = pos != token.NoPos
+	return unit
+}
+
 // addChildren adds the given code units as children of the parent unit
 func (f *codeUnit) addChildren(units []codeUnit) {
 	f.children = append(f.children, units...)
 }
 
-// render the code unit into a slice of strings
-func (f codeUnit) render(depth int) []string {
+// renderedLine is a single line of rendered code
+type renderedLine struct {
+	text   string
+	pos    token.Pos
+	mapped bool
+}
+
+// render the code unit into a slice of lines
+func (f codeUnit) render(depth int) []renderedLine {
 	indent := strings.Repeat(indentToken, depth)
-	lines := []string{indent + f.line}
+	lines := []renderedLine{{text: indent + f.line, pos: f.pos, mapped: f.mapped}}
 	for _, child := range f.children {
 		childLines := child.render(depth + 1)
 		lines = append(lines, childLines...)
@@ -55,6 +75,12 @@ func newCodeBuilder(lines ...string) codeBuilder {
 	return codeBuilder{units: units}
 }
 
+// newMappedCodeBuilder creates a new codeBuilder with a single line that
+// maps back to the given position in the yok source
+func newMappedCodeBuilder(pos token.Pos, line string) codeBuilder {
+	return codeBuilder{units: []codeUnit{newMappedCodeUnitf(pos, "%s", line)}}
+}
+
 // addLine adds a new unit to the codeBuilder with the given line
 func (s *codeBuilder) addLine(line string) *codeUnit {
 	unit := codeUnit{line: line}
@@ -74,12 +100,27 @@ func (s *codeBuilder) addUnits(unit []codeUnit) {
 
 // render the codeUnits into well formated text
 func (s codeBuilder) render() string {
-	units := []string{}
+	code, _ := s.renderWithSourceMap()
+	return code
+}
+
+// renderWithSourceMap renders the codeUnits into well formated text. It also returns a map
+// of the 1 based line numbers in the rendered text to the position of the yok code that generated them
+func (s codeBuilder) renderWithSourceMap() (string, map[int]token.Pos) {
+	lines := []string{}
+	positions := map[int]token.Pos{}
+	lineNumber := 1
 	for _, fragment := range s.units {
-		lines := fragment.render(0)
-		unit := strings.Join(lines, "\n")
-		units = append(units, unit)
+		for _, line := range fragment.render(0) {
+			lines = append(lines, line.text)
+			if line.mapped {
+				positions[lineNumber] = line.pos
+			}
+
+			// multi line strings span several lines of the rendered code
+			lineNumber += strings.Count(line.text, "\n") + 1
+		}
 	}
 
-	return strings.Join(units, "\n")
+	return strings.Join(lines, "\n"), positions
 }
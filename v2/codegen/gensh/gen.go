@@ -5,34 +5,70 @@ import (
 	"strings"
 
 	"github.com/bjatkin/yok/ast/shast"
+	"github.com/bjatkin/yok/codegen/target"
+	"github.com/bjatkin/yok/token"
 )
 
-// Generate takes a shast.Script and renderes it into a well formated shell script
+// Generate takes a shast.Script and renderes it into a well formated POSIX shell script
 func Generate(script *shast.Script) string {
-	scriptBuilder := newCodeBuilder("#!/bin/sh", "")
+	return New(target.POSIX).Generate(script)
+}
 
-	bodyBuilder := generateStmts(script.Statements)
-	scriptBuilder.addUnits(bodyBuilder.units)
+// GenerateWithSourceMap takes a shast.Script and renders it into a well formated POSIX shell script.
+// It also returns a map from the 1 based line numbers of the script to the yok source positions
+// that generated them. Lines that were not generated from yok code (e.g. the shebang) are not included
+func GenerateWithSourceMap(script *shast.Script) (string, map[int]token.Pos) {
+	return New(target.POSIX).GenerateWithSourceMap(script)
+}
+
+// Generator renders shell scripts for a specific target shell
+type Generator struct {
+	target target.Target
+}
+
+// New creates a new Generator for the target shell
+func New(t target.Target) *Generator {
+	return &Generator{target: t}
+}
 
+// Generate takes a shast.Script and renderes it into a well formated shell script
+func (g *Generator) Generate(script *shast.Script) string {
+	scriptBuilder := g.generateScript(script)
 	return scriptBuilder.render()
 }
 
+// GenerateWithSourceMap takes a shast.Script and renders it into a well formated shell script
+// along with the map from line numbers to yok source positions (see GenerateWithSourceMap)
+func (g *Generator) GenerateWithSourceMap(script *shast.Script) (string, map[int]token.Pos) {
+	scriptBuilder := g.generateScript(script)
+	return scriptBuilder.renderWithSourceMap()
+}
+
+// generateScript converts a shast.Script into a codeBuilder
+func (g *Generator) generateScript(script *shast.Script) codeBuilder {
+	scriptBuilder := newCodeBuilder(g.target.Shebang(), "")
+
+	bodyBuilder := g.generateStmts(script.Statements)
+	scriptBuilder.addUnits(bodyBuilder.units)
+
+	return scriptBuilder
+}
+
 // generateExpr takes an shast.Expr and renders it into a well formated shell string
-func generateExpr(expr shast.Expr) string {
+func (g *Generator) generateExpr(expr shast.Expr) string {
 	switch expr := expr.(type) {
 	case *shast.String:
-		value := expr.Value
-		if !strings.Contains(expr.Value, " ") &&
-			!strings.Contains(expr.Value, "\t") {
-			// if the string has no white space, sh allows for dropping the double quotes
-			value = strings.TrimPrefix(value, "\"")
-			value = strings.TrimSuffix(value, "\"")
+		if g.target.Supports(target.ANSIQuotes) {
+			return quoteStringANSI(expr.Value)
 		}
-		return value
+
+		return quoteString(expr.Value)
+	case *shast.Pattern:
+		return quotePattern(expr.Value)
 	case *shast.Exec:
 		args := []string{}
 		for _, arg := range expr.Arguments {
-			n := generateExpr(arg)
+			n := g.generateExpr(arg)
 			args = append(args, n)
 		}
 
@@ -40,82 +76,110 @@ func generateExpr(expr shast.Expr) string {
 			args = append(args, redirect.String())
 		}
 
-		return expr.Command + " " + strings.Join(args, " ")
-	case *shast.Identifier:
-		if expr.Quoted {
-			return "\"$" + expr.Value + "\""
+		env := ""
+		for _, assign := range expr.Env {
+			env += assign.Name + "=" + g.generateExpr(assign.Value) + " "
 		}
 
-		return "$" + expr.Value
+		return env + expr.Command + " " + strings.Join(args, " ")
+	case *shast.Identifier:
+		return quoteExpansion("$"+expr.Value, expr.Quoted)
 	case *shast.ArithmeticCommand:
-		inner := generateExpr(expr.Expression)
+		inner := g.generateExpr(expr.Expression)
 		return "$(( " + inner + " ))"
 	case *shast.InfixExpr:
-		left := generateExpr(expr.Left)
-		right := generateExpr(expr.Right)
+		left := g.generateExpr(expr.Left)
+		right := g.generateExpr(expr.Right)
 		return fmt.Sprintf("%s %s %s", left, expr.Operator, right)
 	case *shast.GroupExpr:
-		inner := generateExpr(expr.Expression)
+		inner := g.generateExpr(expr.Expression)
 		return "( " + inner + " )"
 	case *shast.ParamaterExpansion:
-		expression := generateParamaterExpr(expr.Expression)
-		return "${" + expression + "}"
+		expression := g.generateParamaterExpr(expr.Expression, expr.Quoted)
+		return quoteExpansion("${"+expression+"}", expr.Quoted)
 	case *shast.TestCommand:
-		test := generateExpr(expr.Expression)
+		test := g.generateExpr(expr.Expression)
+		if g.target.Supports(target.DoubleBrackets) {
+			return "[[ " + test + " ]]"
+		}
+
 		return "[ " + test + " ]"
 	case *shast.CommandSub:
-		cmd := generateExpr(expr.Expression)
-		return "$(" + cmd + ")"
+		cmd := g.generateExpr(expr.Expression)
+		return quoteExpansion("$("+cmd+")", expr.Quoted)
 	default:
 		panic(fmt.Sprintf("can not gen sh code, unknown expr type %T", expr))
 	}
 }
 
-func generateParamaterExpr(expr shast.ParamaterExpr) string {
+// generateParamaterExpr renders the inside of a paramater expansion. quoted is true if the expansion
+// is inside double quotes, single quotes are not special there so string defaults are escaped instead
+func (g *Generator) generateParamaterExpr(expr shast.ParamaterExpr, quoted bool) string {
 	switch expr := expr.(type) {
 	case *shast.ParameterLength:
 		return "#" + expr.Paramater.Value
+	case *shast.ParamaterDefault:
+		if value, ok := expr.Default.(*shast.String); ok && quoted {
+			return expr.Paramater.Value + ":-" + escapeDoubleQuoted(value.Value)
+		}
+
+		return expr.Paramater.Value + ":-" + g.generateExpr(expr.Default)
+	case *shast.ParamaterUnset:
+		return expr.Paramater.Value + "-"
 	case *shast.ParamaterRemoveFix:
-		remove := generateExpr(expr.Remove)
+		remove := g.generateExpr(expr.Remove)
+		op := "%"
 		if expr.RemovePrefix {
-			return expr.Paramater.Value + "##" + remove
+			op = "#"
+		}
+		if !expr.Shortest {
+			op += op
 		}
 
-		return expr.Paramater.Value + "%%" + remove
+		return expr.Paramater.Value + op + remove
 	default:
 		panic(fmt.Sprintf("can not get sh code, unknown paramater expr type %T", expr))
 	}
 }
 
 // generateStmt takes an shast.Stmt and converts it into a codeBuilder
-func generateStmt(stmt shast.Stmt) codeBuilder {
+func (g *Generator) generateStmt(stmt shast.Stmt) codeBuilder {
 	switch stmt := stmt.(type) {
 	case *shast.Comment:
-		return newCodeBuilder(stmt.Value)
+		return newMappedCodeBuilder(stmt.Pos, stmt.Value)
 	case *shast.NewLine:
 		return newCodeBuilder("")
 	case *shast.Assign:
-		value := generateExpr(stmt.Value)
-		return newCodeBuilder(stmt.Identifier + "=" + value)
+		value := g.generateExpr(stmt.Value)
+		line := stmt.Identifier + "=" + value
+		if stmt.Export {
+			line = "export " + line
+		}
+		if stmt.Readonly {
+			line = "readonly " + line
+		}
+		line = withComment(line, stmt.Comment)
+		return newMappedCodeBuilder(stmt.Pos, line)
 	case *shast.StmtExpr:
-		expr := generateExpr(stmt.Expression)
-		return newCodeBuilder(expr)
+		expr := g.generateExpr(stmt.Expression)
+		line := withComment(expr, stmt.Comment)
+		return newMappedCodeBuilder(stmt.Pos, line)
 	case *shast.If:
-		test := generateExpr(stmt.Test)
-		ifUnit := newCodeUnitf("if %s; then", test)
+		test := g.generateExpr(stmt.Test)
+		ifUnit := newMappedCodeUnitf(stmt.Pos, "if %s; then", test)
 
 		for _, stmt := range stmt.Statements {
-			line := generateStmt(stmt)
+			line := g.generateStmt(stmt)
 			ifUnit.addChildren(line.units)
 		}
 
 		ifBuilder := codeBuilder{}
 		ifBuilder.addUnit(ifUnit)
 		for _, elseIf := range stmt.ElseIfs {
-			test := generateExpr(elseIf.Test)
-			elseIfUnit := newCodeUnitf("elif %s; then", test)
+			test := g.generateExpr(elseIf.Test)
+			elseIfUnit := newMappedCodeUnitf(elseIf.Pos, "elif %s; then", test)
 
-			bodyBuilder := generateStmts(stmt.Statements)
+			bodyBuilder := g.generateStmts(elseIf.Statements)
 			elseIfUnit.addChildren(bodyBuilder.units)
 
 			ifBuilder.addUnit(elseIfUnit)
@@ -123,25 +187,44 @@ func generateStmt(stmt shast.Stmt) codeBuilder {
 
 		if stmt.ElseStatements != nil {
 			elseUnit := codeUnit{line: "else"}
-			bodyBuilder := generateStmts(stmt.ElseStatements)
+			bodyBuilder := g.generateStmts(stmt.ElseStatements)
 			elseUnit.addChildren(bodyBuilder.units)
 
 			ifBuilder.addUnit(elseUnit)
 		}
 
-		ifBuilder.addLine("fi")
+		ifBuilder.addLine(withComment("fi", stmt.Comment))
 		return ifBuilder
+	case *shast.Function:
+		funcUnit := newCodeUnitf("%s() {", stmt.Name)
+		for _, line := range stmt.Body {
+			funcUnit.addChildren([]codeUnit{{line: line}})
+		}
+
+		builder := codeBuilder{}
+		builder.addUnit(funcUnit)
+		builder.addLine("}")
+		return builder
 
 	default:
 		panic(fmt.Sprintf("can not gen sh code, unknown stmt type %T", stmt))
 	}
 }
 
+// withComment adds the trailing comment to the end of the line if there is one
+func withComment(line string, comment *shast.Comment) string {
+	if comment == nil {
+		return line
+	}
+
+	return line + " " + comment.Value
+}
+
 // generateStmts takes a slice of shast.Stmt and converts it into a codeBuilder
-func generateStmts(statements []shast.Stmt) codeBuilder {
+func (g *Generator) generateStmts(statements []shast.Stmt) codeBuilder {
 	builder := codeBuilder{}
 	for _, stmt := range statements {
-		line := generateStmt(stmt)
+		line := g.generateStmt(stmt)
 		builder.addUnits(line.units)
 	}
 	return builder
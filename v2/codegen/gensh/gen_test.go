@@ -1,15 +1,26 @@
 package gensh
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/bjatkin/yok/codegen/target"
 	"github.com/bjatkin/yok/compiler"
 	"github.com/bjatkin/yok/diff"
 	"github.com/bjatkin/yok/parser"
+	"github.com/bjatkin/yok/sourcemap"
+	"github.com/bjatkin/yok/token"
 )
 
+func TestMain(m *testing.M) {
+	os.Exit(diff.RunWithOrphanCheck(m, "testdata", "*.sh"))
+}
+
 func TestGenerate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -41,11 +52,66 @@ func TestGenerate(t *testing.T) {
 			yokFile: "string_builtins.yok",
 			shFile:  "string_builtins.sh",
 		},
-		// {
-		// 	name:    "nested expressions",
-		// 	yokFile: "nested_expressions.yok",
-		// 	shFile:  "nested_expressions.sh",
-		// },
+		{
+			name:    "nested expressions",
+			yokFile: "nested_expressions.yok",
+			shFile:  "nested_expressions.sh",
+		},
+		{
+			name:    "names",
+			yokFile: "names.yok",
+			shFile:  "names.sh",
+		},
+		{
+			name:    "env",
+			yokFile: "env.yok",
+			shFile:  "env.sh",
+		},
+		{
+			name:    "escapes",
+			yokFile: "escapes.yok",
+			shFile:  "escapes.sh",
+		},
+		{
+			name:    "print",
+			yokFile: "print.yok",
+			shFile:  "print.sh",
+		},
+		{
+			name:    "string library",
+			yokFile: "string_library.yok",
+			shFile:  "string_library.sh",
+		},
+		{
+			name:    "remove fix",
+			yokFile: "remove_fix.yok",
+			shFile:  "remove_fix.sh",
+		},
+		{
+			name:    "patterns",
+			yokFile: "patterns.yok",
+			shFile:  "patterns.sh",
+		},
+		{
+			name:    "consts",
+			yokFile: "consts.yok",
+			shFile:  "consts.sh",
+		},
+		{
+			name:    "types",
+			yokFile: "types.yok",
+			shFile:  "types.sh",
+		},
+		{
+			name:    "strict",
+			yokFile: "strict.yok",
+			shFile:  "strict.sh",
+		},
+		{
+			name:    "if nested calls",
+			yokFile: "if_nested_calls.yok",
+			shFile:  "if_nested_calls.sh",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -77,3 +143,333 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerate_ReadonlyConsts(t *testing.T) {
+	source := []byte("const n = :10 * :2 # the limit\nprint(n)\n")
+	p := parser.New(source)
+	script, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Generate() failed to parse source %v", p.Errors)
+	}
+
+	c := compiler.NewWithOptions(source, compiler.Options{ReadonlyConsts: true})
+	shAst, err := c.Compile(script)
+	if err != nil {
+		t.Fatalf("Generate() failed to compile source %v", c.Errors())
+	}
+
+	want := "#!/bin/sh\n\nreadonly YOK_N=20 # the limit\nprintf '%s\\n' 20"
+	if got := Generate(shAst); got != want {
+		t.Errorf("Generate() = %q, want %q", got, want)
+	}
+}
+
+func TestGenerate_TypeCheck(t *testing.T) {
+	source := []byte("let a int = :7\nif a == :07 {\n\tprint(a)\n}\n")
+	p := parser.New(source)
+	script, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Generate() failed to parse source %v", p.Errors)
+	}
+
+	c := compiler.NewWithOptions(source, compiler.Options{TypeCheck: true})
+	shAst, err := c.Compile(script)
+	if err != nil {
+		t.Fatalf("Generate() failed to compile source %v", c.Errors())
+	}
+
+	want := "#!/bin/sh\n\nYOK_A=7\nif [ \"$YOK_A\" -eq 07 ]; then\n    printf '%s\\n' \"$YOK_A\"\nfi"
+	if got := Generate(shAst); got != want {
+		t.Errorf("Generate() = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateWithSourceMap(t *testing.T) {
+	source := []byte("let lines = \"one\\ntwo\"\nprint(lines)\n")
+	p := parser.New(source)
+	script, err := p.Parse()
+	if err != nil {
+		t.Fatalf("GenerateWithSourceMap() failed to parse source %v", p.Errors)
+	}
+
+	c := compiler.New(source)
+	shAst, err := c.Compile(script)
+	if err != nil {
+		t.Fatalf("GenerateWithSourceMap() failed to compile source %v", c.Errors())
+	}
+
+	_, positions := GenerateWithSourceMap(shAst)
+
+	// the multi line string takes up lines 3 and 4 so the print statement is on line 5
+	want := map[int]token.Pos{3: 0, 5: 23}
+	if !reflect.DeepEqual(positions, want) {
+		t.Errorf("GenerateWithSourceMap() positions = %v, want %v", positions, want)
+	}
+}
+
+func TestGenerateWithSourceMap_HelperError(t *testing.T) {
+	source := []byte("#yok:strict\nlet name = \"yok\"\nprint(upper(name))\n")
+	p := parser.New(source)
+	script, err := p.Parse()
+	if err != nil {
+		t.Fatalf("GenerateWithSourceMap() failed to parse source %v", p.Errors)
+	}
+
+	c := compiler.New(source)
+	shAst, err := c.Compile(script)
+	if err != nil {
+		t.Fatalf("GenerateWithSourceMap() failed to compile source %v", c.Errors())
+	}
+
+	code, positions := GenerateWithSourceMap(shAst)
+	helperLine := slices.IndexFunc(strings.Split(code, "\n"), func(line string) bool {
+		return strings.Contains(line, "| tr")
+	}) + 1
+	if helperLine == 0 {
+		t.Fatalf("GenerateWithSourceMap() did not generate the upper helper:\n%s", code)
+	}
+
+	// set -eu comes before the helper but it was added by the compiler, so it must not be blamed for the error
+	m := sourcemap.New("test.yok", source, positions)
+	msg := fmt.Sprintf("/tmp/test.sh: %d: tr: not found", helperLine)
+	if got := m.Translate("/tmp/test.sh", msg); got != msg {
+		t.Errorf("Map.Translate() = %v, want %v", got, msg)
+	}
+}
+
+func TestCompile_StringBuiltins(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "replace glob characters",
+			source: `print(replace("a*b*c", "*", "[?]"))`,
+			want:   "a[?]b*c\n",
+		},
+		{
+			name:   "replace missing sub string",
+			source: `print(replace("abc", "x", "y"))`,
+			want:   "abc\n",
+		},
+		{
+			name:   "replace all",
+			source: `print(replace_all("Jay Jay Jay!", "Jay", "Lex"))`,
+			want:   "Lex Lex Lex!\n",
+		},
+		{
+			name:   "replace all with an empty sub string",
+			source: `print(replace_all("abc", "", "-"))`,
+			want:   "abc\n",
+		},
+		{
+			name:   "replace all special characters",
+			source: `print(replace_all("a\\b\\c", "\\", "\$1"))`,
+			want:   "a$1b$1c\n",
+		},
+		{
+			name:   "or default with a dollar sign",
+			source: `print(env("YOK_UNSET") or "cost $5")`,
+			want:   "cost $5\n",
+		},
+		{
+			name:   "or default with quotes",
+			source: `print(env("YOK_UNSET") or "it's \"quoted\" {ok}")`,
+			want:   "it's \"quoted\" {ok}\n",
+		},
+		{
+			name:   "or default with backslashes and back ticks",
+			source: "print(env(\"YOK_UNSET\") or \"a\\\\b `date`\")",
+			want:   "a\\b `date`\n",
+		},
+		{
+			name:   "or default with a bang",
+			source: `print(env("YOK_UNSET") or "hi!")`,
+			want:   "hi!\n",
+		},
+		{
+			name:   "split",
+			source: `print(split("a::b::c", "::"))`,
+			want:   "a\nb\nc\n",
+		},
+		{
+			name:   "upper and lower",
+			source: `print(upper("Hello-World"), lower("Hello-World"))`,
+			want:   "HELLO-WORLD hello-world\n",
+		},
+		{
+			name:   "trim",
+			source: `print(trim(" \t hello  world\n "))`,
+			want:   "hello  world\n",
+		},
+		{
+			name:   "trim white space",
+			source: `print(trim("   "), end="|")`,
+			want:   "|",
+		},
+		{
+			name:   "contains",
+			source: `print(contains("a*b", "*"), contains("ab", "*"))`,
+			want:   "true false\n",
+		},
+		{
+			name:   "starts with and ends with",
+			source: `print(starts_with("?ab", "?"), starts_with("ab", "?"), ends_with("ab]", "]"), ends_with("ab", "a"))`,
+			want:   "true false true false\n",
+		},
+		{
+			name:   "len of an expression",
+			source: `print(len(replace_all("a-b-c", "-", "")))`,
+			want:   "3\n",
+		},
+		{
+			name:   "remove prefix of an expression",
+			source: `print(remove_prefix(upper("a*b*c"), "A*"))`,
+			want:   "B*C\n",
+		},
+		{
+			name:   "remove suffix with a variable",
+			source: "let ext = \".gz\"\nprint(remove_suffix(\"a.gz.gz\", ext, shortest=:true))",
+			want:   "a.gz\n",
+		},
+		{
+			name:   "matches",
+			source: `print(matches("a b.txt", '* *.txt'), matches("a.txt", '[!a]*'), matches("*", "*"), matches("ab", "a"))`,
+			want:   "true false true false\n",
+		},
+		{
+			name:   "remove a pattern",
+			source: `print(remove_suffix("a b.tar.gz", '.*'), remove_suffix("a b.tar.gz", '.*', shortest=:true))`,
+			want:   "a b a b.tar\n",
+		},
+		{
+			name:   "remove a pattern with quoted literals",
+			source: `print(remove_prefix("$HOME/a b/c", '$HOME/? '))`,
+			want:   "b/c\n",
+		},
+		{
+			name:   "remove a string with glob characters",
+			source: `print(remove_prefix("*a*b", "*a"))`,
+			want:   "*b\n",
+		},
+		{
+			name:   "test with a builtin",
+			source: "if contains(\"hello\", \"ell\") {\n\tprint(:yes)\n} else {\n\tprint(:no)\n}",
+			want:   "yes\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := []byte(tt.source + "\n")
+			p := parser.New(source)
+			script, err := p.Parse()
+			if err != nil {
+				t.Fatalf("failed to parse %q: %v", source, p.Errors)
+			}
+
+			// every target must print the same output, targets are skipped if their shell is not installed
+			for _, shell := range []target.Target{target.POSIX, target.Bash, target.Dash, target.Ash, target.Zsh} {
+				t.Run(shell.String(), func(t *testing.T) {
+					c := compiler.NewWithOptions(source, compiler.Options{Target: shell})
+					shAst, err := c.Compile(script)
+					if err != nil {
+						t.Fatalf("failed to compile %q: %v", source, c.Errors())
+					}
+
+					name := shell.String()
+					if shell == target.POSIX {
+						name = "sh"
+					}
+
+					if got := runShell(t, name, New(shell).Generate(shAst)); got != tt.want {
+						t.Errorf("compiled %q printed %q, want %q", source, got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestCompile_Strict(t *testing.T) {
+	source, err := os.ReadFile(filepath.Join("..", "..", "testdata", "strict.yok"))
+	if err != nil {
+		t.Fatal("failed to read source file", err)
+	}
+
+	// strict mode must not stop the script because of code that was generated by the compiler
+	for _, shell := range []target.Target{target.POSIX, target.Bash, target.Dash, target.Ash, target.Zsh} {
+		t.Run(shell.String(), func(t *testing.T) {
+			p := parser.New(source)
+			script, err := p.Parse()
+			if err != nil {
+				t.Fatalf("failed to parse source %v", p.Errors)
+			}
+
+			c := compiler.NewWithOptions(source, compiler.Options{Target: shell})
+			shAst, err := c.Compile(script)
+			if err != nil {
+				t.Fatalf("failed to compile source %v", c.Errors())
+			}
+
+			name := shell.String()
+			if shell == target.POSIX {
+				name = "sh"
+			}
+
+			want := " less 0 2\n"
+			if got := runShell(t, name, New(shell).Generate(shAst)); got != want {
+				t.Errorf("strict.yok printed %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestGenerate_StrictPipefail(t *testing.T) {
+	source := []byte("print(:hi)\n")
+	p := parser.New(source)
+	script, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Generate() failed to parse source %v", p.Errors)
+	}
+
+	c := compiler.NewWithOptions(source, compiler.Options{Strict: true, Target: target.Bash})
+	shAst, err := c.Compile(script)
+	if err != nil {
+		t.Fatalf("Generate() failed to compile source %v", c.Errors())
+	}
+
+	want := "#!/usr/bin/env bash\n\nset -eu\nset -o pipefail\n\nprintf '%s\\n' hi"
+	if got := New(target.Bash).Generate(shAst); got != want {
+		t.Errorf("Generate() = %q, want %q", got, want)
+	}
+}
+
+func TestGenerator_Targets(t *testing.T) {
+	source, err := os.ReadFile(filepath.Join("..", "..", "testdata", "targets.yok"))
+	if err != nil {
+		t.Fatal("Generator.Generate() failed to read source file", err)
+	}
+
+	for _, shell := range []target.Target{target.POSIX, target.Bash, target.Dash, target.Ash, target.Zsh} {
+		t.Run(shell.String(), func(t *testing.T) {
+			p := parser.New(source)
+			script, err := p.Parse()
+			if err != nil {
+				t.Fatalf("Generator.Generate() failed to parse source %v", p.Errors)
+			}
+
+			c := compiler.NewWithOptions(source, compiler.Options{Target: shell})
+			shAst, err := c.Compile(script)
+			if err != nil {
+				t.Fatalf("Generator.Generate() failed to compile source %v", c.Errors())
+			}
+
+			got := New(shell).Generate(shAst)
+			shFile := "targets_" + shell.String() + ".sh"
+			if diffs := diff.AgainstFile(t, got, filepath.Join("testdata", shFile)); diffs != "" {
+				t.Errorf("Generator.Generate() generated code does not match %s:\n%s", shFile, diffs)
+			}
+		})
+	}
+}
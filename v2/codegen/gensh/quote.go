@@ -0,0 +1,219 @@
+package gensh
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isSafeChar returns true if the character never has a special meaning in sh
+// and so can be used in a word without any quotes
+func isSafeChar(char rune) bool {
+	switch {
+	case char >= 'a' && char <= 'z':
+		return true
+	case char >= 'A' && char <= 'Z':
+		return true
+	case char >= '0' && char <= '9':
+		return true
+	default:
+		return strings.ContainsRune("_-./:,+=@%^", char)
+	}
+}
+
+// quoteString quotes a string literal so sh treats it as a single word with no expansions.
+// Control characters other than new lines and tabs can not be written portably inside quotes
+// so they are generated with printf and concatenated with the rest of the string
+//
+// Example:
+//
+//	hello     -> hello
+//	a\x1bb    -> a"$(printf '\033')"b
+func quoteString(value string) string {
+	// command substitution strips trailing new lines, but new lines are
+	// never part of a control run so the output of printf is always kept exactly
+	return quoteControlRuns(value, `"$(printf '`, `')"`)
+}
+
+// quoteStringANSI quotes a string literal like quoteString, but control characters are
+// written with $'..' strings for shells that support them
+//
+// Example:
+//
+//	a\x1bb    -> a$'\033'b
+func quoteStringANSI(value string) string {
+	return quoteControlRuns(value, `$'`, `'`)
+}
+
+// quoteControlRuns quotes the string with quoteWord, except for runs of control characters which are
+// written as octal escapes between the open and close strings
+func quoteControlRuns(value, open, close string) string {
+	if !strings.ContainsFunc(value, isControlChar) {
+		return quoteWord(value)
+	}
+
+	b := strings.Builder{}
+	for len(value) > 0 {
+		i := strings.IndexFunc(value, isControlChar)
+		if i < 0 {
+			i = len(value)
+		}
+		if i > 0 {
+			b.WriteString(quoteWord(value[:i]))
+			value = value[i:]
+			continue
+		}
+
+		end := strings.IndexFunc(value, func(char rune) bool { return !isControlChar(char) })
+		if end < 0 {
+			end = len(value)
+		}
+
+		b.WriteString(open)
+		for _, char := range []byte(value[:end]) {
+			fmt.Fprintf(&b, "\\%03o", char)
+		}
+		b.WriteString(close)
+		value = value[end:]
+	}
+
+	return b.String()
+}
+
+// isControlChar returns true if the character is a control character that can not be
+// written directly in the generated code. New lines and tabs are written directly inside quotes
+func isControlChar(char rune) bool {
+	return (char < ' ' && char != '\n' && char != '\t') || char == 0x7f
+}
+
+// quoteWord quotes a string so sh treats it as a single word with no expansions.
+// Strings that only contain safe characters are left bare, strings without any characters that
+// are special inside double quotes are double quoted, and everything else is single quoted
+//
+// Example:
+//
+//	hello       -> hello
+//	hello world -> "hello world"
+//	$5 & *.txt  -> '$5 & *.txt'
+func quoteWord(value string) string {
+	if value == "" {
+		return `""`
+	}
+
+	if !strings.ContainsFunc(value, func(char rune) bool { return !isSafeChar(char) }) {
+		return value
+	}
+
+	if !strings.ContainsAny(value, "$`\\\"!") {
+		return `"` + value + `"`
+	}
+
+	// single quotes can not be escaped inside a single quoted string so
+	// each one closes the string, adds an escaped quote and then re-opens the string
+	return `'` + strings.ReplaceAll(value, `'`, `'\''`) + `'`
+}
+
+// escapeDoubleQuoted escapes a string literal so it can be written inside double quotes, like the default
+// value of a quoted paramater expansion. Control characters are generated with printf, and so are ' and }
+// because bash looks for matching single quotes in an expansion and } would end the expansion
+//
+// Example:
+//
+//	cost $5   -> cost \$5
+//	it's      -> it$(printf '\047')s
+//	a\x1bb    -> a$(printf '\033')b
+func escapeDoubleQuoted(value string) string {
+	b := strings.Builder{}
+	for _, char := range value {
+		switch {
+		case strings.ContainsRune("$`\"\\", char):
+			b.WriteByte('\\')
+			b.WriteRune(char)
+		case isControlChar(char) || char == '\'' || char == '}':
+			fmt.Fprintf(&b, `$(printf '\%03o')`, char)
+		default:
+			b.WriteRune(char)
+		}
+	}
+
+	return b.String()
+}
+
+// quoteExpansion wraps the expansion in double quotes if quoted is set
+func quoteExpansion(expansion string, quoted bool) string {
+	if quoted {
+		return `"` + expansion + `"`
+	}
+
+	return expansion
+}
+
+// quotePattern quotes a glob pattern so it can be used in a parameter expansion. The glob characters
+// and bracket expressions are left unquoted, everything else is quoted so it's matched literally
+func quotePattern(pattern string) string {
+	b := strings.Builder{}
+	literal := strings.Builder{}
+	flush := func() {
+		if literal.Len() > 0 {
+			b.WriteString(quoteWord(literal.String()))
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?':
+			flush()
+			b.WriteByte(pattern[i])
+		case '[':
+			end := bracketEnd(pattern, i)
+			if end < 0 {
+				literal.WriteByte(pattern[i])
+				continue
+			}
+
+			flush()
+			b.WriteString(pattern[i : end+1])
+			i = end
+		default:
+			literal.WriteByte(pattern[i])
+		}
+	}
+	flush()
+
+	if b.Len() == 0 {
+		return `""`
+	}
+
+	return b.String()
+}
+
+// bracketEnd returns the index of the ']' that closes the bracket expression starting at start,
+// or -1 if the bracket expression is not closed
+func bracketEnd(pattern string, start int) int {
+	i := start + 1
+	if strings.HasPrefix(pattern[i:], "!") {
+		i++
+	}
+
+	// a ']' at the start of the bracket expression is matched literally
+	if strings.HasPrefix(pattern[i:], "]") {
+		i++
+	}
+
+	for ; i < len(pattern); i++ {
+		if strings.HasPrefix(pattern[i:], "[:") {
+			end := strings.Index(pattern[i+2:], ":]")
+			if end < 0 {
+				return -1
+			}
+			i += end + 3
+			continue
+		}
+
+		if pattern[i] == ']' {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,270 @@
+package gensh
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/yok/ast/shast"
+	"github.com/bjatkin/yok/compiler"
+	"github.com/bjatkin/yok/parser"
+)
+
+func Test_quoteString(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{
+			name:  "empty",
+			value: "",
+			want:  `""`,
+		},
+		{
+			name:  "safe characters",
+			value: "/usr/local/bin:-O2,a=b",
+			want:  "/usr/local/bin:-O2,a=b",
+		},
+		{
+			name:  "white space",
+			value: "hello world",
+			want:  `"hello world"`,
+		},
+		{
+			name:  "glob",
+			value: "*.txt",
+			want:  `"*.txt"`,
+		},
+		{
+			name:  "variable",
+			value: "$HOME",
+			want:  `'$HOME'`,
+		},
+		{
+			name:  "single quote",
+			value: "it's $5",
+			want:  `'it'\''s $5'`,
+		},
+		{
+			name:  "new line",
+			value: "a\nb",
+			want:  "\"a\nb\"",
+		},
+		{
+			name:  "control characters",
+			value: "a\x1b[0m\r",
+			want:  `a"$(printf '\033')""[0m""$(printf '\015')"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteString(tt.value); got != tt.want {
+				t.Errorf("quoteString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_quoteStringANSI(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{
+			name:  "no control characters",
+			value: "hello world",
+			want:  `"hello world"`,
+		},
+		{
+			name:  "control characters",
+			value: "a\x1b[0m\r",
+			want:  `a$'\033'"[0m"$'\015'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteStringANSI(tt.value); got != tt.want {
+				t.Errorf("quoteStringANSI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_quotePattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+	}{
+		{
+			name:    "empty",
+			pattern: "",
+			want:    `""`,
+		},
+		{
+			name:    "glob",
+			pattern: "*.log",
+			want:    "*.log",
+		},
+		{
+			name:    "quoted literals",
+			pattern: "$HOME/* x?",
+			want:    `'$HOME/'*" x"?`,
+		},
+		{
+			name:    "bracket expressions",
+			pattern: "[!a-z][]][[:space:]]",
+			want:    "[!a-z][]][[:space:]]",
+		},
+		{
+			name:    "unclosed bracket",
+			pattern: "a[b",
+			want:    `"a[b"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quotePattern(tt.pattern); got != tt.want {
+				t.Errorf("quotePattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// specialChars are characters that are likely to break incorrectly quoted sh code
+const specialChars = " \t\n*?[]{}()<>|&;$`\\\"'!#~=%:,.-/^@"
+
+// randomString creates a random string that is biased towards characters that are special in sh
+func randomString(r *rand.Rand, chars string) string {
+	runes := []rune(chars + "abcXYZ019é¿世")
+	length := r.Intn(12)
+
+	b := strings.Builder{}
+	for range length {
+		b.WriteRune(runes[r.Intn(len(runes))])
+	}
+
+	return b.String()
+}
+
+// runSh runs the script with /bin/sh and returns stdout, the test is skipped if /bin/sh is not available
+func runSh(t *testing.T, script string) string {
+	t.Helper()
+
+	return runShell(t, "sh", script)
+}
+
+// runShell runs the script with the given shell, the test is skipped if the shell is not installed
+func runShell(t *testing.T, shell, script string) string {
+	t.Helper()
+
+	sh, err := exec.LookPath(shell)
+	if err != nil {
+		t.Skip(shell, "is not available", err)
+	}
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	cmd := exec.Command(sh, "-c", script)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run script: %v\n%s\n%s", err, stderr.String(), script)
+	}
+
+	return stdout.String()
+}
+
+func TestGenerate_QuotingRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(33))
+
+	want := []string{}
+	stmts := []shast.Stmt{}
+	for range 200 {
+		value := randomString(r, specialChars)
+
+		// print the value as a literal, as a variable and as the default of an unset variable,
+		// each followed by a NUL separator
+		unset := &shast.ParamaterExpansion{
+			Expression: &shast.ParamaterDefault{Paramater: &shast.Identifier{Value: "UNSET"}, Default: &shast.String{Value: value}},
+			Quoted:     true,
+		}
+		stmts = append(stmts,
+			&shast.Assign{Identifier: "VALUE", Value: &shast.String{Value: value}},
+			&shast.StmtExpr{Expression: &shast.Exec{
+				Command:   "printf",
+				Arguments: []shast.Expr{&shast.String{Value: `%s\0%s\0%s\0`}, &shast.String{Value: value}, &shast.Identifier{Value: "VALUE", Quoted: true}, unset},
+			}},
+		)
+		want = append(want, value, value, value)
+	}
+
+	script := Generate(&shast.Script{Statements: stmts})
+	got := strings.Split(strings.TrimSuffix(runSh(t, script), "\x00"), "\x00")
+	if len(got) != len(want) {
+		t.Fatalf("Generate() script printed %d values, want %d\n%s", len(got), len(want), script)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Generate() value %d round tripped as %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+// yokLiteral encodes the value as a yok string literal
+func yokLiteral(value string) string {
+	b := strings.Builder{}
+	b.WriteByte('"')
+	for _, char := range value {
+		switch char {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '"', '\\', '$':
+			b.WriteRune('\\')
+			b.WriteRune(char)
+		default:
+			if char < ' ' || char == 0x7f {
+				fmt.Fprintf(&b, `\u{%x}`, char)
+				continue
+			}
+			b.WriteRune(char)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+func TestCompile_QuotingRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(33))
+
+	for range 50 {
+		value := randomString(r, specialChars+"\r\x1b\x7f")
+		source := []byte("let value = " + yokLiteral(value) + "\nlet copy = value\nprintf(\"%s|%s\", value, copy)\n")
+
+		p := parser.New(source)
+		script, err := p.Parse()
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", source, p.Errors)
+		}
+
+		c := compiler.New(source)
+		shAst, err := c.Compile(script)
+		if err != nil {
+			t.Fatalf("failed to compile %q: %v", source, c.Errors())
+		}
+
+		got := runSh(t, Generate(shAst))
+		if want := value + "|" + value; got != want {
+			t.Errorf("compiled %q printed %q, want %q", source, got, want)
+		}
+	}
+}
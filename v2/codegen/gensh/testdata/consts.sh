@@ -0,0 +1,14 @@
+#!/bin/sh
+
+YOK_ATTEMPT=1
+if [ "$YOK_ATTEMPT" -lt 3 ]; then
+    YOK_ATTEMPT=$(( $YOK_ATTEMPT + 3 ))
+fi
+
+printf '%s %s %s\n' yok 24 "$YOK_ATTEMPT"
+
+# constants are inlined, so they're hoisted into a variable when a builtin needs one
+_TMP1=yok
+_TMP2=./build/yok.tar.gz
+_TMP3=./build/yok.tar.gz
+printf '%s %s %s\n' "${#_TMP1}" "${_TMP2##./}" "${_TMP3%.*}"
\ No newline at end of file
@@ -1,14 +1,14 @@
 #!/bin/sh
 
 # atom values
-A=10
-B=20
-STATUS=ok
+YOK_A=10
+YOK_B=20
+YOK_STATUS=ok
 
 # string values
-PET=dog
-COLOR=red
+YOK_PET=dog
+YOK_COLOR=red
 
 # file paths
-MY_FILE=/my/file.txt
-MY_DIR=my/relative/dir
\ No newline at end of file
+YOK_MY_FILE=/my/file.txt
+YOK_MY_DIR=my/relative/dir
\ No newline at end of file
@@ -0,0 +1,14 @@
+#!/bin/sh
+
+# read environment variables
+YOK_HOME="$HOME"
+YOK_PORT="${PORT:-8080}"
+printf '%s %s\n' "$YOK_HOME" "$YOK_PORT"
+
+# export variables to the commands run by the script
+export BUILD_DIR=/tmp/build
+make all
+
+# set environment variables for a single command
+CC=clang CFLAGS=-O2 make all
+PREFIX="$YOK_HOME" make install
\ No newline at end of file
@@ -0,0 +1,18 @@
+#!/bin/sh
+
+# escape sequences are decoded by yok, not by sh
+YOK_TABBED="a	b"
+YOK_QUOTED='say "hi" to $USER'
+YOK_PATH='C:\Users\yok'
+YOK_SMILE="😀"
+printf '%s %s %s %s\n' "$YOK_TABBED" "$YOK_QUOTED" "$YOK_PATH" "$YOK_SMILE"
+
+# new lines are kept exactly as written
+YOK_LINES="one
+two
+"
+printf '%s\n' "$YOK_LINES"
+
+# control characters are written with printf
+YOK_RED="$(printf '\033')""[31mred""$(printf '\033')""[0m"
+printf '%s\n' "$YOK_RED"
\ No newline at end of file
@@ -1,7 +1,7 @@
 #!/bin/sh
 
 # set the subject name
-SUBJECT=world
+YOK_SUBJECT=world
 
 # say hello to the subject
-echo Hello $SUBJECT >&2
\ No newline at end of file
+printf '%s %s\n' Hello "$YOK_SUBJECT"
\ No newline at end of file
@@ -1,32 +1,32 @@
 #!/bin/sh
 
-X=10
-Y=20
-Z=30
+YOK_X=10
+YOK_Y=20
+YOK_Z=30
 
-if [ "$X" -gt 0 ]; then
-    echo "x is positive" >&2
-    if [ "$Y" = 20 ]; then
-        echo "y is 20" >&2
-        if [ "$Z" != "$X" ]; then
-            echo "z does not equal x" >&2
+if [ "$YOK_X" -gt 0 ]; then
+    printf '%s\n' "x is positive"
+    if [ "$YOK_Y" = 20 ]; then
+        printf '%s\n' "y is 20"
+        if [ "$YOK_Z" != "$YOK_X" ]; then
+            printf '%s\n' "z does not equal x"
         fi
     fi
 else
-    echo "x is negative or zero" >&2
-    if [ "$Y" != 20 ]; then
-        echo "y is not 20" >&2
+    printf '%s\n' "x is negative or zero"
+    if [ "$YOK_Y" != 20 ]; then
+        printf '%s\n' "y is not 20"
     else
-        echo "y is still 20" >&2
+        printf '%s\n' "y is still 20"
     fi
 fi
 
-if [ "$X" -lt 0 ]; then
-    echo "x is negative" >&2
-elif [ "$X" -gt 1 ]; then
-    echo "x is negative" >&2
-elif [ "$X" = 1 ]; then
-    echo "x is negative" >&2
+if [ "$YOK_X" -lt 0 ]; then
+    printf '%s\n' "x is negative"
+elif [ "$YOK_X" -gt 1 ]; then
+    printf '%s\n' "x is positive"
+elif [ "$YOK_X" = 1 ]; then
+    printf '%s\n' "x is one"
 else
-    echo "x is zero" >&2
+    printf '%s\n' "x is zero"
 fi
\ No newline at end of file
@@ -0,0 +1,48 @@
+#!/bin/sh
+
+_yok_upper() {
+    printf '%s' "$1" | tr '[:lower:]' '[:upper:]'
+}
+
+_yok_lower() {
+    printf '%s' "$1" | tr '[:upper:]' '[:lower:]'
+}
+
+_yok_contains() {
+    case $1 in
+        *"$2"*) printf true ;;
+        *) printf false ;;
+    esac
+}
+
+YOK_NAME=yok-lang
+
+# calls nested in if tests are run before the if statement
+_TMP1="${YOK_NAME##yok}"
+if [ "${#_TMP1}" -gt 3 ]; then
+    # calls nested in if bodies are run before the statement that uses them
+    _TMP2="$(_yok_upper "$YOK_NAME")"
+    YOK_SIZE="${#_TMP2}"
+    printf '%s\n' "$YOK_SIZE"
+fi
+
+# else if tests that need temporaries become an if statement nested in the else branch
+if [ "$YOK_NAME" = go ]; then
+    printf '%s\n' go
+else
+    _TMP3="${YOK_NAME%%-lang}"
+    if [ "${#_TMP3}" = 4 ]; then
+        printf '%s\n' four
+    elif [ "$(_yok_upper "${YOK_NAME%%-lang}")" = YOK ]; then
+        printf '%s\n' yok
+    else
+        printf '%s\n' unknown
+    fi
+fi
+
+# nested if statements are fixed at every level
+if [ "$(_yok_contains "$YOK_NAME" -)" = true ]; then
+    if [ "$(_yok_lower "$(_yok_upper "$YOK_NAME")")" = "$YOK_NAME" ]; then
+        printf '%s\n' nested
+    fi
+fi
\ No newline at end of file
@@ -1,8 +1,8 @@
 #!/bin/sh
 
-A=$(( 5 + 10 ))
-B=$(( 10 - 15 ))
-C=$(( 15 * 20 ))
-D=$(( 20 / 10 ))
-E=$(( 10 % 15 ))
-F=$(( ( 1 + 2 ) * 3 ))
\ No newline at end of file
+YOK_A=$(( 5 + 10 ))
+YOK_B=$(( 10 - 15 ))
+YOK_C=$(( 15 * 20 ))
+YOK_D=$(( 20 / 10 ))
+YOK_E=$(( 10 % 15 ))
+YOK_F=$(( ( 1 + 2 ) * 3 ))
\ No newline at end of file
@@ -0,0 +1,30 @@
+#!/bin/sh
+
+# yok variables never clobber special sh variables
+YOK_PATH=/tmp/bin
+YOK_HOME="my home"
+YOK_IFS=,
+printf '%s %s %s\n' "$YOK_PATH" "$YOK_HOME" "$YOK_IFS"
+
+# or environment variables that are passed on to commands
+YOK_GOPATH=/tmp/go
+YOK_CC=clang
+YOK_LANG=C
+printf '%s %s %s\n' "$YOK_GOPATH" "$YOK_CC" "$YOK_LANG"
+
+# variables that only differ by case get unique names
+YOK_MYVAR=1
+YOK_MYVAR_1=2
+printf '%s %s\n' "$YOK_MYVAR" "$YOK_MYVAR_1"
+
+# variables in a block do not overwrite variables in the outer scope
+YOK_COUNT=1
+if [ "$YOK_COUNT" -gt 0 ]; then
+    YOK_COUNT_1=2
+    printf '%s\n' "$YOK_COUNT_1"
+fi
+printf '%s\n' "$YOK_COUNT"
+
+# environment variables can be read and written explicitly
+export PATH=/usr/local/bin
+printf '%s %s\n' "$HOME" "$PATH"
\ No newline at end of file
@@ -1,20 +1,20 @@
 #!/bin/sh
 
 # valid nesting
-NAME=Jacob
-echo "Length of name is: " ${#NAME} >&2
+YOK_NAME=Jacob
+printf '%s %s\n' "Length of name is: " "${#YOK_NAME}"
 
 # requires command substitution
-echo hello $(echo Alexis) >&2
+printf '%s %s\n' hello "$(echo Alexis)"
 
 # requires many levels of command substitution
-echo "go bin:" $(echo $(ls -la $(which go)))
+printf '%s %s\n' "go bin:" "$(echo "$(ls -la "$(which go)")")"
 
 # nested paramater expansions
 _TMP1=hello
-_TMP2=${#_TMP1}
-_TMP3=${#_TMP2}
-echo ${#_TMP3} >&2
-EXCLAIM="¿What! What! are you doing!?"
-_TMP4=${EXCLAIM##¿}
-echo ${_TMP4%%?} >&2
\ No newline at end of file
+_TMP2="${#_TMP1}"
+_TMP3="${#_TMP2}"
+printf '%s\n' "${#_TMP3}"
+YOK_EXCLAIM='¿What! What! are you doing!?'
+_TMP4="${YOK_EXCLAIM##"¿"}"
+printf '%s\n' "${_TMP4%%"?"}"
\ No newline at end of file
@@ -0,0 +1,25 @@
+#!/bin/sh
+
+_yok_matches() {
+    case $1 in
+        $2) printf true ;;
+        *) printf false ;;
+    esac
+}
+
+YOK_FILE=logs/app.2024.log
+YOK_LOG_FILE="*.log"
+
+# patterns can be used as values and tested with matches
+if [ "$(_yok_matches "$YOK_FILE" "$YOK_LOG_FILE")" = true ]; then
+    printf '%s\n' "found a log file"
+fi
+
+if [ "$(_yok_matches "$YOK_FILE" "*/[a-z]*.[0-9][0-9][0-9][0-9].log")" = true ]; then
+    printf '%s\n' "found a dated log file"
+fi
+
+# patterns are matched as globs by remove_prefix and remove_suffix
+printf '%s\n' "${YOK_FILE##*/}"
+printf '%s\n' "${YOK_FILE%.*}"
+printf '%s\n' "${YOK_FILE%%.[!.]*.log}"
\ No newline at end of file
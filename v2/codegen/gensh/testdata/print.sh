@@ -0,0 +1,17 @@
+#!/bin/sh
+
+YOK_NAME=yok
+YOK_COUNT=3
+
+# print writes to stdout followed by a new line
+printf '%s %s\n' hello "$YOK_NAME"
+printf %s "no new line"
+printf '%s\n\n' " 100%"
+
+# eprint writes to stderr
+printf '%s\n' "something went wrong" >&2
+
+# printf uses a format string
+printf '%s has %d letters\n' "$YOK_NAME" "$YOK_COUNT"
+printf '%-8s|%5.2f|%%\n' "$YOK_NAME" "$YOK_COUNT"
+printf '%*s|%.*s\n' 6 "$YOK_NAME" 2 "$YOK_NAME"
\ No newline at end of file
@@ -0,0 +1,29 @@
+#!/bin/sh
+
+_yok_upper() {
+    printf '%s' "$1" | tr '[:lower:]' '[:upper:]'
+}
+
+_yok_lower() {
+    printf '%s' "$1" | tr '[:upper:]' '[:lower:]'
+}
+
+_yok_trim() {
+    _yok_str=${1#"${1%%[![:space:]]*}"}
+    printf '%s' "${_yok_str%"${_yok_str##*[![:space:]]}"}"
+}
+
+YOK_FILE=archive.tar.gz
+
+# any expression can be used with len
+_TMP1=yok
+_TMP2="$(_yok_upper "$YOK_FILE")"
+YOK_NAME_LEN=$(( ${#_TMP1} + ${#_TMP2} ))
+printf '%s\n' "$YOK_NAME_LEN"
+printf '%s\n' "${#HOME}"
+
+# remove the longest or shortest match
+printf '%s\n' "${YOK_FILE%%.gz}"
+printf '%s\n' "${YOK_FILE%.gz}"
+_TMP3="$(_yok_trim "$YOK_FILE")"
+printf '%s\n' "${_TMP3##"$(_yok_lower ARCHIVE.)"}"
\ No newline at end of file
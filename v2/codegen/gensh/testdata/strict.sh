@@ -0,0 +1,18 @@
+#!/bin/sh
+
+set -eu
+
+#yok:strict
+# environment variables may be unset, strict mode reads them as empty strings
+YOK_EDITOR="${YOK_TEST_EDITOR-}"
+YOK_PAGER="${YOK_TEST_PAGER:-less}"
+_TMP1="${YOK_TEST_EDITOR-}"
+YOK_SIZE="${#_TMP1}"
+
+YOK_COUNT=1
+YOK_COUNT=$(( $YOK_COUNT + 1 ))
+
+# the result of math that is not assigned is ignored
+: $(( $YOK_COUNT * 2 ))
+
+printf '%s %s %s %s\n' "$YOK_EDITOR" "$YOK_PAGER" "$YOK_SIZE" "$YOK_COUNT"
\ No newline at end of file
@@ -1,24 +1,24 @@
 #!/bin/sh
 
-GREET="hello world"
-GREET_LEN=${#GREET}
-PLACE=${GREET##"hello "}
-echo $PLACE >&2
-SHORT_GREET=${GREET%%" world"}
-echo $SHORT_GREET >&2
+YOK_GREET="hello world"
+YOK_GREET_LEN="${#YOK_GREET}"
+YOK_PLACE="${YOK_GREET##"hello "}"
+printf '%s\n' "$YOK_PLACE"
+YOK_SHORT_GREET="${YOK_GREET%%" world"}"
+printf '%s\n' "$YOK_SHORT_GREET"
 
 # use literal instead of identifier
 _TMP1="new york"
-STATE_LEN=${#_TMP1}
+YOK_STATE_LEN="${#_TMP1}"
 
 # use call instead of identifier
-_TMP2=$(echo "new mexico")
-STATE_LEN=${#_TMP2}
+_TMP2="$(echo "new mexico")"
+YOK_STATE_LEN="${#_TMP2}"
 
 # use identifiers for remove fix
-T=test
-I=ing
+YOK_T=test
+YOK_I=ing
 _TMP3=testing
-echo ${_TMP3##$(echo -n $T)} >&2
+printf '%s\n' "${_TMP3##"$YOK_T"}"
 _TMP4=testing
-echo ${_TMP4%%$(echo -n $I)} >&2
\ No newline at end of file
+printf '%s\n' "${_TMP4%%"$YOK_I"}"
\ No newline at end of file
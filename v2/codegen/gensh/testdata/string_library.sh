@@ -0,0 +1,109 @@
+#!/bin/sh
+
+_yok_replace() {
+    case $1 in
+        *"$2"*) printf '%s%s%s' "${1%%"$2"*}" "$3" "${1#*"$2"}" ;;
+        *) printf '%s' "$1" ;;
+    esac
+}
+
+_yok_replace_all() {
+    if [ -z "$2" ]; then
+        printf '%s' "$1"
+        return
+    fi
+    _yok_rest=$1
+    while :; do
+        case $_yok_rest in
+            *"$2"*)
+                printf '%s%s' "${_yok_rest%%"$2"*}" "$3"
+                _yok_rest=${_yok_rest#*"$2"}
+                ;;
+            *) break ;;
+        esac
+    done
+    printf '%s' "$_yok_rest"
+}
+
+_yok_split() {
+    if [ -z "$2" ]; then
+        printf '%s' "$1"
+        return
+    fi
+    _yok_rest=$1
+    while :; do
+        case $_yok_rest in
+            *"$2"*)
+                printf '%s\n' "${_yok_rest%%"$2"*}"
+                _yok_rest=${_yok_rest#*"$2"}
+                ;;
+            *) break ;;
+        esac
+    done
+    printf '%s' "$_yok_rest"
+}
+
+_yok_upper() {
+    printf '%s' "$1" | tr '[:lower:]' '[:upper:]'
+}
+
+_yok_lower() {
+    printf '%s' "$1" | tr '[:upper:]' '[:lower:]'
+}
+
+_yok_trim() {
+    _yok_str=${1#"${1%%[![:space:]]*}"}
+    printf '%s' "${_yok_str%"${_yok_str##*[![:space:]]}"}"
+}
+
+_yok_contains() {
+    case $1 in
+        *"$2"*) printf true ;;
+        *) printf false ;;
+    esac
+}
+
+_yok_starts_with() {
+    case $1 in
+        "$2"*) printf true ;;
+        *) printf false ;;
+    esac
+}
+
+_yok_ends_with() {
+    case $1 in
+        *"$2") printf true ;;
+        *) printf false ;;
+    esac
+}
+
+YOK_GREET="  Hello World  "
+YOK_CLEAN="$(_yok_trim "$YOK_GREET")"
+
+# replace the first or every instance of a sub string
+printf '%s\n' "$(_yok_replace "$YOK_CLEAN" o 0)"
+printf '%s\n' "$(_yok_replace_all "$YOK_CLEAN" o 0)"
+
+# nested calls are captured before they are used
+YOK_SHOUT="$(_yok_upper "$(_yok_replace "$YOK_CLEAN" World yok)")"
+printf '%s %s\n' "$YOK_SHOUT" "$(_yok_lower "$YOK_SHOUT")"
+
+# split prints each field on its own line
+printf '%s\n' "$(_yok_split a,b,c ,)"
+
+if [ "$(_yok_contains "$YOK_CLEAN" World)" = true ]; then
+    printf '%s\n' "found the world"
+fi
+
+if [ "$(_yok_starts_with "$YOK_CLEAN" Hello)" = true ]; then
+    printf '%s\n' "starts with hello"
+elif [ "$(_yok_ends_with "$YOK_CLEAN" '!')" = true ]; then
+    printf '%s\n' 'ends with !'
+fi
+
+# the else if branch is taken here, so the script output checks that it runs its own body
+if [ "$(_yok_starts_with "$YOK_CLEAN" World)" = true ]; then
+    printf '%s\n' "starts with world"
+elif [ "$(_yok_ends_with "$YOK_CLEAN" World)" = true ]; then
+    printf '%s\n' "ends with world"
+fi
\ No newline at end of file
@@ -0,0 +1,52 @@
+#!/usr/bin/env zsh
+
+_yok_replace_all() {
+    if [ -z "$2" ]; then
+        printf '%s' "$1"
+        return
+    fi
+    printf '%s' "${1//"$2"/"$3"}"
+}
+
+_yok_upper() {
+    printf '%s' "$1" | tr '[:lower:]' '[:upper:]'
+}
+
+_yok_lower() {
+    printf '%s' "$1" | tr '[:upper:]' '[:lower:]'
+}
+
+_yok_trim() {
+    local _yok_str
+    _yok_str=${1#"${1%%[![:space:]]*}"}
+    printf '%s' "${_yok_str%"${_yok_str##*[![:space:]]}"}"
+}
+
+_yok_contains() {
+    case $1 in
+        *"$2"*) printf true ;;
+        *) printf false ;;
+    esac
+}
+
+_yok_matches() {
+    case $1 in
+        ${~2}) printf true ;;
+        *) printf false ;;
+    esac
+}
+
+# builtins use the features of the target shell where they're available
+YOK_NAME="Hello World"
+YOK_BELL=ding$'\007'
+
+printf '%s %s %s\n' "$(_yok_upper "$YOK_NAME")" "$(_yok_lower "$YOK_NAME")" "$(_yok_trim "  padded  ")"
+printf '%s %s\n' "$(_yok_replace_all "$YOK_NAME" o 0)" "$YOK_BELL"
+
+if [[ "$(_yok_contains "$YOK_NAME" World)" = true ]]; then
+    printf '%s\n' "found it"
+fi
+
+if [[ "$(_yok_matches "$YOK_NAME" "*W[a-z]rld")" = true ]]; then
+    printf '%s\n' "matched a pattern"
+fi
\ No newline at end of file
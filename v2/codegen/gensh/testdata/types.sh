@@ -0,0 +1,18 @@
+#!/bin/sh
+
+# typed variables start with the zero value of their type
+YOK_COUNT=0
+YOK_NAME=""
+YOK_DONE=false
+
+# types can also be written when a value is set
+YOK_LIMIT=10
+YOK_GREETING=hello
+
+if [ "$YOK_COUNT" -lt "$YOK_LIMIT" ]; then
+    YOK_COUNT=$(( $YOK_COUNT + 1 ))
+fi
+
+if [ "$YOK_DONE" = false ]; then
+    printf '%s %s %s\n' "$YOK_GREETING" "$YOK_NAME" "$YOK_COUNT"
+fi
\ No newline at end of file
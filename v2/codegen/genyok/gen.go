@@ -5,76 +5,328 @@ import (
 	"strings"
 
 	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/token"
 )
 
-const indentToken = "    "
+const (
+	// indentToken is used as the indent string for the generated code
+	indentToken = "    "
+	// maxLineLen is the line length after which call arguments are wrapped onto their own lines
+	maxLineLen = 100
+)
 
+// Generate takes a yok script and renders it into well formated yok code.
+// Generate is idempotent, formatting the generated code a second time will not change it.
+//
 // TODO: I might want to use a client to generate yok code since I need to pass the source file in.
 // That could technically let me do the parsing as well
 func Generate(script *yokast.Script, source []byte) string {
-	scriptLines := []string{}
-	for _, stmt := range script.Statements {
-		node := generateStmt(stmt, 0, source)
-		scriptLines = append(scriptLines, node)
+	g := generator{source: source}
+	lines := g.generateStmts(script.Statements, 0)
+	if len(lines) == 0 {
+		return ""
 	}
 
-	return strings.Join(scriptLines, "\n") + "\n"
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// generator generates formatted yok code from a yok AST
+type generator struct {
+	source []byte
 }
 
-func generateExpr(expr yokast.Expr, source []byte) string {
+// formattedStmt is a single formatted statement along with it's trailing comment.
+// trailing comments are kept separate so they can be aligned with the comments of
+// the surrounding statements
+type formattedStmt struct {
+	lines   []string
+	comment string
+}
+
+// generateStmts formats a slice of statements into lines of yok code.
+// Runs of blank lines are collapsed into a single blank line and blank lines at
+// the start or end of the statements are dropped
+func (g *generator) generateStmts(stmts []yokast.Stmt, depth int) []string {
+	formatted := []formattedStmt{}
+	blank := false
+	for _, stmt := range stmts {
+		if _, ok := stmt.(*yokast.NewLine); ok {
+			blank = len(formatted) > 0
+			continue
+		}
+
+		if blank {
+			formatted = append(formatted, formattedStmt{lines: []string{""}})
+			blank = false
+		}
+
+		formatted = append(formatted, g.generateStmt(stmt, depth))
+	}
+
+	alignComments(formatted)
+
+	lines := []string{}
+	for _, stmt := range formatted {
+		lines = append(lines, stmt.lines...)
+	}
+
+	return lines
+}
+
+// alignComments aligns the trailing comments of consecutive single line statements
+func alignComments(stmts []formattedStmt) {
+	for start := 0; start < len(stmts); {
+		end := start
+		width := 0
+		for ; end < len(stmts); end++ {
+			stmt := stmts[end]
+			if stmt.comment == "" || len(stmt.lines) != 1 {
+				break
+			}
+
+			width = max(width, lineLen(stmt.lines[0]))
+		}
+
+		if start == end {
+			addComment(&stmts[start], 0)
+			start++
+			continue
+		}
+
+		for i := start; i < end; i++ {
+			addComment(&stmts[i], width)
+		}
+		start = end
+	}
+}
+
+// addComment adds the trailing comment of the statement to the end of it's last line.
+// The line is padded with spaces until it is at least width long
+func addComment(stmt *formattedStmt, width int) {
+	if stmt.comment == "" {
+		return
+	}
+
+	last := len(stmt.lines) - 1
+	padding := max(width-lineLen(stmt.lines[last]), 0)
+	stmt.lines[last] += strings.Repeat(" ", padding) + " " + stmt.comment
+}
+
+// lineLen returns the number of runes in the line
+func lineLen(line string) int {
+	return len([]rune(line))
+}
+
+// generateStmt formats a single yok statement
+func (g *generator) generateStmt(stmt yokast.Stmt, depth int) formattedStmt {
+	indent := strings.Repeat(indentToken, depth)
+	switch stmt := stmt.(type) {
+	case *yokast.Comment:
+		return formattedStmt{lines: []string{indent + g.generateComment(stmt)}}
+	case *yokast.NewLine:
+		return formattedStmt{lines: []string{""}}
+	case *yokast.Assign:
+		keyword := "let "
+		if stmt.Const {
+			keyword = "const "
+		}
+		if stmt.Export {
+			keyword = "export let "
+		}
+
+		decl := indent + keyword + stmt.Identifier.Name(g.source)
+		if stmt.Type != nil {
+			decl += " " + stmt.Type.Name(g.source)
+		}
+		if stmt.Value == nil {
+			return formattedStmt{
+				lines:   []string{decl},
+				comment: g.generateComment(stmt.Comment),
+			}
+		}
+
+		prefix := decl + " = "
+		value := g.generateExpr(stmt.Value, depth, lineLen(prefix))
+		return formattedStmt{
+			lines:   strings.Split(prefix+value, "\n"),
+			comment: g.generateComment(stmt.Comment),
+		}
+	case *yokast.Reassign:
+		prefix := indent + stmt.Identifier.Name(g.source) + " = "
+		value := g.generateExpr(stmt.Value, depth, lineLen(prefix))
+		return formattedStmt{
+			lines:   strings.Split(prefix+value, "\n"),
+			comment: g.generateComment(stmt.Comment),
+		}
+	case *yokast.EnvAssign:
+		prefix := indent + g.generateEnvVar(stmt.Variable) + " = "
+		value := g.generateExpr(stmt.Value, depth, lineLen(prefix))
+		return formattedStmt{
+			lines:   strings.Split(prefix+value, "\n"),
+			comment: g.generateComment(stmt.Comment),
+		}
+	case *yokast.StmtExpr:
+		expr := g.generateExpr(stmt.Expression, depth, lineLen(indent))
+		return formattedStmt{
+			lines:   strings.Split(indent+expr, "\n"),
+			comment: g.generateComment(stmt.Comment),
+		}
+	case *yokast.If:
+		prefix := indent + "if "
+		test := g.generateExpr(stmt.Test, depth, lineLen(prefix))
+		lines := strings.Split(prefix+test+" {", "\n")
+		lines = append(lines, g.generateBlock(stmt.Body, depth+1)...)
+
+		for _, elseIf := range stmt.ElseIfs {
+			prefix := indent + "} else if "
+			test := g.generateExpr(elseIf.Test, depth, lineLen(prefix))
+			lines = append(lines, strings.Split(prefix+test+" {", "\n")...)
+			lines = append(lines, g.generateBlock(elseIf.Body, depth+1)...)
+		}
+
+		if stmt.ElseBody != nil {
+			lines = append(lines, indent+"} else {")
+			lines = append(lines, g.generateBlock(stmt.ElseBody, depth+1)...)
+		}
+
+		lines = append(lines, indent+"}")
+		return formattedStmt{
+			lines:   lines,
+			comment: g.generateComment(stmt.Comment),
+		}
+	case *yokast.Block:
+		return formattedStmt{lines: g.generateBlock(stmt, depth)}
+	default:
+		panic(fmt.Sprintf("can not gen yok code, unknown stmt type %T", stmt))
+	}
+}
+
+// generateBlock formats the statements in a block
+func (g *generator) generateBlock(block *yokast.Block, depth int) []string {
+	if block == nil {
+		return nil
+	}
+
+	return g.generateStmts(block.Statements, depth)
+}
+
+// generateComment formats a comment, nil comments are formatted as empty strings
+func (g *generator) generateComment(comment *yokast.Comment) string {
+	if comment == nil {
+		return ""
+	}
+
+	return strings.TrimRight(comment.Token.Value(g.source), " \t")
+}
+
+// generateExpr formats a yok expression that starts at the given column.
+// If a call would extend past maxLineLen it's arguments are wrapped
+// onto their own lines, indented one level deeper than depth
+func (g *generator) generateExpr(expr yokast.Expr, depth, col int) string {
 	switch expr := expr.(type) {
 	case *yokast.InfixExpr:
-		left := generateExpr(expr.Left, source)
-		op := expr.Operator.Value(source)
-		right := generateExpr(expr.Right, source)
-		return fmt.Sprintf("%s %s %s", left, op, right)
+		left := g.generateExpr(expr.Left, depth, col)
+		op := " " + expr.Operator.Value(g.source) + " "
+		right := g.generateExpr(expr.Right, depth, endCol(col, left+op))
+		return left + op + right
+	case *yokast.PrefixExpr:
+		op := expr.Token.Value(g.source)
+		return op + g.generateExpr(expr.Expression, depth, col+lineLen(op))
+	case *yokast.GroupExpr:
+		return "(" + g.generateExpr(expr.Expression, depth, col+1) + ")"
 	case *yokast.Identifier:
-		return expr.Token.Value(source)
+		return expr.Name(g.source)
+	case *yokast.EnvVar:
+		return g.generateEnvVar(expr)
 	case *yokast.Atom:
-		return expr.Token.Value(source)
+		return expr.Token.Value(g.source)
+	case *yokast.Pattern:
+		return expr.Token.Value(g.source)
+	case *yokast.String:
+		return expr.Value(g.source)
 	case *yokast.Call:
+		flat := g.generateFlatExpr(expr)
+		if col+lineLen(flat) <= maxLineLen {
+			return flat
+		}
+
+		indent := strings.Repeat(indentToken, depth+1)
 		args := []string{}
 		for _, arg := range expr.Arguments {
-			a := generateExpr(arg, source)
-			args = append(args, a)
+			a := g.generateExpr(arg, depth+1, lineLen(indent))
+			args = append(args, indent+a+",\n")
 		}
-		funcName := expr.Identifier.Token.Value(source)
-		return fmt.Sprintf("%s(%s)", funcName, strings.Join(args, ","))
-	case *yokast.String:
-		return expr.Token.Value(source)
+		for _, arg := range expr.NamedArguments {
+			prefix := indent + arg.Name.Name(g.source) + "="
+			a := g.generateExpr(arg.Value, depth+1, lineLen(prefix))
+			args = append(args, prefix+a+",\n")
+		}
+
+		funcName := expr.Identifier.Name(g.source)
+		closeIndent := strings.Repeat(indentToken, depth)
+		return funcName + "(\n" + strings.Join(args, "") + closeIndent + ")"
+	case *yokast.NestedCall:
+		return g.generateExpr(expr.Call, depth, col)
+	case *yokast.Dict:
+		return g.generateFlatExpr(expr)
 	default:
-		panic(fmt.Sprintf("can not get yok code, unknown expr type %T", expr))
+		panic(fmt.Sprintf("can not gen yok code, unknown expr type %T", expr))
 	}
 }
 
-func generateStmt(stmt yokast.Stmt, indentDepth int, source []byte) string {
-	indent := strings.Repeat(indentToken, indentDepth)
-	switch stmt := stmt.(type) {
-	case *yokast.Comment:
-		return indent + stmt.Token.Value(source)
-	case *yokast.If:
-		test := generateExpr(stmt.Test, source)
-		body := generateStmt(stmt.Body, indentDepth+1, source)
+// generateEnvVar formats an environment variable using the same form it was written in
+func (g *generator) generateEnvVar(envVar *yokast.EnvVar) string {
+	name := envVar.Name.Value(g.source)
+	if envVar.Name.Type == token.StringLiteral {
+		return "env(" + name + ")"
+	}
+
+	return "env." + name
+}
 
-		if stmt.ElseBody == nil {
-			return indent + "if " + test + " {\n" + body + "\n}"
+// generateFlatExpr formats a yok expression on a single line
+func (g *generator) generateFlatExpr(expr yokast.Expr) string {
+	switch expr := expr.(type) {
+	case *yokast.InfixExpr:
+		left := g.generateFlatExpr(expr.Left)
+		right := g.generateFlatExpr(expr.Right)
+		return left + " " + expr.Operator.Value(g.source) + " " + right
+	case *yokast.PrefixExpr:
+		return expr.Token.Value(g.source) + g.generateFlatExpr(expr.Expression)
+	case *yokast.GroupExpr:
+		return "(" + g.generateFlatExpr(expr.Expression) + ")"
+	case *yokast.Call:
+		args := []string{}
+		for _, arg := range expr.Arguments {
+			args = append(args, g.generateFlatExpr(arg))
+		}
+		for _, arg := range expr.NamedArguments {
+			args = append(args, arg.Name.Name(g.source)+"="+g.generateFlatExpr(arg.Value))
 		}
 
-		elseBody := generateStmt(stmt.ElseBody, indentDepth+1, source)
-		return indent + "if " + test + " {\n" +
-			body + "\n} else {\n" +
-			elseBody + "\n}"
-	case *yokast.Block:
-		statements := []string{}
-		for _, statment := range stmt.Statements {
-			s := generateStmt(statment, indentDepth, source)
-			statements = append(statements, indent+s)
+		funcName := expr.Identifier.Name(g.source)
+		return funcName + "(" + strings.Join(args, ", ") + ")"
+	case *yokast.NestedCall:
+		return g.generateFlatExpr(expr.Call)
+	case *yokast.Dict:
+		entries := []string{}
+		for _, entry := range expr.Entries {
+			entries = append(entries, entry.Key.Value(g.source)+": "+g.generateFlatExpr(entry.Value))
 		}
-		return strings.Join(statements, "\n")
-	case *yokast.StmtExpr:
-		expr := generateExpr(stmt.Expression, source)
-		return expr
+
+		return "{" + strings.Join(entries, ", ") + "}"
 	default:
-		panic(fmt.Sprintf("can not get yok code, unknown stmt type %T", stmt))
+		// all other expressions are always formatted on a single line
+		return g.generateExpr(expr, 0, 0)
 	}
 }
+
+// endCol returns the column at the end of s, given that s started at the start column
+func endCol(start int, s string) int {
+	lastLine := strings.LastIndex(s, "\n")
+	if lastLine < 0 {
+		return start + lineLen(s)
+	}
+
+	return lineLen(s[lastLine+1:])
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/bjatkin/yok/ast/yokast"
 	"github.com/bjatkin/yok/diff"
 	"github.com/bjatkin/yok/parser"
 )
@@ -20,6 +21,16 @@ func TestGenerate(t *testing.T) {
 			yokFile:  "if_dirty.yok",
 			wantFile: "if.yok",
 		},
+		{
+			name:     "else if statment",
+			yokFile:  "else_if_dirty.yok",
+			wantFile: "else_if.yok",
+		},
+		{
+			name:     "all nodes",
+			yokFile:  "all_nodes_dirty.yok",
+			wantFile: "all_nodes.yok",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -46,3 +57,47 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerate_Idempotent(t *testing.T) {
+	yokFiles, err := filepath.Glob(filepath.Join("testdata", "*.yok"))
+	if err != nil {
+		t.Fatal("Generate() failed to find test files", err)
+	}
+
+	scriptFiles, err := filepath.Glob(filepath.Join("..", "..", "testdata", "*.yok"))
+	if err != nil {
+		t.Fatal("Generate() failed to find test files", err)
+	}
+	yokFiles = append(yokFiles, scriptFiles...)
+
+	for _, yokFile := range yokFiles {
+		t.Run(filepath.Base(yokFile), func(t *testing.T) {
+			source, err := os.ReadFile(yokFile)
+			if err != nil {
+				t.Fatal("Generate() failed to read source file", err)
+			}
+
+			once := Generate(mustParse(t, source), source)
+			twice := Generate(mustParse(t, []byte(once)), []byte(once))
+			if once != twice {
+				t.Errorf("Generate() is not idempotent, first pass:\n%s\nsecond pass:\n%s", once, twice)
+			}
+		})
+	}
+}
+
+// mustParse parses the source code and fails the test if there are any errors
+func mustParse(t *testing.T, source []byte) *yokast.Script {
+	t.Helper()
+
+	p := parser.New(source)
+	script, err := p.Parse()
+	if err != nil {
+		for _, e := range p.Errors {
+			t.Errorf("Generate() \terror = %v", e)
+		}
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	return script
+}
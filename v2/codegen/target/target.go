@@ -0,0 +1,158 @@
+package target
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bjatkin/yok/errors"
+)
+
+// Target is a shell that yok can generate code for. The zero value is POSIX
+type Target int
+
+const (
+	// POSIX is any POSIX sh, only portable constructs are generated and the non-portable
+	// commands and printf verbs are refused. Other commands are not checked
+	POSIX = Target(iota)
+	// Bash is bash 4 or later
+	Bash
+	// Dash is the Debian Almquist shell
+	Dash
+	// Ash is the busybox ash shell
+	Ash
+	// Zsh is the Z shell
+	Zsh
+)
+
+// Feature is a shell feature that is not part of POSIX sh
+type Feature int
+
+const (
+	// DoubleBrackets is the [[ ]] test command
+	DoubleBrackets = Feature(iota)
+	// Local is the local keyword for declaring function variables
+	Local
+	// ANSIQuotes are $'..' strings which decode escape sequences like $'\033'
+	ANSIQuotes
+	// PatternSubstitution is the ${a//old/new} parameter expansion
+	PatternSubstitution
+	// CaseModification are the ${a^^} and ${a,,} parameter expansions
+	CaseModification
+	// PrintfQuote is the %q verb of the printf builtin
+	PrintfQuote
+	// Pipefail is the pipefail shell option (e.g. set -o pipefail)
+	Pipefail
+	// GlobSubst is the ${~a} parameter expansion, which uses the value of a as a glob pattern.
+	// Other shells already use unquoted expansions as patterns but zsh only does with GLOB_SUBST set
+	GlobSubst
+)
+
+// nonPortableCommands are bash and zsh builtins that are not part of POSIX sh.
+// This is not a full portability check, any other command is assumed to be available
+var nonPortableCommands = []string{
+	"source",
+	"declare",
+	"typeset",
+	"shopt",
+	"pushd",
+	"popd",
+	"dirs",
+	"mapfile",
+	"readarray",
+}
+
+// targets contains the name, shebang, features and the non-portable builtins of each target
+var targets = map[Target]struct {
+	name     string
+	shebang  string
+	features []Feature
+	commands []string
+}{
+	POSIX: {
+		name:    "posix",
+		shebang: "#!/bin/sh",
+	},
+	Bash: {
+		name:     "bash",
+		shebang:  "#!/usr/bin/env bash",
+		features: []Feature{DoubleBrackets, Local, ANSIQuotes, PatternSubstitution, CaseModification, PrintfQuote, Pipefail},
+		commands: nonPortableCommands,
+	},
+	Dash: {
+		name:     "dash",
+		shebang:  "#!/bin/dash",
+		features: []Feature{Local},
+	},
+	Ash: {
+		name:     "ash",
+		shebang:  "#!/bin/ash",
+		features: []Feature{Local, ANSIQuotes, PatternSubstitution, Pipefail},
+	},
+	Zsh: {
+		name:     "zsh",
+		shebang:  "#!/usr/bin/env zsh",
+		features: []Feature{DoubleBrackets, Local, ANSIQuotes, PatternSubstitution, PrintfQuote, Pipefail, GlobSubst},
+		commands: []string{"source", "declare", "typeset", "pushd", "popd", "dirs"},
+	},
+}
+
+// order is the order targets are listed in help text and error messages
+var order = []Target{POSIX, Bash, Dash, Ash, Zsh}
+
+// String returns the name of the target as it's used by the --target flag
+func (t Target) String() string {
+	return targets[t].name
+}
+
+// Shebang returns the first line of scripts generated for the target
+func (t Target) Shebang() string {
+	return targets[t].shebang
+}
+
+// Supports returns true if the target shell has the feature
+func (t Target) Supports(feature Feature) bool {
+	return slices.Contains(targets[t].features, feature)
+}
+
+// HasCommand returns false if the command is a non-portable builtin that the target shell does not have
+func (t Target) HasCommand(command string) bool {
+	return !slices.Contains(nonPortableCommands, command) || slices.Contains(targets[t].commands, command)
+}
+
+// Parse returns the target with the given name
+func Parse(name string) (Target, error) {
+	for _, t := range order {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+
+	return POSIX, errors.New(fmt.Sprintf("unknown target '%s', expected one of %s", name, Names()))
+}
+
+// Names returns the names of all the targets (e.g. posix, bash, dash, ash or zsh)
+func Names() string {
+	names := []string{}
+	for _, t := range order {
+		names = append(names, t.String())
+	}
+
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// Set parses the name of the target so a Target can be used as a command line flag
+func (t *Target) Set(name string) error {
+	parsed, err := Parse(name)
+	if err != nil {
+		return err
+	}
+
+	*t = parsed
+	return nil
+}
+
+// Type is the type of the value shown in the help text of command line flags
+func (t *Target) Type() string {
+	return "target"
+}
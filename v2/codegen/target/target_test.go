@@ -0,0 +1,78 @@
+package target
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Target
+		wantErr string
+	}{
+		{
+			name: "posix",
+			want: POSIX,
+		},
+		{
+			name: "bash",
+			want: Bash,
+		},
+		{
+			name: "ash",
+			want: Ash,
+		},
+		{
+			name:    "fish",
+			want:    POSIX,
+			wantErr: "unknown target 'fish', expected one of posix, bash, dash, ash or zsh",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.name)
+			if got != tt.want {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+
+			if err != nil && err.Error() != tt.wantErr || err == nil && tt.wantErr != "" {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTarget_Supports(t *testing.T) {
+	if POSIX.Supports(Local) {
+		t.Errorf("POSIX.Supports(Local) = true, want false")
+	}
+
+	if !Dash.Supports(Local) || Dash.Supports(DoubleBrackets) {
+		t.Errorf("Dash should support Local but not DoubleBrackets")
+	}
+
+	if !Bash.Supports(CaseModification) || Zsh.Supports(CaseModification) {
+		t.Errorf("Bash should support CaseModification but Zsh should not")
+	}
+}
+
+func TestTarget_HasCommand(t *testing.T) {
+	tests := []struct {
+		target  Target
+		command string
+		want    bool
+	}{
+		{target: POSIX, command: "source", want: false},
+		{target: Dash, command: "source", want: false},
+		{target: Ash, command: "mapfile", want: false},
+		{target: Bash, command: "shopt", want: true},
+		{target: Zsh, command: "typeset", want: true},
+		{target: Zsh, command: "mapfile", want: false},
+		{target: POSIX, command: "grep", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target.String()+" "+tt.command, func(t *testing.T) {
+			if got := tt.target.HasCommand(tt.command); got != tt.want {
+				t.Errorf("Target.HasCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,9 +2,12 @@ package compiler
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/bjatkin/yok/ast/shast"
 	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/codegen/target"
 	"github.com/bjatkin/yok/errors"
 )
 
@@ -17,100 +20,342 @@ func (c *Compiler) compileCall(call *yokast.Call) shast.Expr {
 		args = append(args, expr)
 	}
 
+	if namedArgs, ok := builtinNamedArgs[command]; ok && !c.checkNamedArgs(command, call, namedArgs) {
+		return nil
+	}
+
 	switch command {
 	case "print":
-		return compilePrint(args)
+		return c.compilePrint(call, args, false)
+	case "eprint":
+		return c.compilePrint(call, args, true)
+	case "printf":
+		return c.compilePrintf(call, args)
 	case "len":
-		len, err := compileLen(args)
+		len, err := compileLen(call, args)
 		if err != nil {
 			c.addError(err)
 			return nil
 		}
 
 		return &shast.ParamaterExpansion{Expression: len}
-	case "remove_prefix":
-		remove, err := compileRemoveFix(args)
+	case "remove_prefix", "remove_suffix":
+		remove, err := c.compileRemoveFix(call, command, args)
 		if err != nil {
 			c.addError(err)
 			return nil
 		}
-		remove.RemovePrefix = true
 
 		return &shast.ParamaterExpansion{Expression: remove}
-	case "remove_suffix":
-		remove, err := compileRemoveFix(args)
-		if err != nil {
-			c.addError(err)
+	case "replace", "replace_all", "split", "upper", "lower", "trim", "contains", "starts_with", "ends_with", "matches":
+		return c.compileHelperCall(call, command, args)
+	default:
+		if !c.options.Target.HasCommand(command) {
+			msg := fmt.Sprintf("%s() is not supported by the %s target, use --target to build for a shell that supports it", command, c.options.Target)
+			c.addError(errors.NewPos(call.Identifier.Token.Pos, msg))
 			return nil
 		}
-		remove.RemovePrefix = false
 
-		return &shast.ParamaterExpansion{Expression: remove}
-	default:
 		return &shast.Exec{
 			Command:   command,
 			Arguments: args,
+			Env:       c.compileCallEnv(call),
+		}
+	}
+}
+
+// builtinNamedArgs maps each yok builtin function to the named arguments that it supports
+var builtinNamedArgs = map[string][]string{
+	"print":         {"end"},
+	"eprint":        {"end"},
+	"printf":        nil,
+	"len":           nil,
+	"remove_prefix": {"shortest"},
+	"remove_suffix": {"shortest"},
+	"replace":       nil,
+	"replace_all":   nil,
+	"split":         nil,
+	"upper":         nil,
+	"lower":         nil,
+	"trim":          nil,
+	"contains":      nil,
+	"starts_with":   nil,
+	"ends_with":     nil,
+	"matches":       nil,
+}
+
+// checkNamedArgs checks that the call only uses the given named arguments
+func (c *Compiler) checkNamedArgs(command string, call *yokast.Call, allowed []string) bool {
+	ok := true
+	for _, arg := range call.NamedArguments {
+		name := arg.Name.Name(c.source)
+		if !slices.Contains(allowed, name) {
+			c.addError(errors.NewPos(arg.Name.Token.Pos, fmt.Sprintf("%s() does not support the named argument '%s'", command, name)))
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+// namedArg returns the value of the named argument if it was set in the call
+func namedArg(call *yokast.Call, name string, source []byte) (yokast.Expr, bool) {
+	for _, arg := range call.NamedArguments {
+		if arg.Name.Name(source) == name {
+			return arg.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// compileCallEnv compiles the named arguments of a command call. Currently the only supported
+// named argument is env, which sets environment variables for just that command
+//
+// Example:
+//
+//	make(env={CC: "clang"}) -> CC=clang make
+func (c *Compiler) compileCallEnv(call *yokast.Call) []shast.EnvAssign {
+	env := []shast.EnvAssign{}
+	for _, arg := range call.NamedArguments {
+		name := arg.Name.Name(c.source)
+		if name != "env" {
+			c.addError(errors.NewPos(arg.Name.Token.Pos, fmt.Sprintf("unknown named argument '%s', commands only support env", name)))
+			continue
+		}
+
+		dict, ok := arg.Value.(*yokast.Dict)
+		if !ok {
+			c.addError(errors.NewPos(arg.Name.Token.Pos, "env must be a dict literal (e.g. env={CC: \"clang\"})"))
+			continue
 		}
+
+		for _, entry := range dict.Entries {
+			env = append(env, shast.EnvAssign{
+				Name:  entry.Key.Value(c.source),
+				Value: c.compileExpr(entry.Value),
+			})
+		}
+	}
+
+	return env
+}
+
+// compilePrint compiles print and eprint into a call to printf. The arguments are separated by spaces
+// and followed by a new line, unless a different ending is set with the end argument.
+// eprint writes to stderr instead of stdout
+//
+// Example:
+//
+//	print(a, "b")         -> printf '%s %s\n' "$A" b
+//	print(a, end="")      -> printf '%s' "$A"
+//	eprint("oh no")       -> printf '%s\n' "oh no" >&2
+func (c *Compiler) compilePrint(call *yokast.Call, args []shast.Expr, stderr bool) *shast.Exec {
+	format := strings.Repeat("%s ", len(args))
+	format = strings.TrimSuffix(format, " ")
+
+	end, ok := namedArg(call, "end", c.source)
+	switch {
+	case !ok:
+		format += "\\n"
+	case isStringLiteral(end):
+		format += escapePrintf(end.(*yokast.String).Decoded())
+	default:
+		// the end is not known at compile time so it's passed as an argument instead
+		format += "%s"
+		args = append(args, c.compileExpr(end))
+	}
+
+	exec := &shast.Exec{
+		Command:   "printf",
+		Arguments: append([]shast.Expr{&shast.String{Value: format}}, args...),
 	}
+	if stderr {
+		exec.Redirects = []shast.Redirect{{RightFd: "2"}}
+	}
+
+	return exec
 }
 
-// compilePrint takes a list of arguments and complies a call to the echo command
-func compilePrint(args []shast.Expr) *shast.Exec {
+// isStringLiteral returns true if the expression is a yok string literal
+func isStringLiteral(expr yokast.Expr) bool {
+	_, ok := expr.(*yokast.String)
+	return ok
+}
+
+// printfEscaper escapes the characters that printf would decode in a format string.
+// New lines and tabs are also written as escape sequences so the format stays on one line
+var printfEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\t", "\\t")
+
+// escapePrintf escapes a literal string so it can be used as part of a printf format string
+func escapePrintf(value string) string {
+	return strings.ReplaceAll(printfEscaper.Replace(value), "%", "%%")
+}
+
+// compilePrintf compiles a call to printf. The format must be a string literal so that the number of arguments
+// can be checked at compile time. The format is escaped so that yok escape sequences are not decoded a second time by printf
+//
+// Example:
+//
+//	printf("%s is %d\n", name, age) -> printf '%s is %d\n' "$NAME" "$AGE"
+func (c *Compiler) compilePrintf(call *yokast.Call, args []shast.Expr) shast.Expr {
+	if len(call.Arguments) == 0 {
+		c.addError(errors.NewPos(call.Identifier.Token.Pos, "printf() requires a format string"))
+		return nil
+	}
+
+	format, ok := call.Arguments[0].(*yokast.String)
+	if !ok {
+		c.addError(errors.NewPos(call.Identifier.Token.Pos, "printf() format must be a string literal"))
+		return nil
+	}
+
+	want, err := countPrintfArgs(format.Decoded(), c.options.Target)
+	if err != nil {
+		c.addError(errors.NewPos(format.Token.Pos, err.Error()))
+		return nil
+	}
+
+	if want != len(args)-1 {
+		msg := fmt.Sprintf("printf() format uses %d arguments but got %d", want, len(args)-1)
+		c.addError(errors.NewPos(call.Identifier.Token.Pos, msg))
+		return nil
+	}
+
+	args[0] = &shast.String{Value: printfEscaper.Replace(format.Decoded())}
 	return &shast.Exec{
-		Command:   "echo",
+		Command:   "printf",
 		Arguments: args,
-		Redirects: []shast.Redirect{{RightFd: "2"}},
 	}
 }
 
-// compileLen takes in a list of arguments and complies a *shast.ParameterLength
-func compileLen(args []shast.Expr) (*shast.ParameterLength, error) {
+// countPrintfArgs counts the number of arguments that are used by a printf format string.
+// Each verb uses an argument, and so does each * width or precision.
+// The %q verb is only allowed if the printf builtin of the target supports it
+func countPrintfArgs(format string, t target.Target) (int, error) {
+	args := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+
+		// skip the flags, width and precision
+		i++
+		for i < len(format) && strings.IndexByte("-+ #0123456789.*", format[i]) >= 0 {
+			if format[i] == '*' {
+				args++
+			}
+			i++
+		}
+
+		if i >= len(format) {
+			return 0, errors.New("printf() format ends with an incomplete verb")
+		}
+
+		switch {
+		case format[i] == '%':
+			// %% is a literal percent sign and does not use an argument
+		case strings.IndexByte("diouxXfeEgGcsb", format[i]) >= 0:
+			args++
+		case format[i] == 'q' && t.Supports(target.PrintfQuote):
+			args++
+		case format[i] == 'q':
+			return 0, errors.New(fmt.Sprintf("printf() verb %%q is not supported by the %s target", t))
+		default:
+			return 0, errors.New(fmt.Sprintf("printf() format has an unknown verb %%%c", format[i]))
+		}
+	}
+
+	return args, nil
+}
+
+// compileHelperCall compiles a call to a builtin that is implemented with an sh helper function,
+// the helper is added to the script the first time it's used
+//
+// Example:
+//
+//	upper(name) -> _yok_upper "$NAME"
+func (c *Compiler) compileHelperCall(call *yokast.Call, command string, args []shast.Expr) shast.Expr {
+	helper := helpers[command]
+	if len(args) != helper.args {
+		msg := fmt.Sprintf("%s() takes %d arguments but got %d", command, helper.args, len(args))
+		c.addError(errors.NewPos(call.Identifier.Token.Pos, msg))
+		return nil
+	}
+
+	c.helpers[command] = true
+	return &shast.Exec{
+		Command:   helperPrefix + command,
+		Arguments: args,
+	}
+}
+
+// compileLen takes in a list of arguments and complies a *shast.ParameterLength.
+// The fixer hoists any argument that is not a variable into a temporary variable
+//
+// Example:
+//
+//	len(name) -> ${#NAME}
+func compileLen(call *yokast.Call, args []shast.Expr) (*shast.ParameterLength, error) {
 	if len(args) != 1 {
-		return nil, errors.New("len() takes only a single argument")
+		msg := fmt.Sprintf("len() takes 1 argument but got %d", len(args))
+		return nil, errors.NewPos(call.Identifier.Token.Pos, msg)
 	}
 
 	identifier, ok := args[0].(*shast.Identifier)
 	if !ok {
-		return nil, errors.New(fmt.Sprintf("len() only supports identifiers, but got (%T)%#v", args[0], args[0]))
+		return nil, errors.NewPos(call.Identifier.Token.Pos, "len() argument could not be converted into a variable")
 	}
 
-	// TODO: fix this to support expressions other than identifiers
 	return &shast.ParameterLength{
 		Paramater: identifier,
 	}, nil
 }
 
-// compileRemoveFix takes a list of arguments and compiles a shast.ParamaterRemoveFix
-func compileRemoveFix(args []shast.Expr) (*shast.ParamaterRemoveFix, error) {
+// compileRemoveFix compiles remove_prefix and remove_suffix into a shast.ParamaterRemoveFix.
+// The longest match is removed unless shortest is set to :true
+//
+// Example:
+//
+//	remove_prefix(file, "./")                  -> ${FILE##./}
+//	remove_prefix(file, '*/')                  -> ${FILE##*/}
+//	remove_suffix(file, '.*', shortest=:true)  -> ${FILE%.*}
+func (c *Compiler) compileRemoveFix(call *yokast.Call, command string, args []shast.Expr) (*shast.ParamaterRemoveFix, error) {
 	if len(args) != 2 {
-		return nil, errors.New("remove_suffix() takes 2 arguments")
+		msg := fmt.Sprintf("%s() takes 2 arguments but got %d", command, len(args))
+		return nil, errors.NewPos(call.Identifier.Token.Pos, msg)
 	}
 
 	identifier, ok := args[0].(*shast.Identifier)
 	if !ok {
-		return nil, errors.New("remove_suffix() first argument must be an identifier")
+		msg := fmt.Sprintf("%s() first argument could not be converted into a variable", command)
+		return nil, errors.NewPos(call.Identifier.Token.Pos, msg)
 	}
 
-	remove, ok := isStringOrCommand(args[1])
-	if !ok {
-		return nil, errors.New("remove_suffix() second argument must be string")
+	shortest := false
+	if value, ok := namedArg(call, "shortest", c.source); ok {
+		atom, ok := value.(*yokast.Atom)
+		switch {
+		case ok && atom.Token.Value(c.source) == ":true":
+			shortest = true
+		case ok && atom.Token.Value(c.source) == ":false":
+			shortest = false
+		default:
+			msg := fmt.Sprintf("%s() shortest must be :true or :false", command)
+			return nil, errors.NewPos(call.Identifier.Token.Pos, msg)
+		}
 	}
 
-	return &shast.ParamaterRemoveFix{
-		Paramater: identifier,
-		Remove:    remove,
-	}, nil
-}
-
-func isStringOrCommand(expr shast.Expr) (shast.Expr, bool) {
-	strExpr, ok := expr.(*shast.String)
-	if ok {
-		return strExpr, ok
-	}
-	cmdExpr, ok := expr.(*shast.CommandSub)
-	if ok {
-		return cmdExpr, ok
+	// pattern literals are matched as globs, everything else is matched literally
+	remove := args[1]
+	if pattern, ok := call.Arguments[1].(*yokast.Pattern); ok {
+		remove = &shast.Pattern{Value: pattern.Value(c.source)}
 	}
 
-	return expr, false
+	return &shast.ParamaterRemoveFix{
+		RemovePrefix: command == "remove_prefix",
+		Shortest:     shortest,
+		Paramater:    identifier,
+		Remove:       remove,
+	}, nil
 }
@@ -6,31 +6,57 @@ import (
 
 	"github.com/bjatkin/yok/ast/shast"
 	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/codegen/target"
 	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/sym"
+	"github.com/bjatkin/yok/token"
+	"github.com/bjatkin/yok/types"
 )
 
-// quoteIdentifiers is a shast.Visitor quotes all the identifiers
-type quoteIdentifiers struct{}
-
-// Visit implements the shast.Visitor interface
-func (q *quoteIdentifiers) Visit(node shast.Node) shast.Visitor {
-	if identifier, ok := node.(*shast.Identifier); ok {
-		identifier.Quoted = true
-	}
-
-	return q
-}
-
 // Compiler can be used to compile code from a yok AST into an sh AST
 type Compiler struct {
-	errors []error
-	source []byte
+	errors   []error
+	warnings []error
+	source   []byte
+	symbols  *sym.Table
+	names    *namer
+	// helpers is the set of builtin helper functions used by the script
+	helpers map[string]bool
+	// consts are the folded values of all the constants in the script
+	consts  map[*sym.Symbol]string
+	options Options
+	// types are the inferred types of the script, it's nil unless type checking is enabled
+	types *types.Info
+}
+
+// Options are used to configure the compiler
+type Options struct {
+	// ReadonlyConsts also declares constants as readonly sh variables so they exist at runtime.
+	// Uses of the constants are inlined either way
+	ReadonlyConsts bool
+	// TypeCheck runs the type checker before compiling. Types are also used to pick
+	// between string and integer comparisons in the generated sh
+	TypeCheck bool
+	// Target is the shell the script is compiled for, builtins use the features of the target
+	// where they're available. Non-portable commands that the target does not have are refused
+	Target target.Target
+	// Strict starts the script with set -eu so failed commands and unset variables stop the script.
+	// Scripts can also turn on strict mode with a #yok:strict comment
+	Strict bool
 }
 
 // New creates a new compiler
 func New(source []byte) *Compiler {
+	return NewWithOptions(source, Options{})
+}
+
+// NewWithOptions creates a new compiler with the given options
+func NewWithOptions(source []byte, options Options) *Compiler {
 	return &Compiler{
-		source: source,
+		source:  source,
+		helpers: map[string]bool{},
+		consts:  map[*sym.Symbol]string{},
+		options: options,
 	}
 }
 
@@ -39,10 +65,54 @@ func (c *Compiler) addError(err error) {
 	c.errors = append(c.errors, err)
 }
 
+// Errors returns all the errors that were found while compiling the script
+func (c *Compiler) Errors() []error {
+	return c.errors
+}
+
+// Warnings returns all the warnings that were found while compiling the script.
+// Warnings do not prevent the script from being compiled
+func (c *Compiler) Warnings() []error {
+	return c.warnings
+}
+
+// Symbols returns the symbol table for the compiled script
+func (c *Compiler) Symbols() *sym.Table {
+	return c.symbols
+}
+
 // Compile creates an shast.Script from the given yokast.Script
 func (c *Compiler) Compile(script *yokast.Script) (*shast.Script, error) {
+	// resolve the variables before the fixer adds any internal identifiers
+	resolver := sym.New(c.source)
+	c.symbols, _ = resolver.Resolve(script)
+	c.errors = append(c.errors, resolver.Errors...)
+	c.warnings = append(c.warnings, resolver.Warnings...)
+	if len(c.errors) > 0 {
+		return nil, errors.New("there were errors durring compilation")
+	}
+
+	if c.options.TypeCheck {
+		checker := types.New(c.source, c.symbols)
+		c.types, _ = checker.Check(script)
+		c.errors = append(c.errors, checker.Errors...)
+		if len(c.errors) > 0 {
+			return nil, errors.New("there were errors durring compilation")
+		}
+	}
+
 	// fix the yokast before trying to complie to sh AST
-	f := fixer{source: c.source}
+	c.names = newNamer(c.symbols)
+	c.errors = append(c.errors, c.names.errors...)
+	if len(c.errors) > 0 {
+		return nil, errors.New("there were errors durring compilation")
+	}
+
+	if hasStrictDirective(script.Statements, c.source) {
+		c.options.Strict = true
+	}
+
+	f := fixer{source: c.source, names: c.names, symbols: c.symbols, strict: c.options.Strict}
 	script.Statements = f.walkStmts(script.Statements)
 
 	stmts := c.compileStatements(script.Statements)
@@ -51,53 +121,98 @@ func (c *Compiler) Compile(script *yokast.Script) (*shast.Script, error) {
 		return nil, errors.New("there were errors durring compilation")
 	}
 
-	return &shast.Script{
+	// helper functions must be defined before they are called
+	stmts = append(helperFunctions(c.helpers, c.options.Target), stmts...)
+	if c.options.Strict {
+		stmts = append(strictPreamble(c.options.Target), stmts...)
+	}
+
+	shScript := &shast.Script{
 		Statements: stmts,
-	}, nil
+	}
+	shast.Walk(&quoteExpansions{}, shScript)
+
+	return shScript, nil
 }
 
 // compileStatements compiles a slice of yokast.Stmts into a list of shast.Stmts
 func (c *Compiler) compileStatements(statements []yokast.Stmt) []shast.Stmt {
 	stmts := []shast.Stmt{}
+	dropped := false
 	for _, yokStmt := range statements {
 		stmt := c.compileStmt(yokStmt)
+		if stmt == nil {
+			// statements like const declarations do not generate any sh code
+			dropped = true
+			continue
+		}
+
+		// don't leave extra blank lines where statements were dropped
+		if _, ok := stmt.(*shast.NewLine); ok && dropped && endsWithNewLine(stmts) {
+			continue
+		}
+
+		dropped = false
 		stmts = append(stmts, stmt)
 	}
 
 	return stmts
 }
 
+// endsWithNewLine returns true if the statements are empty or end with a blank line
+func endsWithNewLine(stmts []shast.Stmt) bool {
+	if len(stmts) == 0 {
+		return true
+	}
+
+	_, ok := stmts[len(stmts)-1].(*shast.NewLine)
+	return ok
+}
+
 // complieNode converts a yokast.Stmt into it's equivilant shast.Stmt
 func (c *Compiler) compileStmt(stmt yokast.Stmt) shast.Stmt {
 	switch s := stmt.(type) {
 	case *yokast.NewLine:
-		return &shast.NewLine{}
+		return &shast.NewLine{Pos: s.Pos}
 	case *yokast.Comment:
-		return &shast.Comment{
-			Value: s.Token.Value(c.source),
-		}
+		return c.compileComment(s)
 	case *yokast.Assign:
-		identifier := s.Identifier.Name(c.source)
-		identifier = strings.ToUpper(identifier)
-
-		value := c.compileExpr(s.Value)
-		_, ok := value.(*shast.InfixExpr)
-		if ok {
-			value = &shast.ArithmeticCommand{Expression: value}
+		if s.Const {
+			return c.compileConst(s)
 		}
 
-		return &shast.Assign{
-			Identifier: identifier,
-			Value:      value,
+		t := c.annotatedType(s)
+		value := s.Value
+		if value == nil {
+			value = yokast.NewInternalString(t.Zero(), token.Token{Type: token.StringLiteral})
 		}
+
+		assign := c.compileAssign(s.Pos, c.identifierName(s.Identifier), value, s.Comment)
+		assign.Export = s.Export
+		return assign
+	case *yokast.Reassign:
+		// sh does not distinguish between declaring and reassigning a variable
+		return c.compileAssign(s.Pos, c.identifierName(s.Identifier), s.Value, s.Comment)
+	case *yokast.EnvAssign:
+		assign := c.compileAssign(s.Pos, s.Variable.VarName(c.source), s.Value, s.Comment)
+		assign.Export = true
+		return assign
 	case *yokast.StmtExpr:
 		expression := c.compileExpr(s.Expression)
 		_, ok := expression.(*shast.InfixExpr)
 		if ok {
-			expression = &shast.ArithmeticCommand{Expression: expression}
+			// the result of the math is passed to the ':' command, otherwise sh would try to run it as a command
+			expression = &shast.Exec{
+				Command:   ":",
+				Arguments: []shast.Expr{&shast.ArithmeticCommand{Expression: expression}},
+			}
 		}
 
-		return &shast.StmtExpr{Expression: expression}
+		return &shast.StmtExpr{
+			Pos:        s.Pos,
+			Expression: expression,
+			Comment:    c.compileComment(s.Comment),
+		}
 	case *yokast.If:
 		test := c.complieTestCommand(s.Test)
 		stmts := c.compileStatements(s.Body.Statements)
@@ -106,54 +221,164 @@ func (c *Compiler) compileStmt(stmt yokast.Stmt) shast.Stmt {
 		for _, elseIf := range s.ElseIfs {
 			test := c.complieTestCommand(elseIf.Test)
 			stmts := c.compileStatements(elseIf.Body.Statements)
-			elseIfs = append(elseIfs, shast.ElseIf{Test: test, Statements: stmts})
+			elseIfs = append(elseIfs, shast.ElseIf{Pos: elseIf.Pos, Test: test, Statements: stmts})
 		}
 
 		if s.ElseBody == nil {
 			return &shast.If{
+				Pos:        s.Pos,
 				Test:       test,
 				Statements: stmts,
 				ElseIfs:    elseIfs,
+				Comment:    c.compileComment(s.Comment),
 			}
 		}
 
 		elseStmts := c.compileStatements(s.ElseBody.Statements)
 		return &shast.If{
+			Pos:            s.Pos,
 			Test:           test,
 			Statements:     stmts,
 			ElseIfs:        elseIfs,
 			ElseStatements: elseStmts,
+			Comment:        c.compileComment(s.Comment),
 		}
 	default:
 		panic(fmt.Sprintf("Unknown statement type %T", s))
 	}
 }
 
+// compileAssign compiles a variable assignment into an shast.Assign
+func (c *Compiler) compileAssign(pos token.Pos, identifier string, expr yokast.Expr, comment *yokast.Comment) *shast.Assign {
+	value := c.compileExpr(expr)
+	switch v := value.(type) {
+	case *shast.InfixExpr:
+		value = &shast.ArithmeticCommand{Expression: v}
+	case *shast.Exec:
+		// the output of the command is assigned to the variable
+		value = &shast.CommandSub{Expression: v}
+	}
+
+	return &shast.Assign{
+		Pos:        pos,
+		Identifier: identifier,
+		Value:      value,
+		Comment:    c.compileComment(comment),
+	}
+}
+
+// annotatedType returns the type annotation of the let statement, or types.Unknown if there is no annotation.
+// The zero value of the type is used when the statement does not set a value
+//
+// Example:
+//
+//	let a int -> A=0
+func (c *Compiler) annotatedType(assign *yokast.Assign) types.Type {
+	if assign.Type == nil {
+		return types.Unknown
+	}
+
+	name := assign.Type.Name(c.source)
+	t, ok := types.Parse(name)
+	if !ok {
+		c.addError(errors.NewPos(assign.Type.Token.Pos, types.UnknownTypeMsg(name)))
+	}
+
+	return t
+}
+
+// compileConst folds the value of a constant so it can be inlined everywhere it's used.
+// Nothing is generated for the constant unless it's also declared as a readonly variable
+func (c *Compiler) compileConst(assign *yokast.Assign) shast.Stmt {
+	value, err := c.foldConst(assign.Identifier, assign.Value)
+	if err != nil {
+		c.addError(err)
+		return nil
+	}
+
+	symbol, _ := c.symbols.Lookup(assign.Identifier)
+	c.consts[symbol] = value
+
+	if !c.options.ReadonlyConsts {
+		return nil
+	}
+
+	return &shast.Assign{
+		Pos:        assign.Pos,
+		Identifier: c.identifierName(assign.Identifier),
+		Value:      &shast.String{Value: value},
+		Comment:    c.compileComment(assign.Comment),
+		Readonly:   true,
+	}
+}
+
+// identifierName returns the sh variable name for a yok identifier. Internal identifiers
+// created by the compiler are not in the symbol table and already have unique sh names
+func (c *Compiler) identifierName(ident *yokast.Identifier) string {
+	symbol, ok := c.symbols.Lookup(ident)
+	if !ok {
+		return ident.Name(c.source)
+	}
+
+	return c.names.name(symbol)
+}
+
+// compileComment converts a yokast.Comment into an shast.Comment, nil comments are left as nil
+// so that statements without trailing comments can be compiled with the same code
+func (c *Compiler) compileComment(comment *yokast.Comment) *shast.Comment {
+	if comment == nil {
+		return nil
+	}
+
+	return &shast.Comment{
+		Pos:   comment.Token.Pos,
+		Value: comment.Token.Value(c.source),
+	}
+}
+
 // complieExpr converts a yokast.Expr into it's equivilant shast.Expr
 func (c *Compiler) compileExpr(expr yokast.Expr) shast.Expr {
 	switch e := expr.(type) {
 	case *yokast.String:
-		value := e.Value(c.source)
-		return &shast.String{Value: value}
+		return &shast.String{Value: e.Decoded()}
 	case *yokast.Atom:
 		value := e.Token.Value(c.source)
 		value = strings.TrimPrefix(value, ":")
-		value = "\"" + value + "\""
 
 		return &shast.String{Value: value}
+	case *yokast.Pattern:
+		// patterns used as values are plain strings, they are only matched as globs by builtins like matches()
+		return &shast.String{Value: e.Value(c.source)}
 	case *yokast.Identifier:
-		value := e.Name(c.source)
-		value = strings.ToUpper(value)
+		if value, ok := c.constValue(e); ok {
+			return &shast.String{Value: value}
+		}
 
-		return &shast.Identifier{Value: value}
+		return &shast.Identifier{Value: c.identifierName(e)}
+	case *yokast.EnvVar:
+		// environment variables are used exactly as they are written
+		paramater := &shast.Identifier{Value: e.VarName(c.source)}
+		if c.options.Strict {
+			// environment variables may not be set, so they're read as empty strings rather than stopping the script
+			return &shast.ParamaterExpansion{Expression: &shast.ParamaterUnset{Paramater: paramater}}
+		}
+
+		return paramater
 	case *yokast.Call:
 		return c.compileCall(e)
 	case *yokast.InfixExpr:
+		if e.Operator.Type == token.OrKeyword {
+			return c.compileDefault(e)
+		}
+
 		left := c.compileExpr(e.Left)
 		right := c.compileExpr(e.Right)
 
 		operator := e.Operator.Value(c.source)
 		operator = convertOperator(operator)
+		if c.isIntComparison(e) {
+			operator = convertIntOperator(operator)
+		}
 
 		return &shast.InfixExpr{
 			Left:     left,
@@ -166,6 +391,9 @@ func (c *Compiler) compileExpr(expr yokast.Expr) shast.Expr {
 		return &shast.GroupExpr{
 			Expression: expr,
 		}
+	case *yokast.Dict:
+		c.addError(errors.NewPos(e.Token.Pos, "dict literals can only be used as the env argument of a call"))
+		return nil
 	case *yokast.NestedCall:
 		expr := c.compileExpr(e.Call)
 
@@ -177,16 +405,96 @@ func (c *Compiler) compileExpr(expr yokast.Expr) shast.Expr {
 	}
 }
 
-// complieTestCommand complies the given test into an shast.TestCommand
+// compileDefault compiles an 'or' expression into a paramater expansion with a default value.
+// The left side must be a variable, the right side is used if the variable is unset or empty
+//
+// Example:
+//
+//	env("PORT") or :8080 -> ${PORT:-8080}
+func (c *Compiler) compileDefault(expr *yokast.InfixExpr) shast.Expr {
+	// the default also covers unset environment variables so they're used directly, even in strict mode
+	var paramater *shast.Identifier
+	ok := true
+	if env, isEnv := expr.Left.(*yokast.EnvVar); isEnv {
+		paramater = &shast.Identifier{Value: env.VarName(c.source)}
+	} else {
+		paramater, ok = c.compileExpr(expr.Left).(*shast.Identifier)
+	}
+	if !ok {
+		c.addError(errors.NewPos(expr.Operator.Pos, "the left side of 'or' must be a variable"))
+		return nil
+	}
+
+	return &shast.ParamaterExpansion{
+		Expression: &shast.ParamaterDefault{
+			Paramater: paramater,
+			Default:   c.compileExpr(expr.Right),
+		},
+	}
+}
+
+// complieTestCommand complies the given test into an shast.TestCommand.
+// Comparisons are tested directly, any other value is only true if it is equal to true
 func (c *Compiler) complieTestCommand(test yokast.Expr) *shast.TestCommand {
 	expr := c.compileExpr(test)
+	if exec, ok := expr.(*shast.Exec); ok {
+		// the test uses the output of the command
+		expr = &shast.CommandSub{Expression: exec}
+	}
 
-	v := &quoteIdentifiers{}
-	shast.Walk(v, expr)
+	if !isComparison(expr) {
+		expr = &shast.InfixExpr{
+			Left:     expr,
+			Operator: "=",
+			Right:    &shast.String{Value: "true"},
+		}
+	}
 
 	return &shast.TestCommand{Expression: expr}
 }
 
+// isComparison returns true if the expression is a comparison that can be used directly in a test command
+func isComparison(expr shast.Expr) bool {
+	infix, ok := expr.(*shast.InfixExpr)
+	if !ok {
+		return false
+	}
+
+	switch infix.Operator {
+	case "=", "!=", "-eq", "-ne", "-gt", "-ge", "-lt", "-le":
+		return true
+	default:
+		return false
+	}
+}
+
+// isIntComparison returns true if the expression compares two values that are known to be ints
+func (c *Compiler) isIntComparison(expr *yokast.InfixExpr) bool {
+	if c.types == nil {
+		return false
+	}
+
+	switch expr.Operator.Type {
+	case token.EqualEqual, token.NotEqual:
+		return c.types.TypeOf(expr.Left) == types.Int && c.types.TypeOf(expr.Right) == types.Int
+	default:
+		return false
+	}
+}
+
+// convertIntOperator converts the sh string comparison operator into the integer comparison operator,
+// so that values like 07 and 7 are equal
+func convertIntOperator(operator string) string {
+	switch operator {
+	case "=":
+		return "-eq"
+	case "!=":
+		return "-ne"
+	default:
+		return operator
+	}
+}
+
 // convertOperator converts the yok operator to the equivalent 'sh' operator
 func convertOperator(operator string) string {
 	switch operator {
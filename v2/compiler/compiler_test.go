@@ -3,12 +3,18 @@ package compiler
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/bjatkin/yok/diff"
+	"github.com/bjatkin/yok/errors"
 	"github.com/bjatkin/yok/parser"
 )
 
+func TestMain(m *testing.M) {
+	os.Exit(diff.RunWithOrphanCheck(m, "testdata", "*.txt"))
+}
+
 func TestCompiler_Compile(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -45,6 +51,61 @@ func TestCompiler_Compile(t *testing.T) {
 			sourceFile: "nested_expressions.yok",
 			astFile:    "nested_expressions_ast.txt",
 		},
+		{
+			name:       "names",
+			sourceFile: "names.yok",
+			astFile:    "names_ast.txt",
+		},
+		{
+			name:       "env",
+			sourceFile: "env.yok",
+			astFile:    "env_ast.txt",
+		},
+		{
+			name:       "escapes",
+			sourceFile: "escapes.yok",
+			astFile:    "escapes_ast.txt",
+		},
+		{
+			name:       "print",
+			sourceFile: "print.yok",
+			astFile:    "print_ast.txt",
+		},
+		{
+			name:       "string library",
+			sourceFile: "string_library.yok",
+			astFile:    "string_library_ast.txt",
+		},
+		{
+			name:       "remove fix",
+			sourceFile: "remove_fix.yok",
+			astFile:    "remove_fix_ast.txt",
+		},
+		{
+			name:       "patterns",
+			sourceFile: "patterns.yok",
+			astFile:    "patterns_ast.txt",
+		},
+		{
+			name:       "consts",
+			sourceFile: "consts.yok",
+			astFile:    "consts_ast.txt",
+		},
+		{
+			name:       "types",
+			sourceFile: "types.yok",
+			astFile:    "types_ast.txt",
+		},
+		{
+			name:       "strict",
+			sourceFile: "strict.yok",
+			astFile:    "strict_ast.txt",
+		},
+		{
+			name:       "if nested calls",
+			sourceFile: "if_nested_calls.yok",
+			astFile:    "if_nested_calls_ast.txt",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -81,3 +142,157 @@ func TestCompiler_Compile(t *testing.T) {
 		})
 	}
 }
+
+func TestCompiler_Compile_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		wantErrors []string
+	}{
+		{
+			name:       "export reserved name",
+			source:     "export let path = :/bin\n",
+			wantErrors: []string{"test.yok:1:12: can not export 'path' because it would overwrite $PATH, use env.PATH to set it explicitly"},
+		},
+		{
+			name:       "exported names collide",
+			source:     "export let my_var = :a\nexport let MY_VAR = :b\n",
+			wantErrors: []string{"test.yok:2:12: can not export 'MY_VAR' because 'my_var' is also exported as $MY_VAR"},
+		},
+		{
+			name:       "dict outside of env",
+			source:     "let a = {CC: \"clang\"}\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:9: dict literals can only be used as the env argument of a call"},
+		},
+		{
+			name:       "unknown named argument",
+			source:     "make(:all, jobs=:4)\n",
+			wantErrors: []string{"test.yok:1:12: unknown named argument 'jobs', commands only support env"},
+		},
+		{
+			name:       "env is not a dict",
+			source:     "make(:all, env=:4)\n",
+			wantErrors: []string{"test.yok:1:12: env must be a dict literal (e.g. env={CC: \"clang\"})"},
+		},
+		{
+			name:       "named argument for a builtin",
+			source:     "print(:hi, env={A: :b})\n",
+			wantErrors: []string{"test.yok:1:12: print() does not support the named argument 'env'"},
+		},
+		{
+			name:       "or default for a literal",
+			source:     "print(:a or :b)\n",
+			wantErrors: []string{"test.yok:1:10: the left side of 'or' must be a variable"},
+		},
+		{
+			name:       "unsupported print argument",
+			source:     "print(:hi, sep=\", \")\n",
+			wantErrors: []string{"test.yok:1:12: print() does not support the named argument 'sep'"},
+		},
+		{
+			name:       "printf too few arguments",
+			source:     "printf(\"%s and %s\", :a)\n",
+			wantErrors: []string{"test.yok:1:1: printf() format uses 2 arguments but got 1"},
+		},
+		{
+			name:       "printf too many arguments",
+			source:     "printf(\"100%%\", :a)\n",
+			wantErrors: []string{"test.yok:1:1: printf() format uses 0 arguments but got 1"},
+		},
+		{
+			name:       "printf star width uses an argument",
+			source:     "printf(\"%*d|%.*s\", :5, :42, :abc)\n",
+			wantErrors: []string{"test.yok:1:1: printf() format uses 4 arguments but got 3"},
+		},
+		{
+			name:       "printf unknown verb",
+			source:     "printf(\"%y\", :a)\n",
+			wantErrors: []string{"test.yok:1:8: printf() format has an unknown verb %y"},
+		},
+		{
+			name:       "printf verb that is not portable",
+			source:     "printf(\"%q\", :a)\n",
+			wantErrors: []string{"test.yok:1:8: printf() verb %q is not supported by the posix target"},
+		},
+		{
+			name:       "command that is not portable",
+			source:     "source(\"env.sh\")\n",
+			wantErrors: []string{"test.yok:1:1: source() is not supported by the posix target, use --target to build for a shell that supports it"},
+		},
+		{
+			name:       "printf format is not a literal",
+			source:     "let f = \"%s\"\nprintf(f, :a)\n",
+			wantErrors: []string{"test.yok:2:1: printf() format must be a string literal"},
+		},
+		{
+			name:       "wrong number of arguments for a string builtin",
+			source:     "let a = \"a b\"\nprint(replace(a, \" \"))\n",
+			wantErrors: []string{"test.yok:2:7: replace() takes 3 arguments but got 2"},
+		},
+		{
+			name:       "wrong number of arguments for remove_prefix",
+			source:     "print(remove_prefix(\"abc\"))\n",
+			wantErrors: []string{"test.yok:1:7: remove_prefix() takes 2 arguments but got 1"},
+		},
+		{
+			name:       "shortest is not a bool",
+			source:     "print(remove_suffix(\"abc\", \"c\", shortest=\"yes\"))\n",
+			wantErrors: []string{"test.yok:1:7: remove_suffix() shortest must be :true or :false"},
+		},
+		{
+			name:       "unsupported remove_prefix argument",
+			source:     "print(remove_prefix(\"abc\", \"a\", longest=:true))\n",
+			wantErrors: []string{"test.yok:1:33: remove_prefix() does not support the named argument 'longest'"},
+		},
+		{
+			name:       "const is not constant",
+			source:     "const a = echo(:hi)\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:7: const 'a' must be a literal, another constant or integer math"},
+		},
+		{
+			name:       "const uses a variable",
+			source:     "let a = :1\nconst b = a + :1\nprint(b)\n",
+			wantErrors: []string{"test.yok:2:11: const 'b' can not use 'a' because it is not a constant"},
+		},
+		{
+			name:       "const divides by zero",
+			source:     "const a = :10 / (:2 - :2)\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:15: const 'a' divides by zero"},
+		},
+		{
+			name:       "const math with a string",
+			source:     "const a = \"ten\" * :2\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:7: const 'a' uses 'ten' in integer math but it is not an integer"},
+		},
+		{
+			name:       "unknown type",
+			source:     "let a float\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:7: unknown type 'float', expected int, str or bool"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := []byte(tt.source)
+			p := parser.New(source)
+			script, err := p.Parse()
+			if err != nil {
+				t.Fatalf("Compiler.Compile() failed to parse source: %v %v", err, p.Errors)
+			}
+
+			c := New(source)
+			_, err = c.Compile(script)
+			if err == nil {
+				t.Fatal("Compiler.Compile() expected an error")
+			}
+
+			got := []string{}
+			for _, e := range c.Errors() {
+				got = append(got, errors.Format(e, "test.yok", source))
+			}
+
+			if !reflect.DeepEqual(got, tt.wantErrors) {
+				t.Errorf("Compiler.Compile() errors = %v, want %v", got, tt.wantErrors)
+			}
+		})
+	}
+}
@@ -8,6 +8,11 @@ import (
 	"github.com/bjatkin/yok/repr"
 )
 
+// Encode converts an sh script into a repr string, it's used to debug the compiler
+func Encode(script *shast.Script) string {
+	return encodeScript(script)
+}
+
 // encodeScript converts a yok script into a repr string
 func encodeScript(script *shast.Script) string {
 	array := repr.Array{}
@@ -31,22 +36,48 @@ func encodeNode(node shast.Node) repr.Value {
 	case *shast.NewLine:
 		return repr.NewObject("NewLine")
 	case *shast.Assign:
-		return repr.NewObject(
+		assign := repr.NewObject(
 			"Assign",
 			repr.NewField("Identifier", repr.String(node.Identifier)),
 			repr.NewField("Value", encodeNode(node.Value)),
 		)
-	case *shast.StmtExpr:
+		if node.Export {
+			assign.AddFields(repr.NewField("Export", repr.Bool(true)))
+		}
+		if node.Readonly {
+			assign.AddFields(repr.NewField("Readonly", repr.Bool(true)))
+		}
+		addTrailingComment(&assign, node.Comment)
+		return assign
+	case *shast.Function:
+		body := repr.Array{}
+		for _, line := range node.Body {
+			body.AddValue(repr.String(strings.ReplaceAll(line, "\"", "\\\"")))
+		}
+
 		return repr.NewObject(
+			"Function",
+			repr.NewField("Name", repr.String(node.Name)),
+			repr.NewField("Body", body),
+		)
+	case *shast.StmtExpr:
+		stmt := repr.NewObject(
 			"StmtExpr",
 			repr.NewField("Expression", encodeNode(node.Expression)),
 		)
+		addTrailingComment(&stmt, node.Comment)
+		return stmt
 	case *shast.String:
 		safeValue := strings.ReplaceAll(node.Value, "\"", "\\\"")
 		return repr.NewObject(
 			"String",
 			repr.NewField("Value", repr.String(safeValue)),
 		)
+	case *shast.Pattern:
+		return repr.NewObject(
+			"Pattern",
+			repr.NewField("Value", repr.String(strings.ReplaceAll(node.Value, "\"", "\\\""))),
+		)
 	case *shast.Exec:
 		args := encodeExprs(node.Arguments)
 
@@ -55,12 +86,24 @@ func encodeNode(node shast.Node) repr.Value {
 			redirects.AddValue(repr.String(r.String()))
 		}
 
-		return repr.NewObject(
+		exec := repr.NewObject(
 			"Execute",
 			repr.NewField("Command", repr.String(node.Command)),
 			repr.NewField("Arguments", args),
 			repr.NewField("Redirects", redirects),
 		)
+		if len(node.Env) > 0 {
+			env := repr.Array{}
+			for _, assign := range node.Env {
+				env.AddValue(repr.NewObject(
+					"EnvAssign",
+					repr.NewField("Name", repr.String(assign.Name)),
+					repr.NewField("Value", encodeNode(assign.Value)),
+				))
+			}
+			exec.AddFields(repr.NewField("Env", env))
+		}
+		return exec
 	case *shast.Identifier:
 		return repr.NewObject(
 			"Identifier",
@@ -94,13 +137,15 @@ func encodeNode(node shast.Node) repr.Value {
 		elseIfs := encodeElseIfs(node.ElseIfs)
 		elseBody := encodeStmts(node.ElseStatements)
 
-		return repr.NewObject(
+		ifStmt := repr.NewObject(
 			"IfStatement",
 			repr.NewField("Test", test),
 			repr.NewField("Body", body),
 			repr.NewField("ElseIfs", elseIfs),
 			repr.NewField("ElseBody", elseBody),
 		)
+		addTrailingComment(&ifStmt, node.Comment)
+		return ifStmt
 	case *shast.TestCommand:
 		expression := encodeNode(node.Expression)
 		return repr.NewObject(
@@ -119,15 +164,33 @@ func encodeNode(node shast.Node) repr.Value {
 			"ParamaterLenght",
 			repr.NewField("Paramater", paramater),
 		)
+	case *shast.ParamaterDefault:
+		paramater := encodeNode(node.Paramater)
+		def := encodeNode(node.Default)
+		return repr.NewObject(
+			"ParamaterDefault",
+			repr.NewField("Paramater", paramater),
+			repr.NewField("Default", def),
+		)
+	case *shast.ParamaterUnset:
+		paramater := encodeNode(node.Paramater)
+		return repr.NewObject(
+			"ParamaterUnset",
+			repr.NewField("Paramater", paramater),
+		)
 	case *shast.ParamaterRemoveFix:
 		paramater := encodeNode(node.Paramater)
 		remove := encodeNode(node.Remove)
-		return repr.NewObject(
+		removeFix := repr.NewObject(
 			"ParamaterRemoveFix",
 			repr.NewField("RemovePrefix", repr.Bool(node.RemovePrefix)),
 			repr.NewField("Paramater", paramater),
 			repr.NewField("Remove", remove),
 		)
+		if node.Shortest {
+			removeFix.AddFields(repr.NewField("Shortest", repr.Bool(true)))
+		}
+		return removeFix
 	case *shast.CommandSub:
 		expr := encodeNode(node.Expression)
 		return repr.NewObject(
@@ -139,6 +202,15 @@ func encodeNode(node shast.Node) repr.Value {
 	}
 }
 
+// addTrailingComment adds the trailing comment to the encoded statement if the statement has one
+func addTrailingComment(object *repr.Object, comment *shast.Comment) {
+	if comment == nil {
+		return
+	}
+
+	object.AddFields(repr.NewField("Comment", encodeNode(comment)))
+}
+
 // encodeElseIfs encodes a slice of ElseIf nodes into a repr.Array
 func encodeElseIfs(elseIfs []shast.ElseIf) repr.Array {
 	array := repr.Array{}
@@ -1,18 +1,21 @@
 package compiler
 
 import (
-	"fmt"
-
 	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/sym"
 	"github.com/bjatkin/yok/token"
 )
 
 // fixer fixes up the yok AST so it's closer to the sh AST that will be generated.
 // It does de-sugaring and simplifies complex code that can't be represented directly in sh
 type fixer struct {
-	source             []byte
-	errors             []error
-	internalIdentifier int
+	source []byte
+	errors []error
+	names  *namer
+	// symbols are used to find constants, they are inlined so they don't exist as sh variables
+	symbols *sym.Table
+	// strict is set when the script is compiled in strict mode
+	strict bool
 }
 
 func (f *fixer) walkStmts(statements []yokast.Stmt) []yokast.Stmt {
@@ -30,18 +33,89 @@ func (f *fixer) fixStmt(stmt yokast.Stmt) []yokast.Stmt {
 	case *yokast.Assign:
 		stmts, expr := f.fixExpr(s.Value, 0)
 		s.Value = expr
+		setPos(stmts, s.Pos)
+		return append(stmts, s)
+	case *yokast.Reassign:
+		stmts, expr := f.fixExpr(s.Value, 0)
+		s.Value = expr
+		setPos(stmts, s.Pos)
+		return append(stmts, s)
+	case *yokast.EnvAssign:
+		stmts, expr := f.fixExpr(s.Value, 0)
+		s.Value = expr
+		setPos(stmts, s.Pos)
 		return append(stmts, s)
 	case *yokast.StmtExpr:
 		stmts, expr := f.fixExpr(s.Expression, 0)
 		s.Expression = expr
+		setPos(stmts, s.Pos)
+		return append(stmts, s)
+	case *yokast.If:
+		stmts, test := f.fixExpr(s.Test, 0)
+		s.Test = test
+		setPos(stmts, s.Pos)
+		f.fixBranches(s)
 		return append(stmts, s)
 	default:
-		// TODO: this is not going to work, we actually need to walk
-		// the whole tree here
 		return []yokast.Stmt{s}
 	}
 }
 
+// fixBranches fixes the bodies of the if statement and the tests of its else if branches.
+// sh can not run statements between an if test and an elif test, so the first else if that needs
+// temporaries is turned into an if statement nested in the else branch, along with the branches that follow it
+//
+// Example:
+//
+//	if a {           if a {
+//	    ...              ...
+//	} else if b() {  } else {
+//	    ...       ->     _TMP1=b()
+//	}                    if _TMP1 {
+//	                         ...
+//	                     }
+//	                 }
+func (f *fixer) fixBranches(stmt *yokast.If) {
+	stmt.Body.Statements = f.walkStmts(stmt.Body.Statements)
+
+	for i := range stmt.ElseIfs {
+		elseIf := &stmt.ElseIfs[i]
+		stmts, test := f.fixExpr(elseIf.Test, 0)
+		elseIf.Test = test
+		if len(stmts) == 0 {
+			elseIf.Body.Statements = f.walkStmts(elseIf.Body.Statements)
+			continue
+		}
+
+		nested := &yokast.If{
+			Pos:      elseIf.Pos,
+			Test:     elseIf.Test,
+			Body:     elseIf.Body,
+			ElseIfs:  stmt.ElseIfs[i+1:],
+			ElseBody: stmt.ElseBody,
+		}
+		f.fixBranches(nested)
+		setPos(stmts, elseIf.Pos)
+
+		stmt.ElseIfs = stmt.ElseIfs[:i]
+		stmt.ElseBody = &yokast.Block{Statements: append(stmts, nested)}
+		return
+	}
+
+	if stmt.ElseBody != nil {
+		stmt.ElseBody.Statements = f.walkStmts(stmt.ElseBody.Statements)
+	}
+}
+
+// setPos sets the position of hoisted statements to the position of the statement they were hoisted from
+func setPos(stmts []yokast.Stmt, pos token.Pos) {
+	for _, stmt := range stmts {
+		if assign, ok := stmt.(*yokast.Assign); ok {
+			assign.Pos = pos
+		}
+	}
+}
+
 func (f *fixer) fixExpr(expr yokast.Expr, depth int) ([]yokast.Stmt, yokast.Expr) {
 	switch e := expr.(type) {
 	case *yokast.Call:
@@ -56,26 +130,14 @@ func (f *fixer) fixExpr(expr yokast.Expr, depth int) ([]yokast.Stmt, yokast.Expr
 			e.Arguments[0] = target
 
 			return prefix, e
-		case "remove_suffix":
-			if len(e.Arguments) != 2 {
-				return nil, e
-			}
-
-			targetStmts, target := f.simplifyToIdent(e.Arguments[0], depth+1)
-			e.Arguments[0] = target
-			removeStmts, remove := f.simplifyToStringOrCommand(e.Arguments[1], depth+1)
-			e.Arguments[1] = remove
-
-			prefix := append(targetStmts, removeStmts...)
-			return prefix, e
-		case "remove_prefix":
+		case "remove_prefix", "remove_suffix":
 			if len(e.Arguments) != 2 {
 				return nil, e
 			}
 
 			targetStmts, target := f.simplifyToIdent(e.Arguments[0], depth+1)
 			e.Arguments[0] = target
-			removeStmts, remove := f.simplifyToStringOrCommand(e.Arguments[1], depth+1)
+			removeStmts, remove := f.simplifyToWord(e.Arguments[1], depth+1)
 			e.Arguments[1] = remove
 
 			prefix := append(targetStmts, removeStmts...)
@@ -88,28 +150,70 @@ func (f *fixer) fixExpr(expr yokast.Expr, depth int) ([]yokast.Stmt, yokast.Expr
 				prefix = append(prefix, stmts...)
 			}
 
+			for i, arg := range e.NamedArguments {
+				stmts, a := f.fixExpr(arg.Value, depth+1)
+				e.NamedArguments[i].Value = a
+				prefix = append(prefix, stmts...)
+			}
+
 			if depth == 0 {
 				return prefix, e
 			}
 
 			return prefix, &yokast.NestedCall{Depth: depth, Call: e}
 		}
+	case *yokast.InfixExpr:
+		leftStmts, left := f.fixExpr(e.Left, depth+1)
+		rightStmts, right := f.fixExpr(e.Right, depth+1)
+		e.Left = left
+		e.Right = right
+
+		return append(leftStmts, rightStmts...), e
+	case *yokast.GroupExpr:
+		prefix, expr := f.fixExpr(e.Expression, depth+1)
+		e.Expression = expr
+
+		return prefix, e
+	case *yokast.PrefixExpr:
+		prefix, expr := f.fixExpr(e.Expression, depth+1)
+		e.Expression = expr
+
+		return prefix, e
+	case *yokast.Dict:
+		prefix := []yokast.Stmt{}
+		for i, entry := range e.Entries {
+			stmts, value := f.fixExpr(entry.Value, depth)
+			e.Entries[i].Value = value
+			prefix = append(prefix, stmts...)
+		}
+
+		return prefix, e
 	default:
 		return nil, e
 	}
 }
 
 func (f *fixer) nextTmpIdentifier() string {
-	f.internalIdentifier += 1
-	ident := fmt.Sprintf("_TMP%d", f.internalIdentifier)
-	return ident
+	return f.names.temp()
 }
 
+// simplifyToIdent hoists the expression into a temporary variable so it can be used in a parameter expansion.
+// Variables are already valid parameters so they are left as is, constants are inlined so they are hoisted
+//
 // TODO: we should consider looking for asignment expressiosn that already match the literal value so we don't get
 // duplicate identifiers that map to the same value
-func (f *fixer) simplifyToIdent(expr yokast.Expr, depth int) ([]yokast.Stmt, *yokast.Identifier) {
-	if ident, ok := expr.(*yokast.Identifier); ok {
-		return nil, ident
+func (f *fixer) simplifyToIdent(expr yokast.Expr, depth int) ([]yokast.Stmt, yokast.Expr) {
+	switch e := expr.(type) {
+	case *yokast.Identifier:
+		if !f.isConst(e) {
+			return nil, expr
+		}
+	case *yokast.EnvVar:
+		// environment variables may be unset, which stops the script in strict mode,
+		// so they're hoisted into a temporary that is always set
+		if !f.strict {
+			return nil, expr
+		}
 	}
 
 	prefix, fixedExpr := f.fixExpr(expr, depth)
@@ -122,38 +226,21 @@ func (f *fixer) simplifyToIdent(expr yokast.Expr, depth int) ([]yokast.Stmt, *yo
 	), ident
 }
 
-// TODO: we should consider looking for asignment expressiosn that already match the literal value so we don't get
-// duplicate identifiers that map to the same value
-func (f *fixer) simplifyToStringOrCommand(expr yokast.Expr, depth int) ([]yokast.Stmt, yokast.Expr) {
-	if lit, ok := expr.(*yokast.String); ok {
-		return nil, lit
-	}
-
-	if call, ok := expr.(*yokast.Call); ok {
-		return nil, &yokast.NestedCall{Depth: depth, Call: call}
-	}
-
-	prefix := []yokast.Stmt{}
-	ident, ok := expr.(*yokast.Identifier)
-	if !ok {
-		tok := token.Token{Type: token.Identifier}
-		tmpName := f.nextTmpIdentifier()
-		ident = yokast.NewInternalIdentifier(tmpName, tok)
-
-		fixedPrefix, fixedExpr := f.fixExpr(expr, depth)
-		prefix = append(
-			fixedPrefix,
-			&yokast.Assign{Identifier: ident, Value: fixedExpr},
-		)
-	}
+// isConst returns true if the identifier refers to a constant
+func (f *fixer) isConst(ident *yokast.Identifier) bool {
+	symbol, ok := f.symbols.Lookup(ident)
+	return ok && symbol.Const
+}
 
-	call := &yokast.NestedCall{
-		Depth: depth,
-		Call: &yokast.Call{
-			Identifier: yokast.NewInternalIdentifier("echo", token.Token{Type: token.Identifier}),
-			Arguments:  []yokast.Expr{yokast.NewInternalString("-n", token.Token{Type: token.StringLiteral}), ident},
-		},
+// simplifyToWord simplifies the expression so it can be used as a single word in a parameter expansion.
+// Literals, variables and calls can be used directly, anything else is hoisted into a temporary variable
+func (f *fixer) simplifyToWord(expr yokast.Expr, depth int) ([]yokast.Stmt, yokast.Expr) {
+	switch e := expr.(type) {
+	case *yokast.String, *yokast.Atom, *yokast.Pattern, *yokast.Identifier, *yokast.EnvVar:
+		return nil, e
+	case *yokast.Call:
+		return f.fixExpr(e, depth)
+	default:
+		return f.simplifyToIdent(e, depth)
 	}
-
-	return prefix, call
 }
@@ -0,0 +1,122 @@
+package compiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/token"
+)
+
+// foldConst evaluates the value of a const declaration at compile time. Constants can only use
+// literals, other constants and integer math, so the result is always a plain string
+//
+// Example:
+//
+//	const n = :10 * :2 -> 20
+func (c *Compiler) foldConst(ident *yokast.Identifier, expr yokast.Expr) (string, error) {
+	name := ident.Name(c.source)
+	switch e := expr.(type) {
+	case *yokast.String:
+		return e.Decoded(), nil
+	case *yokast.Atom:
+		return strings.TrimPrefix(e.Token.Value(c.source), ":"), nil
+	case *yokast.Pattern:
+		return e.Value(c.source), nil
+	case *yokast.Identifier:
+		if value, ok := c.constValue(e); ok {
+			return value, nil
+		}
+
+		msg := fmt.Sprintf("const '%s' can not use '%s' because it is not a constant", name, e.Name(c.source))
+		return "", errors.NewPos(e.Token.Pos, msg)
+	case *yokast.GroupExpr:
+		return c.foldConst(ident, e.Expression)
+	case *yokast.PrefixExpr:
+		if e.Token.Type != token.Minus {
+			break
+		}
+
+		value, err := c.foldInt(ident, e.Expression)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(-value), nil
+	case *yokast.InfixExpr:
+		return c.foldInfix(ident, e)
+	}
+
+	msg := fmt.Sprintf("const '%s' must be a literal, another constant or integer math", name)
+	return "", errors.NewPos(ident.Token.Pos, msg)
+}
+
+// foldInfix evaluates integer math in a const declaration
+func (c *Compiler) foldInfix(ident *yokast.Identifier, expr *yokast.InfixExpr) (string, error) {
+	switch expr.Operator.Type {
+	case token.Plus, token.Minus, token.Multiply, token.Divide, token.Mod:
+	default:
+		msg := fmt.Sprintf("const '%s' must be a literal, another constant or integer math", ident.Name(c.source))
+		return "", errors.NewPos(ident.Token.Pos, msg)
+	}
+
+	left, err := c.foldInt(ident, expr.Left)
+	if err != nil {
+		return "", err
+	}
+
+	right, err := c.foldInt(ident, expr.Right)
+	if err != nil {
+		return "", err
+	}
+
+	// sh uses the same truncating division and remainder as go
+	switch expr.Operator.Type {
+	case token.Plus:
+		return strconv.Itoa(left + right), nil
+	case token.Minus:
+		return strconv.Itoa(left - right), nil
+	case token.Multiply:
+		return strconv.Itoa(left * right), nil
+	}
+
+	if right == 0 {
+		msg := fmt.Sprintf("const '%s' divides by zero", ident.Name(c.source))
+		return "", errors.NewPos(expr.Operator.Pos, msg)
+	}
+
+	if expr.Operator.Type == token.Divide {
+		return strconv.Itoa(left / right), nil
+	}
+
+	return strconv.Itoa(left % right), nil
+}
+
+// foldInt evaluates a const expression that must be an integer
+func (c *Compiler) foldInt(ident *yokast.Identifier, expr yokast.Expr) (int, error) {
+	value, err := c.foldConst(ident, expr)
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		msg := fmt.Sprintf("const '%s' uses '%s' in integer math but it is not an integer", ident.Name(c.source), value)
+		return 0, errors.NewPos(ident.Token.Pos, msg)
+	}
+
+	return i, nil
+}
+
+// constValue returns the value of the constant that the identifier refers to
+func (c *Compiler) constValue(ident *yokast.Identifier) (string, bool) {
+	symbol, ok := c.symbols.Lookup(ident)
+	if !ok || !symbol.Const {
+		return "", false
+	}
+
+	value, ok := c.consts[symbol]
+	return value, ok
+}
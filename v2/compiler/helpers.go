@@ -0,0 +1,235 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/bjatkin/yok/ast/shast"
+	"github.com/bjatkin/yok/codegen/target"
+)
+
+// helper is an sh function that implements a yok builtin. Helpers are only added to
+// a script if the script uses the builtin, and each helper is only added once
+type helper struct {
+	// args is the number of arguments that the builtin takes
+	args int
+	// body is the raw sh code of the function body, one line per element
+	body []string
+	// locals are the variables set by the body, they're declared local on targets that support it
+	locals []string
+	// lowerings are target specific bodies that replace the portable body,
+	// the first lowering the target supports is used
+	lowerings []lowering
+}
+
+// lowering is a helper body that only works on targets with the feature
+type lowering struct {
+	feature target.Feature
+	body    []string
+}
+
+// bodyFor returns the body of the helper for the target
+func (h helper) bodyFor(t target.Target) []string {
+	for _, lowering := range h.lowerings {
+		if t.Supports(lowering.feature) {
+			return lowering.body
+		}
+	}
+
+	if len(h.locals) > 0 && t.Supports(target.Local) {
+		return append([]string{"local " + strings.Join(h.locals, " ")}, h.body...)
+	}
+
+	return h.body
+}
+
+// helperPrefix is added to the name of the builtin to get the name of the helper function.
+// yok identifiers can not start with an underscore so helpers can never collide with user code
+const helperPrefix = "_yok_"
+
+// helperOrder is the order that helpers are added to the top of a script
+var helperOrder = []string{
+	"replace",
+	"replace_all",
+	"split",
+	"upper",
+	"lower",
+	"trim",
+	"contains",
+	"starts_with",
+	"ends_with",
+	"matches",
+}
+
+// helpers are the builtins that are implemented with helper functions. Parameter expansions and case
+// statements are used where possible, tr is only used where POSIX sh has no built in alternative.
+// Targets with more powerful parameter expansions use the lowerings instead. Only helpers have lowerings,
+// the other builtins (e.g. len, remove_prefix and print) compile to POSIX expansions and printf that work on every target
+// Helpers are always called in a command substitution so variables they set do not leak into the script
+var helpers = map[string]helper{
+	// replace replaces the first instance of $2 in $1 with $3
+	"replace": {
+		args: 3,
+		body: []string{
+			`case $1 in`,
+			`    *"$2"*) printf '%s%s%s' "${1%%"$2"*}" "$3" "${1#*"$2"}" ;;`,
+			`    *) printf '%s' "$1" ;;`,
+			`esac`,
+		},
+	},
+	// replace_all replaces every instance of $2 in $1 with $3
+	"replace_all": {
+		args:   3,
+		locals: []string{"_yok_rest"},
+		body: []string{
+			`if [ -z "$2" ]; then`,
+			`    printf '%s' "$1"`,
+			`    return`,
+			`fi`,
+			`_yok_rest=$1`,
+			`while :; do`,
+			`    case $_yok_rest in`,
+			`        *"$2"*)`,
+			`            printf '%s%s' "${_yok_rest%%"$2"*}" "$3"`,
+			`            _yok_rest=${_yok_rest#*"$2"}`,
+			`            ;;`,
+			`        *) break ;;`,
+			`    esac`,
+			`done`,
+			`printf '%s' "$_yok_rest"`,
+		},
+		lowerings: []lowering{
+			{
+				feature: target.PatternSubstitution,
+				body: []string{
+					`if [ -z "$2" ]; then`,
+					`    printf '%s' "$1"`,
+					`    return`,
+					`fi`,
+					`printf '%s' "${1//"$2"/"$3"}"`,
+				},
+			},
+		},
+	},
+	// split splits $1 on every instance of $2 and prints each field on it's own line
+	"split": {
+		args:   2,
+		locals: []string{"_yok_rest"},
+		body: []string{
+			`if [ -z "$2" ]; then`,
+			`    printf '%s' "$1"`,
+			`    return`,
+			`fi`,
+			`_yok_rest=$1`,
+			`while :; do`,
+			`    case $_yok_rest in`,
+			`        *"$2"*)`,
+			`            printf '%s\n' "${_yok_rest%%"$2"*}"`,
+			`            _yok_rest=${_yok_rest#*"$2"}`,
+			`            ;;`,
+			`        *) break ;;`,
+			`    esac`,
+			`done`,
+			`printf '%s' "$_yok_rest"`,
+		},
+	},
+	// upper converts $1 to upper case
+	"upper": {
+		args: 1,
+		body: []string{
+			`printf '%s' "$1" | tr '[:lower:]' '[:upper:]'`,
+		},
+		lowerings: []lowering{
+			{feature: target.CaseModification, body: []string{`printf '%s' "${1^^}"`}},
+		},
+	},
+	// lower converts $1 to lower case
+	"lower": {
+		args: 1,
+		body: []string{
+			`printf '%s' "$1" | tr '[:upper:]' '[:lower:]'`,
+		},
+		lowerings: []lowering{
+			{feature: target.CaseModification, body: []string{`printf '%s' "${1,,}"`}},
+		},
+	},
+	// trim removes leading and trailing white space from $1
+	"trim": {
+		args:   1,
+		locals: []string{"_yok_str"},
+		body: []string{
+			`_yok_str=${1#"${1%%[![:space:]]*}"}`,
+			`printf '%s' "${_yok_str%"${_yok_str##*[![:space:]]}"}"`,
+		},
+	},
+	// contains prints true if $1 contains $2
+	"contains": {
+		args: 2,
+		body: []string{
+			`case $1 in`,
+			`    *"$2"*) printf true ;;`,
+			`    *) printf false ;;`,
+			`esac`,
+		},
+	},
+	// starts_with prints true if $1 starts with $2
+	"starts_with": {
+		args: 2,
+		body: []string{
+			`case $1 in`,
+			`    "$2"*) printf true ;;`,
+			`    *) printf false ;;`,
+			`esac`,
+		},
+	},
+	// ends_with prints true if $1 ends with $2
+	"ends_with": {
+		args: 2,
+		body: []string{
+			`case $1 in`,
+			`    *"$2") printf true ;;`,
+			`    *) printf false ;;`,
+			`esac`,
+		},
+	},
+	// matches prints true if $1 matches the glob pattern $2, $2 is left unquoted so it's used as a pattern
+	"matches": {
+		args: 2,
+		body: []string{
+			`case $1 in`,
+			`    $2) printf true ;;`,
+			`    *) printf false ;;`,
+			`esac`,
+		},
+		lowerings: []lowering{
+			{
+				feature: target.GlobSubst,
+				body: []string{
+					`case $1 in`,
+					`    ${~2}) printf true ;;`,
+					`    *) printf false ;;`,
+					`esac`,
+				},
+			},
+		},
+	},
+}
+
+// helperFunctions returns the definitions of all the helpers that were used by the script
+func helperFunctions(used map[string]bool, t target.Target) []shast.Stmt {
+	stmts := []shast.Stmt{}
+	for _, name := range helperOrder {
+		if !used[name] {
+			continue
+		}
+
+		stmts = append(stmts,
+			&shast.Function{
+				Name: helperPrefix + name,
+				Body: helpers[name].bodyFor(t),
+			},
+			&shast.NewLine{},
+		)
+	}
+
+	return stmts
+}
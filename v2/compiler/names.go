@@ -0,0 +1,157 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/sym"
+)
+
+// varPrefix is added to the sh names of yok variables. Environment variables and special sh variables
+// do not use the prefix, so yok variables can't clobber them by accident
+const varPrefix = "YOK_"
+
+// reservedNames are special sh variables and well known environment variables.
+// yok variables can not be exported with one of these names, use env.NAME to intentionally write one of these variables
+var reservedNames = map[string]bool{
+	// special sh variables
+	"IFS":       true,
+	"PATH":      true,
+	"HOME":      true,
+	"PWD":       true,
+	"OLDPWD":    true,
+	"PPID":      true,
+	"LINENO":    true,
+	"OPTARG":    true,
+	"OPTIND":    true,
+	"PS1":       true,
+	"PS2":       true,
+	"PS4":       true,
+	"ENV":       true,
+	"CDPATH":    true,
+	"MAIL":      true,
+	"MAILCHECK": true,
+	"MAILPATH":  true,
+	"HISTFILE":  true,
+	"HISTSIZE":  true,
+	"FCEDIT":    true,
+	"RANDOM":    true,
+	"SECONDS":   true,
+	"HOSTNAME":  true,
+	"UID":       true,
+	"EUID":      true,
+
+	// well known environment variables
+	"USER":     true,
+	"LOGNAME":  true,
+	"SHELL":    true,
+	"TERM":     true,
+	"TZ":       true,
+	"TMPDIR":   true,
+	"LANG":     true,
+	"LANGUAGE": true,
+	"LC_ALL":   true,
+	"LC_CTYPE": true,
+	"EDITOR":   true,
+	"PAGER":    true,
+	"DISPLAY":  true,
+}
+
+// namer picks the sh variable names used for yok variables. Every yok variable gets a unique
+// sh name made from varPrefix and its upper-cased yok name, so it does not collide with the environment,
+// other yok variables, or the internal variables created by the compiler.
+// Exported variables are the exception, they always use their upper-cased yok name without the prefix
+type namer struct {
+	// names maps each yok symbol to it's sh name
+	names map[*sym.Symbol]string
+	// used is the set of all the sh names that have been used so far
+	used map[string]bool
+	// tmpCount is the number of temporary variables that have been created
+	tmpCount int
+	errors   []error
+}
+
+// newNamer creates a namer and names all the symbols in the symbol table
+func newNamer(table *sym.Table) *namer {
+	n := &namer{
+		names: map[*sym.Symbol]string{},
+		used:  map[string]bool{},
+	}
+
+	// environment variables are used exactly as written so they must be reserved first
+	for _, name := range table.EnvVars() {
+		n.used[name] = true
+	}
+
+	// exported variables are visible to other programs so they can not be renamed
+	exported := map[string]*sym.Symbol{}
+	for _, symbol := range table.Symbols() {
+		if !symbol.Exported {
+			continue
+		}
+
+		name := strings.ToUpper(symbol.Name)
+		if reservedNames[name] {
+			msg := fmt.Sprintf("can not export '%s' because it would overwrite $%s, use env.%s to set it explicitly", symbol.Name, name, name)
+			n.errors = append(n.errors, errors.NewPos(symbol.Pos(), msg))
+		}
+
+		// exporting the same yok name again reuses the variable, but two different names can't share it
+		if other, ok := exported[name]; ok && other.Name != symbol.Name {
+			msg := fmt.Sprintf("can not export '%s' because '%s' is also exported as $%s", symbol.Name, other.Name, name)
+			n.errors = append(n.errors, errors.NewPos(symbol.Pos(), msg))
+		}
+		exported[name] = symbol
+
+		n.used[name] = true
+		n.names[symbol] = name
+	}
+
+	// redeclaring a variable in the same scope replaces the old variable so it can reuse the same name
+	redeclared := map[*sym.Scope]map[string]string{}
+	for _, symbol := range table.Symbols() {
+		if symbol.Exported {
+			continue
+		}
+
+		scopeNames, ok := redeclared[symbol.Scope]
+		if !ok {
+			scopeNames = map[string]string{}
+			redeclared[symbol.Scope] = scopeNames
+		}
+
+		name, ok := scopeNames[symbol.Name]
+		if !ok {
+			name = n.pick(varPrefix + strings.ToUpper(symbol.Name))
+			scopeNames[symbol.Name] = name
+		}
+
+		n.names[symbol] = name
+	}
+
+	return n
+}
+
+// pick returns the first unused name starting with base and marks it as used.
+// Names that collide get a numbered suffix (e.g. YOK_NAME_1)
+func (n *namer) pick(base string) string {
+	name := base
+	for i := 1; n.used[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+
+	n.used[name] = true
+	return name
+}
+
+// name returns the sh name for the symbol
+func (n *namer) name(symbol *sym.Symbol) string {
+	return n.names[symbol]
+}
+
+// temp returns a new unique name for a temporary variable
+func (n *namer) temp() string {
+	n.tmpCount++
+	return n.pick(fmt.Sprintf("_TMP%d", n.tmpCount))
+}
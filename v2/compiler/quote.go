@@ -0,0 +1,24 @@
+package compiler
+
+import "github.com/bjatkin/yok/ast/shast"
+
+// quoteExpansions is a shast.Visitor that double quotes every variable expansion, paramater
+// expansion and command substitution so the results are never word split or glob expanded.
+// Arithmetic expressions are skipped since quotes are not allowed inside $(( ... ))
+type quoteExpansions struct{}
+
+// Visit implements the shast.Visitor interface
+func (q *quoteExpansions) Visit(node shast.Node) shast.Visitor {
+	switch node := node.(type) {
+	case *shast.Identifier:
+		node.Quoted = true
+	case *shast.CommandSub:
+		node.Quoted = true
+	case *shast.ParamaterExpansion:
+		node.Quoted = true
+	case *shast.ArithmeticCommand:
+		return nil
+	}
+
+	return q
+}
@@ -0,0 +1,48 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/bjatkin/yok/ast/shast"
+	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/codegen/target"
+	"github.com/bjatkin/yok/token"
+)
+
+// strictDirective is a top level comment that turns on strict mode for a single script
+const strictDirective = "#yok:strict"
+
+// hasStrictDirective returns true if any of the top level statements is the strict directive
+func hasStrictDirective(stmts []yokast.Stmt, source []byte) bool {
+	for _, stmt := range stmts {
+		comment, ok := stmt.(*yokast.Comment)
+		if ok && strings.TrimSpace(comment.Token.Value(source)) == strictDirective {
+			return true
+		}
+	}
+
+	return false
+}
+
+// strictPreamble returns the statements that turn on strict mode at the start of the script.
+// Failed commands and unset variables always stop the script, failures inside a pipeline
+// also stop the script if the target supports pipefail
+//
+// Example:
+//
+//	set -eu
+//	set -o pipefail
+func strictPreamble(t target.Target) []shast.Stmt {
+	stmts := []shast.Stmt{
+		&shast.StmtExpr{Pos: token.NoPos, Expression: &shast.Exec{Command: "set", Arguments: []shast.Expr{&shast.String{Value: "-eu"}}}},
+	}
+
+	if t.Supports(target.Pipefail) {
+		stmts = append(stmts, &shast.StmtExpr{
+			Pos:        token.NoPos,
+			Expression: &shast.Exec{Command: "set", Arguments: []shast.Expr{&shast.String{Value: "-o"}, &shast.String{Value: "pipefail"}}},
+		})
+	}
+
+	return append(stmts, &shast.NewLine{})
+}
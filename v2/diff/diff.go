@@ -13,13 +13,22 @@ type Diff struct {
 
 // AgainstFile is a testing helper function that takes the got string and want file
 // and diffs the got string against the contents of the want file.
-// if got and want are the same an empty string is returned
+// if got and want are the same an empty string is returned, otherwise a unified diff is returned.
+//
+// If the tests are run with the -update flag, or the YOK_UPDATE_GOLDEN environment variable is set,
+// the want file is rewritten with the got string instead
 func AgainstFile(t *testing.T, got, wantFilePath string) string {
 	t.Helper()
 
+	markChecked(wantFilePath)
+	if shouldUpdate() {
+		updateGoldenFile(t, got, wantFilePath)
+		return ""
+	}
+
 	wantBytes, err := os.ReadFile(wantFilePath)
 	if err != nil {
-		t.Fatalf("Failed to read diff file %s", wantFilePath)
+		t.Fatalf("Failed to read diff file %s, run the tests with -update to create it", wantFilePath)
 	}
 
 	want := string(wantBytes)
@@ -28,19 +37,16 @@ func AgainstFile(t *testing.T, got, wantFilePath string) string {
 		return ""
 	}
 
-	diff := NewDiff(got, want)
-	return PrettyPrint(diff)
+	return Unified(wantFilePath, "got", want, got, contextLines)
 }
 
 // UpdateFile updates the want file to match the got file contents.
+//
+// Deprecated: run the tests with the -update flag instead
 func UpdateFile(t *testing.T, got, wantFilePath string) {
 	t.Helper()
 
-	err := os.WriteFile(wantFilePath, []byte(got), 0o0655)
-	if err != nil {
-		t.Fatalf("failed to write diff file %s", wantFilePath)
-	}
-
+	updateGoldenFile(t, got, wantFilePath)
 	t.Errorf("File Successfully updated, please remove UpdateFile()")
 }
 
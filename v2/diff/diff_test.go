@@ -137,3 +137,106 @@ func TestLines2(t *testing.T) {
 		})
 	}
 }
+
+func TestUnified(t *testing.T) {
+	type args struct {
+		from string
+		to   string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "no changes",
+			args: args{
+				from: "a\nb\nc\n",
+				to:   "a\nb\nc\n",
+			},
+			want: "",
+		},
+		{
+			name: "line modified",
+			args: args{
+				from: "a\nb\nc\n",
+				to:   "a\nB\nc\n",
+			},
+			want: "--- want\n+++ got\n" +
+				"@@ -1,3 +1,3 @@\n" +
+				" a\n" +
+				"-b\n" +
+				"+B\n" +
+				" c\n",
+		},
+		{
+			name: "context lines",
+			args: args{
+				from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+				to:   "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			},
+			want: "--- want\n+++ got\n" +
+				"@@ -2,7 +2,7 @@\n" +
+				" 2\n" +
+				" 3\n" +
+				" 4\n" +
+				"-5\n" +
+				"+five\n" +
+				" 6\n" +
+				" 7\n" +
+				" 8\n",
+		},
+		{
+			name: "separate hunks",
+			args: args{
+				from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+				to:   "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			},
+			want: "--- want\n+++ got\n" +
+				"@@ -1,4 +1,4 @@\n" +
+				"-1\n" +
+				"+one\n" +
+				" 2\n" +
+				" 3\n" +
+				" 4\n" +
+				"@@ -7,4 +7,4 @@\n" +
+				" 7\n" +
+				" 8\n" +
+				" 9\n" +
+				"-10\n" +
+				"+ten\n",
+		},
+		{
+			name: "lines added to empty",
+			args: args{
+				from: "",
+				to:   "a\nb\n",
+			},
+			want: "--- want\n+++ got\n" +
+				"@@ -0,0 +1,2 @@\n" +
+				"+a\n" +
+				"+b\n",
+		},
+		{
+			name: "missing final new line",
+			args: args{
+				from: "a\nb\n",
+				to:   "a\nb",
+			},
+			want: "--- want\n+++ got\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				" a\n" +
+				"-b\n" +
+				"+b\n" +
+				"\\ No newline at end of file\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("want", "got", tt.args.from, tt.args.to, 3)
+			if got != tt.want {
+				t.Errorf("Unified() = \n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package diff
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// updateEnv is the environment variable that can be used instead of the -update flag.
+// This is useful with `go test ./...` since packages that do not import diff will reject the -update flag
+const updateEnv = "YOK_UPDATE_GOLDEN"
+
+// update is set by the -update test flag
+var update = flag.Bool("update", false, "rewrite golden files with the current test output")
+
+// contextLines is the number of unchanged lines shown around each change in golden file diffs
+const contextLines = 3
+
+var (
+	// checkedMu protects checked
+	checkedMu sync.Mutex
+	// checked is the set of golden files that have been checked by AgainstFile
+	checked = map[string]bool{}
+)
+
+// shouldUpdate returns true if golden files should be rewritten rather than checked
+func shouldUpdate() bool {
+	return *update || os.Getenv(updateEnv) != ""
+}
+
+// markChecked records that the golden file has been checked
+func markChecked(path string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = filepath.Clean(path)
+	}
+
+	checkedMu.Lock()
+	defer checkedMu.Unlock()
+	checked[abs] = true
+}
+
+// updateGoldenFile writes the got value into the golden file, creating any missing directories
+func updateGoldenFile(t *testing.T, got, wantFilePath string) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Dir(wantFilePath), 0o0755)
+	if err != nil {
+		t.Fatalf("failed to create directory for golden file %s: %v", wantFilePath, err)
+	}
+
+	err = os.WriteFile(wantFilePath, []byte(got), 0o0644)
+	if err != nil {
+		t.Fatalf("failed to write golden file %s: %v", wantFilePath, err)
+	}
+
+	t.Logf("updated golden file %s", wantFilePath)
+}
+
+// Orphaned returns all the files in dir that match one of the given glob patterns
+// but have not been checked by AgainstFile
+func Orphaned(dir string, patterns ...string) ([]string, error) {
+	orphans := []string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		matched := false
+		for _, pattern := range patterns {
+			ok, err := filepath.Match(pattern, d.Name())
+			if err != nil {
+				return err
+			}
+			matched = matched || ok
+		}
+		if !matched {
+			return nil
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+
+		checkedMu.Lock()
+		defer checkedMu.Unlock()
+		if !checked[abs] {
+			orphans = append(orphans, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(orphans)
+	return orphans, nil
+}
+
+// RunWithOrphanCheck runs the tests and then reports any golden files in dir, matching one of the
+// glob patterns, that were never checked by AgainstFile. Orphans are only reported when all the tests
+// were run and passed, filtering tests with -run or -skip would otherwise report false positives.
+// It should be called from TestMain and returns the exit code for the test binary.
+//
+// Example:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(diff.RunWithOrphanCheck(m, "testdata", "*.txt"))
+//	}
+func RunWithOrphanCheck(m *testing.M, dir string, patterns ...string) int {
+	code := m.Run()
+	if code != 0 || isFiltered() {
+		return code
+	}
+
+	orphans, err := Orphaned(dir, patterns...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to check for orphaned golden files: %v\n", err)
+		return 1
+	}
+
+	if len(orphans) == 0 {
+		return code
+	}
+
+	fmt.Fprintln(os.Stderr, "found golden files that are not used by any test:")
+	for _, orphan := range orphans {
+		fmt.Fprintf(os.Stderr, "\t%s\n", orphan)
+	}
+
+	return 1
+}
+
+// isFiltered returns true if the tests were filtered using the -run or -skip flags
+func isFiltered() bool {
+	for _, name := range []string{"test.run", "test.skip"} {
+		f := flag.Lookup(name)
+		if f != nil && f.Value.String() != "" {
+			return true
+		}
+	}
+
+	return false
+}
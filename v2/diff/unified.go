@@ -0,0 +1,221 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// editKind is the kind of change made to a line
+type editKind int
+
+const (
+	editKeep = editKind(iota)
+	editInsert
+	editDelete
+)
+
+// edit is a single line edit needed to turn one text into another.
+// from and to are the indexes of the line in the from and to texts.
+// For inserts from is the position in the from text where the line is inserted
+// and for deletes to is the position in the to text where the line was removed
+type edit struct {
+	kind editKind
+	from int
+	to   int
+}
+
+// splitLines splits the text into lines, each line keeps it's trailing new line
+// so a final line without a new line never matches a line with one
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.SplitAfter(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// fixLastLine adds back the new line that was trimmed from the end of the text by splitLines
+func fixLastLine(lines []string, text string) []string {
+	if len(lines) > 0 && strings.HasSuffix(text, "\n") {
+		lines[len(lines)-1] += "\n"
+	}
+
+	return lines
+}
+
+// diffLines finds the shortest set of edits that turns from into to using the Myers diff algorithm
+// http://www.xmailserver.org/diff2.pdf
+func diffLines(from, to []string) []edit {
+	n, m := len(from), len(to)
+	maxD := n + m
+	offset := maxD + 1
+	v := make([]int, 2*maxD+2)
+
+	trace := [][]int{}
+	found := false
+	for d := 0; d <= maxD && !found; d++ {
+		trace = append(trace, append([]int{}, v...))
+		for k := -d; k <= d; k += 2 {
+			x := 0
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && from[x] == to[y] {
+				x++
+				y++
+			}
+
+			v[k+offset] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// walk backwards through the trace to recover the edits
+	edits := []edit{}
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+			prevK = k + 1
+		}
+
+		prevX := v[prevK+offset]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{kind: editKeep, from: x - 1, to: y - 1})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{kind: editInsert, from: x, to: y - 1})
+			} else {
+				edits = append(edits, edit{kind: editDelete, from: x - 1, to: y})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	// the edits were collected backwards so they need to be reversed
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
+
+// hunk is a range of edits that will be rendered together
+type hunk struct {
+	start int
+	end   int
+}
+
+// findHunks groups the changed edits into hunks with the given number of context lines.
+// Hunks that overlap or touch are merged into a single hunk
+func findHunks(edits []edit, context int) []hunk {
+	hunks := []hunk{}
+	for i, e := range edits {
+		if e.kind == editKeep {
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := min(i+context+1, len(edits))
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+			continue
+		}
+
+		hunks = append(hunks, hunk{start: start, end: end})
+	}
+
+	return hunks
+}
+
+// Unified returns a unified diff that turns from into to, showing context unchanged
+// lines around each change. The fromName and toName are used in the diff header.
+// If there are no differences an empty string is returned
+//
+// Example:
+//
+//	--- want.txt
+//	+++ got
+//	@@ -1,3 +1,3 @@
+//	 a
+//	-b
+//	+c
+//	 d
+func Unified(fromName, toName, from, to string, context int) string {
+	fromLines := fixLastLine(splitLines(from), from)
+	toLines := fixLastLine(splitLines(to), to)
+
+	edits := diffLines(fromLines, toLines)
+	hunks := findHunks(edits, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	out := strings.Builder{}
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks {
+		fromStart, fromCount := 0, 0
+		toStart, toCount := 0, 0
+		body := strings.Builder{}
+		for i, e := range edits[h.start:h.end] {
+			if i == 0 {
+				fromStart, toStart = e.from, e.to
+			}
+
+			switch e.kind {
+			case editKeep:
+				writeLine(&body, " ", fromLines[e.from])
+				fromCount++
+				toCount++
+			case editDelete:
+				writeLine(&body, "-", fromLines[e.from])
+				fromCount++
+			case editInsert:
+				writeLine(&body, "+", toLines[e.to])
+				toCount++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(fromStart, fromCount), hunkRange(toStart, toCount))
+		out.WriteString(body.String())
+	}
+
+	return out.String()
+}
+
+// hunkRange formats the start and count of a hunk header range.
+// start is a 0 based line index which is converted into a 1 based line number
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// empty ranges point at the line before the change
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// writeLine writes a single diff line. Lines that do not end with a new line are
+// marked the same way as GNU diff
+func writeLine(out *strings.Builder, prefix, line string) {
+	out.WriteString(prefix)
+	out.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		out.WriteString("\n\\ No newline at end of file\n")
+	}
+}
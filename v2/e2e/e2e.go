@@ -0,0 +1,117 @@
+// Package e2e runs generated sh scripts with the shells that are installed on the local machine.
+// It is used to check that compiled yok code behaves the same way with every shell.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/bjatkin/yok/codegen/target"
+)
+
+// Shell is a locally installed shell that scripts can be run with
+type Shell struct {
+	// Name is the name of the shell, e.g. dash or busybox
+	Name string
+	// Path is the path to the shell executable
+	Path string
+	// Args are passed to the shell before the script, e.g. busybox needs 'sh' to run the shell applet
+	Args []string
+	// Target is the target that scripts are compiled for before they're run with the shell
+	Target target.Target
+}
+
+// shells are all the shells scripts are run with if they are installed. zsh does not run POSIX sh
+// scripts unless it's emulating sh, so it runs scripts compiled for the zsh target instead
+var shells = []struct {
+	name   string
+	args   []string
+	target target.Target
+}{
+	{name: "sh"},
+	{name: "dash"},
+	{name: "bash"},
+	{name: "busybox", args: []string{"sh"}},
+	{name: "zsh", target: target.Zsh},
+}
+
+// Available returns the shells that are installed on the local machine, in a stable order
+func Available() []Shell {
+	available := []Shell{}
+	for _, shell := range shells {
+		path, err := exec.LookPath(shell.name)
+		if err != nil {
+			continue
+		}
+
+		available = append(available, Shell{
+			Name:   shell.name,
+			Path:   path,
+			Args:   shell.args,
+			Target: shell.target,
+		})
+	}
+
+	return available
+}
+
+// Result is the observable outcome of running a script
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// String formats the result so it can be stored in a golden file
+func (r Result) String() string {
+	return fmt.Sprintf("exit status: %d\n-- stdout --\n%s-- stderr --\n%s", r.ExitCode, r.Stdout, r.Stderr)
+}
+
+// Env returns the fixed environment that scripts are run with
+func Env() map[string]string {
+	return map[string]string{
+		"PATH":   os.Getenv("PATH"),
+		"HOME":   "/home/yok",
+		"LC_ALL": "C",
+	}
+}
+
+// Run writes the script into dir as script.sh and runs it with the shell.
+// The script runs in dir with a fixed environment so the result is the same on every machine.
+// A non-zero exit status is part of the result, an error is only returned if the script could not be run
+func Run(ctx context.Context, shell Shell, dir, script string) (Result, error) {
+	scriptFile := filepath.Join(dir, "script.sh")
+	err := os.WriteFile(scriptFile, []byte(script), 0o0644)
+	if err != nil {
+		return Result{}, err
+	}
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	args := append(append([]string{}, shell.Args...), "script.sh")
+	cmd := exec.CommandContext(ctx, shell.Path, args...)
+	cmd.Dir = dir
+	for name, value := range Env() {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return Result{}, ctx.Err()
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return Result{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: exitErr.ExitCode()}, nil
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{Stdout: stdout.String(), Stderr: stderr.String()}, nil
+}
@@ -0,0 +1,219 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjatkin/yok/ast/shast"
+	"github.com/bjatkin/yok/codegen/gensh"
+	"github.com/bjatkin/yok/codegen/target"
+	"github.com/bjatkin/yok/compiler"
+	"github.com/bjatkin/yok/diff"
+	"github.com/bjatkin/yok/interp"
+	"github.com/bjatkin/yok/parser"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(diff.RunWithOrphanCheck(m, "testdata", "*.txt"))
+}
+
+// hostDependent are the examples that print different output on different machines
+var hostDependent = map[string]string{
+	"env.yok":                "runs make",
+	"nested_expressions.yok": "lists the go binary",
+}
+
+// knownDivergent are the examples that are expected to give different results with different shells.
+// Divergence from the golden file is logged for these examples rather than failing the test
+var knownDivergent = map[string]string{
+	"atoms.yok": "bare atoms are run as commands and each shell reports the missing command differently",
+}
+
+// scriptTimeout is how long a single script is allowed to run
+const scriptTimeout = 10 * time.Second
+
+// exampleDirs are the directories of yok examples that are run by the tests
+var exampleDirs = []struct {
+	name string
+	dir  string
+	// spec examples describe syntax that yok may not support yet, so failing to compile only skips them
+	allowErrors bool
+}{
+	{
+		name: "testdata",
+		dir:  filepath.Join("..", "testdata"),
+	},
+	{
+		name:        "spec",
+		dir:         filepath.Join("..", "spec"),
+		allowErrors: true,
+	},
+}
+
+// compileFunc compiles the example for the target
+type compileFunc func(t *testing.T, shell target.Target) *shast.Script
+
+// forEachExample calls check for every example that does not depend on the host in a sub test, with a function
+// that compiles the example and the golden file for the example. Examples that can't be compiled for POSIX are skipped
+func forEachExample(t *testing.T, check func(t *testing.T, name string, compile compileFunc, goldenFile string)) {
+	t.Helper()
+
+	for _, examples := range exampleDirs {
+		t.Run(examples.name, func(t *testing.T) {
+			yokFiles, err := filepath.Glob(filepath.Join(examples.dir, "*.yok"))
+			if err != nil {
+				t.Fatal("failed to find examples", err)
+			}
+
+			for _, yokFile := range yokFiles {
+				name := filepath.Base(yokFile)
+				t.Run(name, func(t *testing.T) {
+					if reason, ok := hostDependent[name]; ok {
+						t.Skip(name, reason)
+					}
+
+					shAst := compile(t, yokFile, target.POSIX, examples.allowErrors)
+					compileFor := func(t *testing.T, shell target.Target) *shast.Script {
+						if shell == target.POSIX {
+							return shAst
+						}
+						return compile(t, yokFile, shell, examples.allowErrors)
+					}
+
+					goldenFile := filepath.Join("testdata", examples.name, strings.TrimSuffix(name, ".yok")+".txt")
+					check(t, name, compileFor, goldenFile)
+				})
+			}
+		})
+	}
+}
+
+func TestExamples(t *testing.T) {
+	shells := Available()
+	if len(shells) == 0 {
+		t.Skip("no shells are available")
+	}
+
+	forEachExample(t, func(t *testing.T, name string, compile compileFunc, goldenFile string) {
+		_, divergent := knownDivergent[name]
+		checkShells(t, shells, compile, goldenFile, divergent)
+	})
+}
+
+// TestExamples_Interp checks that the interpreter gives the same results as the shells
+func TestExamples_Interp(t *testing.T) {
+	forEachExample(t, func(t *testing.T, name string, compile compileFunc, goldenFile string) {
+		if reason, ok := knownDivergent[name]; ok {
+			t.Skip(name, reason)
+		}
+
+		shAst := compile(t, target.POSIX)
+
+		want, err := os.ReadFile(goldenFile)
+		if err != nil {
+			t.Fatalf("failed to read golden file %s: %v", goldenFile, err)
+		}
+
+		stdout := bytes.Buffer{}
+		stderr := bytes.Buffer{}
+		i := interp.New(Env())
+		i.Stdout = &stdout
+		i.Stderr = &stderr
+		status, err := i.Run(shAst)
+		if err != nil {
+			t.Fatalf("failed to interpret script: %v\n%s", err, gensh.Generate(shAst))
+		}
+
+		got := Result{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: status}.String()
+		if got != string(want) {
+			diffs := diff.Unified(goldenFile, "interp", string(want), got, 3)
+			t.Errorf("interpreter result diverges from %s:\n%s", goldenFile, diffs)
+		}
+	})
+}
+
+// compile compiles the yok file for the target shell.
+// If allowErrors is true files that do not compile are skipped rather than failing the test
+func compile(t *testing.T, yokFile string, shell target.Target, allowErrors bool) *shast.Script {
+	t.Helper()
+
+	source, err := os.ReadFile(yokFile)
+	if err != nil {
+		t.Fatal("failed to read source file", err)
+	}
+
+	errs := []error{}
+	p := parser.New(source)
+	script, err := p.Parse()
+	if err != nil {
+		errs = p.Errors
+	}
+
+	var shAst *shast.Script
+	if len(errs) == 0 {
+		c := compiler.NewWithOptions(source, compiler.Options{Target: shell})
+		shAst, err = c.Compile(script)
+		if err != nil {
+			errs = c.Errors()
+		}
+	}
+
+	if len(errs) > 0 && allowErrors {
+		t.Skip(filepath.Base(yokFile), "does not compile", errs)
+	}
+	if len(errs) > 0 {
+		t.Fatalf("failed to compile %s: %v", yokFile, errs)
+	}
+
+	return shAst
+}
+
+// checkShells compiles the example for the target of each shell, runs it with the shell and compares the
+// results against the golden file. The first shell is checked with diff.AgainstFile so -update rewrites
+// the golden file with its result, every other shell must then match the golden file. Each shell that diverges is reported separately,
+// if divergent is true the divergence is only logged
+func checkShells(t *testing.T, shells []Shell, compile compileFunc, goldenFile string, divergent bool) {
+	t.Helper()
+
+	for i, shell := range shells {
+		t.Run(shell.Name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), scriptTimeout)
+			defer cancel()
+
+			script := gensh.New(shell.Target).Generate(compile(t, shell.Target))
+			result, err := Run(ctx, shell, t.TempDir(), script)
+			if err != nil {
+				t.Fatalf("failed to run script with %s: %v\n%s", shell.Name, err, script)
+			}
+
+			got := result.String()
+			if i == 0 {
+				if diffs := diff.AgainstFile(t, got, goldenFile); diffs != "" {
+					t.Errorf("%s result does not match %s:\n%s", shell.Name, goldenFile, diffs)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatalf("failed to read golden file %s: %v", goldenFile, err)
+			}
+
+			if got == string(want) {
+				return
+			}
+
+			diffs := diff.Unified(goldenFile, shell.Name, string(want), got, 3)
+			if divergent {
+				t.Logf("%s result diverges from %s as expected:\n%s", shell.Name, goldenFile, diffs)
+				return
+			}
+			t.Errorf("%s result diverges from %s:\n%s", shell.Name, goldenFile, diffs)
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package errors
 
+import "github.com/bjatkin/yok/token"
+
 // TODO: we need to build better error types here
 // good error handling is going to be absolutely essential for this to be a good tool
 
@@ -20,3 +22,30 @@ func (e Err) Error() string {
 func New(msg string) Err {
 	return Err{msg}
 }
+
+// PosErr is an error that is caused by the yok code at a specific position in the source
+type PosErr struct {
+	Pos token.Pos
+	msg string
+}
+
+func (e PosErr) Error() string {
+	return e.msg
+}
+
+// NewPos creates a new error at the given position in the yok source
+func NewPos(pos token.Pos, msg string) PosErr {
+	return PosErr{Pos: pos, msg: msg}
+}
+
+// Format formats err for display to a user. If the err is a PosErr the full
+// position of the error is added in the standard file:line:col format, otherwise only the file is added
+func Format(err error, fileName string, source []byte) string {
+	posErr, ok := err.(PosErr)
+	if !ok {
+		return fileName + ": " + err.Error()
+	}
+
+	pos := token.GetFullPosition(fileName, source, posErr.Pos)
+	return pos.String() + ": " + posErr.msg
+}
@@ -0,0 +1,136 @@
+package interp
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Call is a single call to a command
+type Call struct {
+	// Args are the arguments of the call, the name of the command is not included
+	Args   []string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Env contains the exported variables of the script and the environment variables set for the call
+	Env map[string]string
+	// FS is the file system of the interpreter
+	FS fs.FS
+}
+
+// Command is a command that can be run by the interpreter, it returns the exit status of the command
+type Command func(call *Call) int
+
+// DefaultCommands returns the commands that are available to every interpreter
+func DefaultCommands() map[string]Command {
+	return maps.Clone(defaultCommands)
+}
+
+// defaultCommands are the sh builtins used by generated code, along with some common commands
+var defaultCommands = map[string]Command{
+	":":     func(call *Call) int { return 0 },
+	"true":  func(call *Call) int { return 0 },
+	"false": func(call *Call) int { return 1 },
+	"printf": func(call *Call) int {
+		if len(call.Args) == 0 {
+			fmt.Fprintln(call.Stderr, "printf: usage: printf format [arguments]")
+			return 2
+		}
+
+		out, errs := printf(call.Args[0], call.Args[1:])
+		io.WriteString(call.Stdout, out)
+		for _, err := range errs {
+			fmt.Fprintln(call.Stderr, err)
+		}
+		if len(errs) > 0 {
+			return 1
+		}
+
+		return 0
+	},
+	"echo": func(call *Call) int {
+		fmt.Fprintln(call.Stdout, strings.Join(call.Args, " "))
+		return 0
+	},
+	"cat": func(call *Call) int {
+		if len(call.Args) == 0 {
+			io.Copy(call.Stdout, call.Stdin)
+			return 0
+		}
+
+		status := 0
+		for _, name := range call.Args {
+			data, err := fs.ReadFile(call.FS, strings.TrimPrefix(name, "./"))
+			if err != nil {
+				fmt.Fprintf(call.Stderr, "cat: %s: No such file or directory\n", name)
+				status = 1
+				continue
+			}
+			call.Stdout.Write(data)
+		}
+
+		return status
+	},
+}
+
+// DefaultFunctions returns the native implementations of the helper functions that the compiler adds to scripts
+func DefaultFunctions() map[string]Command {
+	return maps.Clone(defaultFunctions)
+}
+
+// defaultFunctions are the Go equivalents of the compiler's helper functions.
+// They must print exactly what the helper function would print
+var defaultFunctions = map[string]Command{
+	"_yok_replace": helperFunc(3, func(args []string) string {
+		return strings.Replace(args[0], args[1], args[2], 1)
+	}),
+	"_yok_replace_all": helperFunc(3, func(args []string) string {
+		if args[1] == "" {
+			return args[0]
+		}
+		return strings.ReplaceAll(args[0], args[1], args[2])
+	}),
+	"_yok_split": helperFunc(2, func(args []string) string {
+		if args[1] == "" {
+			return args[0]
+		}
+		return strings.ReplaceAll(args[0], args[1], "\n")
+	}),
+	"_yok_upper": helperFunc(1, func(args []string) string {
+		return strings.ToUpper(args[0])
+	}),
+	"_yok_lower": helperFunc(1, func(args []string) string {
+		return strings.ToLower(args[0])
+	}),
+	"_yok_trim": helperFunc(1, func(args []string) string {
+		return strings.Trim(args[0], " \t\n\v\f\r")
+	}),
+	"_yok_contains": helperFunc(2, func(args []string) string {
+		return strconv.FormatBool(strings.Contains(args[0], args[1]))
+	}),
+	"_yok_starts_with": helperFunc(2, func(args []string) string {
+		return strconv.FormatBool(strings.HasPrefix(args[0], args[1]))
+	}),
+	"_yok_ends_with": helperFunc(2, func(args []string) string {
+		return strconv.FormatBool(strings.HasSuffix(args[0], args[1]))
+	}),
+	"_yok_matches": helperFunc(2, func(args []string) string {
+		// the pattern is left unquoted in the helper so it's matched as a glob
+		return strconv.FormatBool(compileGlob(args[1]).matches(args[0]))
+	}),
+}
+
+// helperFunc creates a command from a helper function. Missing arguments are empty strings, just like in sh
+func helperFunc(args int, fn func(args []string) string) Command {
+	return func(call *Call) int {
+		padded := make([]string, max(args, len(call.Args)))
+		copy(padded, call.Args)
+
+		io.WriteString(call.Stdout, fn(padded))
+		return 0
+	}
+}
@@ -0,0 +1,226 @@
+package interp
+
+import (
+	"strings"
+	"unicode"
+)
+
+// globKind is the kind of a single element of a glob pattern
+type globKind int
+
+const (
+	// globLiteral matches a single character exactly
+	globLiteral = globKind(iota)
+	// globAny matches any single character (e.g. ?)
+	globAny
+	// globStar matches any string, including the empty string (e.g. *)
+	globStar
+	// globClass matches a single character from a bracket expression (e.g. [!a-z])
+	globClass
+)
+
+// globItem is a single element of a glob pattern
+type globItem struct {
+	kind    globKind
+	char    rune
+	negated bool
+	// match is used by globClass items to check if a character is part of the bracket expression
+	match func(rune) bool
+}
+
+// glob is a compiled sh glob pattern
+type glob []globItem
+
+// compileGlob compiles an sh glob pattern. Like in sh a '[' that is never closed is matched literally
+func compileGlob(pattern string) glob {
+	g := glob{}
+	chars := []rune(pattern)
+	for i := 0; i < len(chars); i++ {
+		switch chars[i] {
+		case '*':
+			g = append(g, globItem{kind: globStar})
+		case '?':
+			g = append(g, globItem{kind: globAny})
+		case '[':
+			item, end := compileClass(chars, i)
+			if end < 0 {
+				g = append(g, globItem{kind: globLiteral, char: '['})
+				continue
+			}
+
+			g = append(g, item)
+			i = end
+		default:
+			g = append(g, globItem{kind: globLiteral, char: chars[i]})
+		}
+	}
+
+	return g
+}
+
+// literalGlob creates a glob that matches the value exactly
+func literalGlob(value string) glob {
+	g := glob{}
+	for _, char := range value {
+		g = append(g, globItem{kind: globLiteral, char: char})
+	}
+
+	return g
+}
+
+// charClasses are the character classes that can be used in bracket expressions (e.g. [[:space:]])
+var charClasses = map[string]func(rune) bool{
+	"alnum":  func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) },
+	"alpha":  unicode.IsLetter,
+	"blank":  func(r rune) bool { return r == ' ' || r == '\t' },
+	"cntrl":  unicode.IsControl,
+	"digit":  func(r rune) bool { return r >= '0' && r <= '9' },
+	"graph":  func(r rune) bool { return unicode.IsGraphic(r) && !unicode.IsSpace(r) },
+	"lower":  unicode.IsLower,
+	"print":  unicode.IsPrint,
+	"punct":  unicode.IsPunct,
+	"space":  func(r rune) bool { return strings.ContainsRune(" \t\n\v\f\r", r) },
+	"upper":  unicode.IsUpper,
+	"xdigit": func(r rune) bool { return strings.ContainsRune("0123456789abcdefABCDEF", r) },
+}
+
+// compileClass compiles the bracket expression that starts at start. It returns the index of the closing ']'
+// or -1 if the bracket expression is never closed
+func compileClass(chars []rune, start int) (globItem, int) {
+	item := globItem{kind: globClass}
+	i := start + 1
+	if i < len(chars) && (chars[i] == '!' || chars[i] == '^') {
+		item.negated = true
+		i++
+	}
+
+	matchers := []func(rune) bool{}
+	for first := true; i < len(chars); first = false {
+		// a ']' at the start of the bracket expression is matched literally
+		if chars[i] == ']' && !first {
+			item.match = func(r rune) bool {
+				for _, match := range matchers {
+					if match(r) {
+						return true
+					}
+				}
+				return false
+			}
+			return item, i
+		}
+
+		if chars[i] == '[' && i+1 < len(chars) && chars[i+1] == ':' {
+			end := classEnd(chars, i+2)
+			if end < 0 {
+				return globItem{}, -1
+			}
+
+			if class, ok := charClasses[string(chars[i+2:end])]; ok {
+				matchers = append(matchers, class)
+			}
+			// skip past the closing ':]'
+			i = end + 2
+			continue
+		}
+
+		if i+2 < len(chars) && chars[i+1] == '-' && chars[i+2] != ']' {
+			low, high := chars[i], chars[i+2]
+			matchers = append(matchers, func(r rune) bool { return r >= low && r <= high })
+			i += 3
+			continue
+		}
+
+		char := chars[i]
+		matchers = append(matchers, func(r rune) bool { return r == char })
+		i++
+	}
+
+	return globItem{}, -1
+}
+
+// classEnd returns the index of the ':]' that closes a character class name starting at start,
+// or -1 if the character class is never closed
+func classEnd(chars []rune, start int) int {
+	for i := start; i+1 < len(chars); i++ {
+		if chars[i] == ':' && chars[i+1] == ']' {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// matches returns true if the glob matches the whole value
+func (g glob) matches(value string) bool {
+	return matchRunes(g, []rune(value))
+}
+
+// matchRunes matches the glob against the characters, backtracking over each '*'
+func matchRunes(g glob, chars []rune) bool {
+	for len(g) > 0 {
+		item := g[0]
+		if item.kind == globStar {
+			for i := 0; i <= len(chars); i++ {
+				if matchRunes(g[1:], chars[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(chars) == 0 || !item.matchChar(chars[0]) {
+			return false
+		}
+
+		g = g[1:]
+		chars = chars[1:]
+	}
+
+	return len(chars) == 0
+}
+
+// matchChar returns true if the item matches the single character
+func (item globItem) matchChar(char rune) bool {
+	switch item.kind {
+	case globLiteral:
+		return item.char == char
+	case globAny:
+		return true
+	case globClass:
+		return item.match(char) != item.negated
+	default:
+		return false
+	}
+}
+
+// removePrefix removes the shortest or longest prefix of the value that matches the glob
+func (g glob) removePrefix(value string, shortest bool) string {
+	chars := []rune(value)
+	for n := range len(chars) + 1 {
+		if !shortest {
+			n = len(chars) - n
+		}
+
+		if matchRunes(g, chars[:n]) {
+			return string(chars[n:])
+		}
+	}
+
+	return value
+}
+
+// removeSuffix removes the shortest or longest suffix of the value that matches the glob
+func (g glob) removeSuffix(value string, shortest bool) string {
+	chars := []rune(value)
+	for n := range len(chars) + 1 {
+		if !shortest {
+			n = len(chars) - n
+		}
+
+		if matchRunes(g, chars[len(chars)-n:]) {
+			return string(chars[:len(chars)-n])
+		}
+	}
+
+	return value
+}
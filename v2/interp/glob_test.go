@@ -0,0 +1,62 @@
+package interp
+
+import "testing"
+
+func Test_glob_matches(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		value   string
+		want    bool
+	}{
+		{name: "literal", pattern: "abc", value: "abc", want: true},
+		{name: "literal mismatch", pattern: "abc", value: "abd", want: false},
+		{name: "star", pattern: "a*c", value: "a/b/c", want: true},
+		{name: "empty star", pattern: "a*", value: "a", want: true},
+		{name: "question mark", pattern: "a?c", value: "abc", want: true},
+		{name: "question mark needs a character", pattern: "a?", value: "a", want: false},
+		{name: "range", pattern: "[a-c]x", value: "bx", want: true},
+		{name: "negated range", pattern: "[!a-c]x", value: "bx", want: false},
+		{name: "character class", pattern: "[[:space:]]*", value: " a", want: true},
+		{name: "leading close bracket", pattern: "[]a]", value: "]", want: true},
+		{name: "unclosed bracket", pattern: "[a", value: "[a", want: true},
+		{name: "unicode", pattern: "?b", value: "😀b", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compileGlob(tt.pattern).matches(tt.value); got != tt.want {
+				t.Errorf("glob.matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_glob_remove(t *testing.T) {
+	tests := []struct {
+		name     string
+		glob     glob
+		value    string
+		prefix   bool
+		shortest bool
+		want     string
+	}{
+		{name: "longest prefix", glob: compileGlob("*/"), value: "a/b/c", prefix: true, want: "c"},
+		{name: "shortest prefix", glob: compileGlob("*/"), value: "a/b/c", prefix: true, shortest: true, want: "b/c"},
+		{name: "longest suffix", glob: compileGlob(".*"), value: "a.tar.gz", want: "a"},
+		{name: "shortest suffix", glob: compileGlob(".*"), value: "a.tar.gz", shortest: true, want: "a.tar"},
+		{name: "literal", glob: literalGlob("*a"), value: "*a*b", prefix: true, want: "*b"},
+		{name: "no match", glob: literalGlob("x"), value: "abc", want: "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.glob.removeSuffix(tt.value, tt.shortest)
+			if tt.prefix {
+				got = tt.glob.removePrefix(tt.value, tt.shortest)
+			}
+
+			if got != tt.want {
+				t.Errorf("glob.remove() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,576 @@
+// Package interp runs shast scripts directly in Go without a shell. Scripts run with a virtual environment,
+// a fake file system and a table of commands, so they can be run quickly and hermetically.
+// Only the subset of sh that the compiler generates is supported. Helper functions have raw sh bodies
+// so they are run with native Go implementations instead, and globs are never expanded into file names
+package interp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bjatkin/yok/ast/shast"
+	"github.com/bjatkin/yok/errors"
+)
+
+// emptyFS is a file system without any files
+type emptyFS struct{}
+
+func (emptyFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// variable is a shell variable
+type variable struct {
+	value    string
+	exported bool
+	readonly bool
+}
+
+// Interpreter runs shast scripts. Variables set by a script are kept between runs
+type Interpreter struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// FS is the file system that commands can read from
+	FS fs.FS
+	// Commands are the commands that can be run by scripts
+	Commands map[string]Command
+	// Functions are the native implementations of the functions that scripts can define.
+	// Defining a function that has no native implementation is an error
+	Functions map[string]Command
+
+	vars map[string]*variable
+	// defined are the functions that have been defined by the script
+	defined map[string]bool
+	// status is the exit status of the last command ($?)
+	status int
+	// errexit and nounset are the -e and -u shell options
+	errexit bool
+	nounset bool
+}
+
+// New creates a new interpreter with the environment variables in env. Output is discarded,
+// stdin is empty and the file system is empty until they are set
+func New(env map[string]string) *Interpreter {
+	vars := map[string]*variable{}
+	for name, value := range env {
+		vars[name] = &variable{value: value, exported: true}
+	}
+
+	return &Interpreter{
+		Stdin:     strings.NewReader(""),
+		Stdout:    io.Discard,
+		Stderr:    io.Discard,
+		FS:        emptyFS{},
+		Commands:  DefaultCommands(),
+		Functions: DefaultFunctions(),
+		vars:      vars,
+		defined:   map[string]bool{},
+	}
+}
+
+// exitError stops the script with the exit status
+type exitError struct {
+	status int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.status)
+}
+
+// Run runs the script and returns its exit status. An error is only returned if
+// the script uses sh that the interpreter does not support
+func (i *Interpreter) Run(script *shast.Script) (int, error) {
+	err := i.runStmts(script.Statements)
+	if exit, ok := err.(exitError); ok {
+		i.status = exit.status
+		return exit.status, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return i.status, nil
+}
+
+// Var returns the value of the variable and true if it is set
+func (i *Interpreter) Var(name string) (string, bool) {
+	v, ok := i.vars[name]
+	if !ok {
+		return "", false
+	}
+
+	return v.value, true
+}
+
+// fail writes the error message to stderr and stops the script with the exit status, like a non-interactive shell
+func (i *Interpreter) fail(status int, format string, args ...any) error {
+	fmt.Fprintf(i.Stderr, format+"\n", args...)
+	return exitError{status: status}
+}
+
+// runStmts runs each of the statements in order
+func (i *Interpreter) runStmts(stmts []shast.Stmt) error {
+	for _, stmt := range stmts {
+		err := i.runStmt(stmt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runStmt runs a single statement
+func (i *Interpreter) runStmt(stmt shast.Stmt) error {
+	switch s := stmt.(type) {
+	case *shast.Comment, *shast.NewLine:
+		return nil
+	case *shast.Assign:
+		// the status of an assignment is the status of the last command substitution in it
+		i.status = 0
+		value, err := i.expand(s.Value)
+		if err != nil {
+			return err
+		}
+
+		v, ok := i.vars[s.Identifier]
+		if ok && v.readonly {
+			return i.fail(2, "%s: is read only", s.Identifier)
+		}
+		if !ok {
+			v = &variable{}
+			i.vars[s.Identifier] = v
+		}
+
+		v.value = value
+		v.exported = v.exported || s.Export
+		v.readonly = s.Readonly
+		return i.checkStatus()
+	case *shast.StmtExpr:
+		err := i.runCommand(s.Expression, i.Stdout)
+		if err != nil {
+			return err
+		}
+
+		return i.checkStatus()
+	case *shast.If:
+		return i.runIf(s)
+	case *shast.Function:
+		if _, ok := i.Functions[s.Name]; !ok {
+			return errors.New(fmt.Sprintf("function %s has a raw sh body and no native implementation", s.Name))
+		}
+
+		i.defined[s.Name] = true
+		i.status = 0
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("can not interpret statement type %T", stmt))
+	}
+}
+
+// checkStatus stops the script if the last command failed and the -e option is set
+func (i *Interpreter) checkStatus() error {
+	if i.errexit && i.status != 0 {
+		return exitError{status: i.status}
+	}
+
+	return nil
+}
+
+// runIf runs the body of the first branch of the if statement whose test is true
+func (i *Interpreter) runIf(stmt *shast.If) error {
+	ok, err := i.test(stmt.Test.Expression)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return i.runBranch(stmt.Statements)
+	}
+
+	for _, elseIf := range stmt.ElseIfs {
+		ok, err := i.test(elseIf.Test.Expression)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return i.runBranch(elseIf.Statements)
+		}
+	}
+
+	return i.runBranch(stmt.ElseStatements)
+}
+
+// runBranch runs the statements of an if branch, the status of an empty branch is 0
+func (i *Interpreter) runBranch(stmts []shast.Stmt) error {
+	i.status = 0
+	return i.runStmts(stmts)
+}
+
+// runCommand runs the expression as a command and writes it's stdout to the writer
+func (i *Interpreter) runCommand(expr shast.Expr, stdout io.Writer) error {
+	exec, ok := expr.(*shast.Exec)
+	if ok {
+		return i.runExec(exec, stdout)
+	}
+
+	// any other expression is expanded and the first word is run as the command
+	words, err := i.fields(expr)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		i.status = 0
+		return nil
+	}
+
+	return i.call(words[0], words[1:], nil, stdout)
+}
+
+// runExec runs the command and writes it's stdout to the writer
+func (i *Interpreter) runExec(exec *shast.Exec, stdout io.Writer) error {
+	args := []string{}
+	for _, arg := range exec.Arguments {
+		words, err := i.fields(arg)
+		if err != nil {
+			return err
+		}
+		args = append(args, words...)
+	}
+
+	env := map[string]string{}
+	for _, assign := range exec.Env {
+		value, err := i.expand(assign.Value)
+		if err != nil {
+			return err
+		}
+		env[assign.Name] = value
+	}
+
+	for _, redirect := range exec.Redirects {
+		switch {
+		case redirect.LeftFd <= 1 && redirect.RightFd == "2":
+			stdout = i.Stderr
+		case redirect.LeftFd <= 1 && redirect.RightFd == "1":
+			// stdout is already written to stdout
+		default:
+			return errors.New("can not interpret redirect " + redirect.String())
+		}
+	}
+
+	return i.call(exec.Command, args, env, stdout)
+}
+
+// call calls the command with the arguments, env contains environment variables that are only set for this call
+func (i *Interpreter) call(name string, args []string, env map[string]string, stdout io.Writer) error {
+	if name == "set" {
+		return i.set(args)
+	}
+
+	command, ok := i.Commands[name]
+	if i.defined[name] {
+		command, ok = i.Functions[name]
+	}
+	if !ok {
+		fmt.Fprintf(i.Stderr, "%s: not found\n", name)
+		i.status = 127
+		return nil
+	}
+
+	callEnv := map[string]string{}
+	for name, v := range i.vars {
+		if v.exported {
+			callEnv[name] = v.value
+		}
+	}
+	maps.Copy(callEnv, env)
+
+	i.status = command(&Call{
+		Args:   args,
+		Stdin:  i.Stdin,
+		Stdout: stdout,
+		Stderr: i.Stderr,
+		Env:    callEnv,
+		FS:     i.FS,
+	})
+	return nil
+}
+
+// set sets the -e and -u shell options. Other options are accepted but have no effect
+func (i *Interpreter) set(args []string) error {
+	for n := 0; n < len(args); n++ {
+		arg := args[n]
+		switch {
+		case arg == "-o" || arg == "+o":
+			// named options like pipefail have no effect since pipelines are not supported
+			n++
+		case strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "+"):
+			on := arg[0] == '-'
+			for _, option := range arg[1:] {
+				switch option {
+				case 'e':
+					i.errexit = on
+				case 'u':
+					i.nounset = on
+				}
+			}
+		default:
+			return errors.New("can not interpret positional paramaters set by set " + strings.Join(args, " "))
+		}
+	}
+
+	i.status = 0
+	return nil
+}
+
+// lookup returns the value of the variable. If the variable is not set and the -u option is set the script is stopped
+func (i *Interpreter) lookup(name string) (string, error) {
+	v, ok := i.vars[name]
+	if !ok && i.nounset {
+		return "", i.fail(2, "%s: parameter not set", name)
+	}
+	if !ok {
+		return "", nil
+	}
+
+	return v.value, nil
+}
+
+// fields expands the expression into a list of words. Unquoted expansions are split on white space
+// and removed if they are empty, quoted expansions and literals are always a single word
+func (i *Interpreter) fields(expr shast.Expr) ([]string, error) {
+	value, err := i.expand(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	quoted := true
+	switch e := expr.(type) {
+	case *shast.Identifier:
+		quoted = e.Quoted
+	case *shast.ParamaterExpansion:
+		quoted = e.Quoted
+	case *shast.CommandSub:
+		quoted = e.Quoted
+	case *shast.ArithmeticCommand:
+		quoted = false
+	}
+
+	if quoted {
+		return []string{value}, nil
+	}
+
+	return strings.Fields(value), nil
+}
+
+// expand expands the expression into a single string
+func (i *Interpreter) expand(expr shast.Expr) (string, error) {
+	switch e := expr.(type) {
+	case *shast.String:
+		return e.Value, nil
+	case *shast.Pattern:
+		return e.Value, nil
+	case *shast.Identifier:
+		return i.lookup(e.Value)
+	case *shast.ParamaterExpansion:
+		return i.expandParamater(e.Expression)
+	case *shast.CommandSub:
+		stdout := bytes.Buffer{}
+		err := i.runCommand(e.Expression, &stdout)
+		if err != nil {
+			return "", err
+		}
+
+		// command substitution removes all the trailing new lines from the output
+		return strings.TrimRight(stdout.String(), "\n"), nil
+	case *shast.ArithmeticCommand:
+		value, err := i.arithmetic(e.Expression)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(value, 10), nil
+	default:
+		return "", errors.New(fmt.Sprintf("can not expand expression type %T", expr))
+	}
+}
+
+// expandParamater expands a paramater expansion
+func (i *Interpreter) expandParamater(expr shast.ParamaterExpr) (string, error) {
+	switch e := expr.(type) {
+	case *shast.ParameterLength:
+		value, err := i.lookup(e.Paramater.Value)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(utf8.RuneCountInString(value)), nil
+	case *shast.ParamaterDefault:
+		if value, _ := i.Var(e.Paramater.Value); value != "" {
+			return value, nil
+		}
+
+		return i.expand(e.Default)
+	case *shast.ParamaterUnset:
+		value, _ := i.Var(e.Paramater.Value)
+		return value, nil
+	case *shast.ParamaterRemoveFix:
+		value, err := i.lookup(e.Paramater.Value)
+		if err != nil {
+			return "", err
+		}
+
+		remove, err := i.expand(e.Remove)
+		if err != nil {
+			return "", err
+		}
+
+		g := literalGlob(remove)
+		if isPattern(e.Remove) {
+			g = compileGlob(remove)
+		}
+
+		if e.RemovePrefix {
+			return g.removePrefix(value, e.Shortest), nil
+		}
+		return g.removeSuffix(value, e.Shortest), nil
+	default:
+		return "", errors.New(fmt.Sprintf("can not expand paramater expression type %T", expr))
+	}
+}
+
+// isPattern returns true if the expression is matched as a glob pattern rather than a literal string.
+// Only patterns and unquoted expansions are matched as globs
+func isPattern(expr shast.Expr) bool {
+	switch e := expr.(type) {
+	case *shast.Pattern:
+		return true
+	case *shast.Identifier:
+		return !e.Quoted
+	case *shast.ParamaterExpansion:
+		return !e.Quoted
+	case *shast.CommandSub:
+		return !e.Quoted
+	default:
+		return false
+	}
+}
+
+// arithmetic evaluates an arithmetic expression
+func (i *Interpreter) arithmetic(expr shast.Expr) (int64, error) {
+	switch e := expr.(type) {
+	case *shast.GroupExpr:
+		return i.arithmetic(e.Expression)
+	case *shast.ArithmeticCommand:
+		return i.arithmetic(e.Expression)
+	case *shast.InfixExpr:
+		left, err := i.arithmetic(e.Left)
+		if err != nil {
+			return 0, err
+		}
+
+		right, err := i.arithmetic(e.Right)
+		if err != nil {
+			return 0, err
+		}
+
+		switch e.Operator {
+		case "+":
+			return left + right, nil
+		case "-":
+			return left - right, nil
+		case "*":
+			return left * right, nil
+		case "/", "%":
+			if right == 0 {
+				return 0, i.fail(2, "arithmetic expression: division by zero")
+			}
+			if e.Operator == "/" {
+				return left / right, nil
+			}
+			return left % right, nil
+		default:
+			return 0, errors.New("can not interpret arithmetic operator " + e.Operator)
+		}
+	default:
+		value, err := i.expand(expr)
+		if err != nil {
+			return 0, err
+		}
+
+		// variables are 0 if they're empty
+		if value == "" {
+			return 0, nil
+		}
+
+		number, err := parseInt(value)
+		if err != nil {
+			return 0, i.fail(2, "arithmetic expression: invalid number %q", value)
+		}
+
+		return number, nil
+	}
+}
+
+// test evaluates the expression of a test command
+func (i *Interpreter) test(expr shast.Expr) (bool, error) {
+	switch e := expr.(type) {
+	case *shast.GroupExpr:
+		return i.test(e.Expression)
+	case *shast.InfixExpr:
+		left, err := i.expand(e.Left)
+		if err != nil {
+			return false, err
+		}
+
+		right, err := i.expand(e.Right)
+		if err != nil {
+			return false, err
+		}
+
+		switch e.Operator {
+		case "=":
+			return left == right, nil
+		case "!=":
+			return left != right, nil
+		}
+
+		l, lErr := parseInt(left)
+		r, rErr := parseInt(right)
+		if lErr != nil || rErr != nil {
+			// sh reports the bad number and the test is false
+			fmt.Fprintf(i.Stderr, "[: integer expression expected: %q %s %q\n", left, e.Operator, right)
+			return false, nil
+		}
+
+		switch e.Operator {
+		case "-eq":
+			return l == r, nil
+		case "-ne":
+			return l != r, nil
+		case "-gt":
+			return l > r, nil
+		case "-ge":
+			return l >= r, nil
+		case "-lt":
+			return l < r, nil
+		case "-le":
+			return l <= r, nil
+		default:
+			return false, errors.New("can not interpret test operator " + e.Operator)
+		}
+	default:
+		value, err := i.expand(expr)
+		if err != nil {
+			return false, err
+		}
+
+		return value != "", nil
+	}
+}
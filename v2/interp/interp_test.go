@@ -0,0 +1,173 @@
+package interp
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bjatkin/yok/ast/shast"
+	"github.com/bjatkin/yok/codegen/gensh"
+	"github.com/bjatkin/yok/compiler"
+	"github.com/bjatkin/yok/diff"
+	"github.com/bjatkin/yok/parser"
+)
+
+// compile compiles the yok source into an shast.Script
+func compile(t *testing.T, source string, options compiler.Options) *shast.Script {
+	t.Helper()
+
+	p := parser.New([]byte(source))
+	script, err := p.Parse()
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", source, p.Errors)
+	}
+
+	c := compiler.NewWithOptions([]byte(source), options)
+	shAst, err := c.Compile(script)
+	if err != nil {
+		t.Fatalf("failed to compile %q: %v", source, c.Errors())
+	}
+
+	return shAst
+}
+
+func TestInterpreter_Run(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		options    compiler.Options
+		env        map[string]string
+		wantStdout string
+		wantStderr string
+		wantStatus int
+	}{
+		{
+			name:       "print",
+			source:     "let name = \"yok\"\nprint(\"hello\", name)\neprint(\"oops\")\n",
+			wantStdout: "hello yok\n",
+			wantStderr: "oops\n",
+		},
+		{
+			name:       "math",
+			source:     "let a = :10\nlet b = (a + :2) * :3 % :7\nprint(b)\n",
+			wantStdout: "1\n",
+		},
+		{
+			name:       "if else",
+			source:     "let a = :3\nif a > :5 {\n\tprint(:big)\n} else if a > :1 {\n\tprint(:medium)\n} else {\n\tprint(:small)\n}\n",
+			wantStdout: "medium\n",
+		},
+		{
+			name:       "string builtins",
+			source:     "let s = upper(trim(\"  a-b-c  \"))\nprint(replace_all(s, \"-\", \"+\"), len(s), contains(s, \"B\"))\n",
+			wantStdout: "A+B+C 5 true\n",
+		},
+		{
+			name:       "remove fixes",
+			source:     "let f = \"a/b/c.tar.gz\"\nprint(remove_prefix(f, '*/'), remove_suffix(f, '.*', shortest=:true))\n",
+			wantStdout: "c.tar.gz a/b/c.tar\n",
+		},
+		{
+			name:       "environment variables",
+			source:     "let home = env(\"HOME\")\nlet port = env(\"PORT\") or :8080\nprint(home, port)\n",
+			env:        map[string]string{"HOME": "/home/yok"},
+			wantStdout: "/home/yok 8080\n",
+		},
+		{
+			name:       "unknown command",
+			source:     "make(:all)\nprint(:done)\n",
+			wantStdout: "done\n",
+			wantStderr: "make: not found\n",
+		},
+		{
+			name:       "strict mode stops at the first failure",
+			source:     "print(:start)\nfalse(:now)\nprint(:unreachable)\n",
+			options:    compiler.Options{Strict: true},
+			wantStdout: "start\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "division by zero",
+			source:     "let zero = :0\nlet a = :1 / zero\nprint(a)\n",
+			wantStderr: "arithmetic expression: division by zero\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "empty file system",
+			source:     "cat(\"motd.txt\")\n",
+			wantStderr: "cat: motd.txt: No such file or directory\n",
+			wantStatus: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shAst := compile(t, tt.source, tt.options)
+
+			stdout := bytes.Buffer{}
+			stderr := bytes.Buffer{}
+			i := New(tt.env)
+			i.Stdout = &stdout
+			i.Stderr = &stderr
+			status, err := i.Run(shAst)
+			if err != nil {
+				t.Fatalf("Interpreter.Run() error = %v\n%s", err, gensh.Generate(shAst))
+			}
+
+			if got := stdout.String(); got != tt.wantStdout {
+				t.Errorf("Interpreter.Run() stdout does not match:\n%s\n%s", diff.Unified("want", "got", tt.wantStdout, got, 3), gensh.Generate(shAst))
+			}
+			if got := stderr.String(); got != tt.wantStderr {
+				t.Errorf("Interpreter.Run() stderr does not match:\n%s\n%s", diff.Unified("want", "got", tt.wantStderr, got, 3), gensh.Generate(shAst))
+			}
+			if status != tt.wantStatus {
+				t.Errorf("Interpreter.Run() status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestInterpreter_Commands(t *testing.T) {
+	shAst := compile(t, "export let greeting = \"hi\"\nlet secret = :1\ngreet(:yok, env={LANG: :C})\ncat(\"motd.txt\")\n", compiler.Options{})
+
+	var got *Call
+	stdout := bytes.Buffer{}
+	i := New(nil)
+	i.Stdout = &stdout
+	i.FS = fstest.MapFS{"motd.txt": {Data: []byte("welcome\n")}}
+	i.Commands["greet"] = func(call *Call) int {
+		got = call
+		return 0
+	}
+
+	status, err := i.Run(shAst)
+	if err != nil {
+		t.Fatalf("Interpreter.Run() error = %v", err)
+	}
+	if status != 0 {
+		t.Errorf("Interpreter.Run() status = %d, want 0", status)
+	}
+
+	if got == nil {
+		t.Fatal("Interpreter.Run() did not call greet")
+	}
+	if len(got.Args) != 1 || got.Args[0] != "yok" {
+		t.Errorf("Interpreter.Run() greet args = %q, want [yok]", got.Args)
+	}
+	if len(got.Env) != 2 || got.Env["GREETING"] != "hi" || got.Env["LANG"] != "C" {
+		t.Errorf("Interpreter.Run() greet env = %v, want the exported variables and LANG", got.Env)
+	}
+	if stdout.String() != "welcome\n" {
+		t.Errorf("Interpreter.Run() stdout = %q, want %q", stdout.String(), "welcome\n")
+	}
+}
+
+func TestInterpreter_RawFunction(t *testing.T) {
+	script := &shast.Script{Statements: []shast.Stmt{
+		&shast.Function{Name: "custom", Body: []string{"echo hi"}},
+	}}
+
+	_, err := New(nil).Run(script)
+	if err == nil {
+		t.Errorf("Interpreter.Run() expected an error for a function without a native implementation")
+	}
+}
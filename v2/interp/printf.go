@@ -0,0 +1,214 @@
+package interp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// printf formats the arguments like the sh printf builtin. The format is reused until all the
+// arguments have been consumed. Errors converting numeric arguments are written to errs and the
+// argument is treated as 0, just like sh
+func printf(format string, args []string) (string, []string) {
+	out := strings.Builder{}
+	errs := []string{}
+	for {
+		used, stop := formatOnce(&out, format, args, &errs)
+		args = args[used:]
+		if stop || used == 0 || len(args) == 0 {
+			break
+		}
+	}
+
+	return out.String(), errs
+}
+
+// formatOnce writes the format a single time, it returns the number of arguments that were used
+// and true if a \c escape in a %b argument stopped the output
+func formatOnce(out *strings.Builder, format string, args []string, errs *[]string) (int, bool) {
+	used := 0
+	nextArg := func() string {
+		if used >= len(args) {
+			return ""
+		}
+
+		used++
+		return args[used-1]
+	}
+
+	for i := 0; i < len(format); i++ {
+		switch format[i] {
+		case '\\':
+			value, n, _ := decodeEscape(format[i+1:], false)
+			out.WriteString(value)
+			i += n
+		case '%':
+			start := i
+			i++
+			for i < len(format) && strings.IndexByte("-+ #0", format[i]) >= 0 {
+				i++
+			}
+			// a * width or precision is replaced with the next argument
+			spec := format[start:i]
+			for ; i < len(format) && strings.IndexByte("0123456789.*", format[i]) >= 0; i++ {
+				if format[i] == '*' {
+					spec += strconv.FormatInt(parseNumber(nextArg(), errs), 10)
+				} else {
+					spec += string(format[i])
+				}
+			}
+			if i >= len(format) {
+				out.WriteString(format[start:])
+				return used, false
+			}
+
+			switch verb := format[i]; verb {
+			case '%':
+				out.WriteByte('%')
+			case 's':
+				fmt.Fprintf(out, spec+"s", nextArg())
+			case 'b':
+				value, stop := decodeEscapes(nextArg())
+				fmt.Fprintf(out, spec+"s", value)
+				if stop {
+					return used, true
+				}
+			case 'c':
+				arg := []rune(nextArg())
+				if len(arg) > 0 {
+					fmt.Fprintf(out, spec+"c", arg[0])
+				}
+			case 'd', 'i':
+				fmt.Fprintf(out, spec+"d", parseNumber(nextArg(), errs))
+			case 'o', 'u', 'x', 'X':
+				goVerb := string(verb)
+				if verb == 'u' {
+					goVerb = "d"
+				}
+				fmt.Fprintf(out, spec+goVerb, uint64(parseNumber(nextArg(), errs)))
+			case 'f', 'e', 'E', 'g', 'G':
+				fmt.Fprintf(out, spec+string(verb), parseFloat(nextArg(), errs))
+			default:
+				*errs = append(*errs, fmt.Sprintf("printf: %%%c: invalid directive", verb))
+				return used, true
+			}
+		default:
+			out.WriteByte(format[i])
+		}
+	}
+
+	return used, false
+}
+
+// parseNumber parses a numeric printf argument. Like sh, a leading quote uses the value of the next character
+func parseNumber(arg string, errs *[]string) int64 {
+	if strings.HasPrefix(arg, "'") || strings.HasPrefix(arg, `"`) {
+		chars := []rune(arg[1:])
+		if len(chars) == 0 {
+			return 0
+		}
+		return int64(chars[0])
+	}
+
+	if arg == "" {
+		return 0
+	}
+
+	value, err := parseInt(arg)
+	if err != nil {
+		*errs = append(*errs, fmt.Sprintf("printf: %s: invalid number", arg))
+	}
+
+	return value
+}
+
+// parseFloat parses a floating point printf argument
+func parseFloat(arg string, errs *[]string) float64 {
+	if arg == "" {
+		return 0
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
+	if err != nil {
+		*errs = append(*errs, fmt.Sprintf("printf: %s: invalid number", arg))
+	}
+
+	return value
+}
+
+// parseInt parses an sh integer, integers with a leading 0 are octal and integers with a leading 0x are hex
+func parseInt(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	digits := strings.ToLower(strings.TrimLeft(value, "+-"))
+	if strings.Contains(digits, "_") || strings.HasPrefix(digits, "0o") || strings.HasPrefix(digits, "0b") {
+		// go accepts these integer literals but sh does not
+		return 0, strconv.ErrSyntax
+	}
+
+	return strconv.ParseInt(value, 0, 64)
+}
+
+// decodeEscapes decodes the escape sequences in a %b argument. It returns true if the
+// output should stop because of a \c escape
+func decodeEscapes(value string) (string, bool) {
+	out := strings.Builder{}
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			out.WriteByte(value[i])
+			continue
+		}
+
+		decoded, n, stop := decodeEscape(value[i+1:], true)
+		if stop {
+			return out.String(), true
+		}
+		out.WriteString(decoded)
+		i += n
+	}
+
+	return out.String(), false
+}
+
+// simpleEscapes are the single character escape sequences understood by printf
+var simpleEscapes = map[byte]string{
+	'\\': "\\", 'a': "\a", 'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v", '"': `"`, '\'': "'",
+}
+
+// decodeEscape decodes the escape sequence that follows a '\'. It returns the decoded value and the number
+// of characters after the '\' that were used. In %b arguments octal escapes start with a 0 and \c stops the output
+func decodeEscape(escape string, isArg bool) (string, int, bool) {
+	if escape == "" {
+		return "\\", 0, false
+	}
+
+	if value, ok := simpleEscapes[escape[0]]; ok {
+		return value, 1, false
+	}
+
+	if escape[0] == 'c' && isArg {
+		return "", 1, true
+	}
+
+	start, maxDigits := 0, 3
+	if isArg {
+		if escape[0] != '0' {
+			return "\\", 0, false
+		}
+		start, maxDigits = 1, 4
+	}
+
+	end := start
+	for end < len(escape) && end < maxDigits && escape[end] >= '0' && escape[end] <= '7' {
+		end++
+	}
+	if end == 0 {
+		return "\\", 0, false
+	}
+
+	value, _ := strconv.ParseUint(escape[start:end], 8, 8)
+	if start == end {
+		value = 0
+	}
+
+	return string([]byte{byte(value)}), end, false
+}
@@ -0,0 +1,41 @@
+package interp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_printf(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		args     []string
+		want     string
+		wantErrs []string
+	}{
+		{name: "strings", format: `%s %s\n`, args: []string{"a", "b"}, want: "a b\n"},
+		{name: "reused format", format: `%s,`, args: []string{"a", "b", "c"}, want: "a,b,c,"},
+		{name: "missing arguments", format: `%s|%d|`, args: nil, want: "|0|"},
+		{name: "width and precision", format: `%-5s|%5.2f|%%`, args: []string{"yok", "3"}, want: "yok  | 3.00|%"},
+		{name: "star width and precision", format: `%*s|%-*d|%.*s`, args: []string{"5", "yok", "3", "7", "2", "abc"}, want: "  yok|7  |ab"},
+		{name: "numbers", format: `%d %i %o %x %X %u`, args: []string{"10", "010", "8", "255", "0xff", "7"}, want: "10 8 10 ff FF 7"},
+		{name: "character value", format: `%d`, args: []string{"'A"}, want: "65"},
+		{name: "escapes", format: `a\tb\\\101\n`, want: "a\tb\\A\n"},
+		{name: "escaped argument", format: `%b|%s`, args: []string{`a\nb\0101`, `a\nb`}, want: "a\nbA|a\\nb"},
+		{name: "stop output", format: `%b%s`, args: []string{`a\cb`, "c"}, want: "a"},
+		{name: "invalid number", format: `%d`, args: []string{"abc"}, want: "0", wantErrs: []string{"printf: abc: invalid number"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := printf(tt.format, tt.args)
+			if got != tt.want {
+				t.Errorf("printf() = %q, want %q", got, tt.want)
+			}
+			if len(errs) > 0 || len(tt.wantErrs) > 0 {
+				if !reflect.DeepEqual(errs, tt.wantErrs) {
+					t.Errorf("printf() errs = %v, want %v", errs, tt.wantErrs)
+				}
+			}
+		})
+	}
+}
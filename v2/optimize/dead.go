@@ -0,0 +1,206 @@
+package optimize
+
+import (
+	"github.com/bjatkin/yok/ast/shast"
+	"github.com/bjatkin/yok/token"
+)
+
+// removeDeadBranches removes the branches of if statements that can never run because their
+// test compares two literals. If the test is always true the body replaces the whole if statement
+//
+// Example:
+//
+//	if [ true = true ]; then
+//	    printf '%s\n' yes  -> printf '%s\n' yes
+//	fi
+func removeDeadBranches(script *shast.Script) bool {
+	return rewriteStmts(script, func(stmts []shast.Stmt, nested bool) ([]shast.Stmt, bool) {
+		changed := false
+		result := []shast.Stmt{}
+		for _, stmt := range stmts {
+			ifStmt, ok := stmt.(*shast.If)
+			if !ok {
+				result = append(result, stmt)
+				continue
+			}
+
+			replaced, ok := foldIf(ifStmt)
+			if !ok {
+				result = append(result, stmt)
+				continue
+			}
+
+			changed = true
+			result = append(result, replaced...)
+		}
+
+		if !changed {
+			return stmts, false
+		}
+
+		if nested && isEmpty(result) {
+			// the if statement was the only command in the body so it's replaced with the ':' command
+			result = append(result, &shast.StmtExpr{Pos: token.NoPos, Expression: &shast.Exec{Command: ":"}})
+		}
+
+		return collapseNewLines(result), true
+	})
+}
+
+// foldIf returns the statements that replace the if statement if any of the tests are constant
+func foldIf(stmt *shast.If) ([]shast.Stmt, bool) {
+	result, known := evalTest(stmt.Test)
+	switch {
+	case known && result:
+		return stmt.Statements, true
+	case known && len(stmt.ElseIfs) > 0:
+		next := stmt.ElseIfs[0]
+		return []shast.Stmt{&shast.If{
+			Pos:            next.Pos,
+			Test:           next.Test,
+			Statements:     next.Statements,
+			ElseIfs:        stmt.ElseIfs[1:],
+			ElseStatements: stmt.ElseStatements,
+			Comment:        stmt.Comment,
+		}}, true
+	case known:
+		return stmt.ElseStatements, true
+	}
+
+	// constant else ifs are removed if they are false, if they are true they become the else body
+	changed := false
+	elseIfs := []shast.ElseIf{}
+	for _, elseIf := range stmt.ElseIfs {
+		result, known := evalTest(elseIf.Test)
+		if !known {
+			elseIfs = append(elseIfs, elseIf)
+			continue
+		}
+
+		changed = true
+		if result {
+			// the rest of the else ifs and the else body can never run
+			stmt.ElseStatements = elseIf.Statements
+			break
+		}
+	}
+
+	if !changed {
+		return nil, false
+	}
+
+	stmt.ElseIfs = elseIfs
+	return []shast.Stmt{stmt}, true
+}
+
+// evalTest evaluates a test command that compares two literals. It returns false
+// for known if the result of the test can not be known before the script runs
+func evalTest(test *shast.TestCommand) (result bool, known bool) {
+	infix, ok := test.Expression.(*shast.InfixExpr)
+	if !ok {
+		return false, false
+	}
+
+	left, ok := infix.Left.(*shast.String)
+	if !ok {
+		return false, false
+	}
+
+	right, ok := infix.Right.(*shast.String)
+	if !ok {
+		return false, false
+	}
+
+	switch infix.Operator {
+	case "=":
+		return left.Value == right.Value, true
+	case "!=":
+		return left.Value != right.Value, true
+	}
+
+	l, ok := intValue(left)
+	if !ok {
+		return false, false
+	}
+
+	r, ok := intValue(right)
+	if !ok {
+		return false, false
+	}
+
+	switch infix.Operator {
+	case "-eq":
+		return l == r, true
+	case "-ne":
+		return l != r, true
+	case "-lt":
+		return l < r, true
+	case "-le":
+		return l <= r, true
+	case "-gt":
+		return l > r, true
+	case "-ge":
+		return l >= r, true
+	default:
+		return false, false
+	}
+}
+
+// removeUnusedAssigns removes assignments to variables that are never used. Assignments with side effects
+// (e.g. command substitutions) and exported or readonly variables are always kept
+//
+// Example:
+//
+//	_TMP1=hello -> (removed)
+func removeUnusedAssigns(script *shast.Script) bool {
+	uses := countUsage(script)
+	return rewriteStmts(script, func(stmts []shast.Stmt, nested bool) ([]shast.Stmt, bool) {
+		kept := []shast.Stmt{}
+		for _, stmt := range stmts {
+			assign, ok := stmt.(*shast.Assign)
+			if ok && uses[assign.Identifier] == 0 && !assign.Export && !assign.Readonly && isPure(assign.Value) {
+				continue
+			}
+
+			kept = append(kept, stmt)
+		}
+
+		if len(kept) == len(stmts) {
+			return stmts, false
+		}
+
+		if nested && isEmpty(kept) {
+			// the body of an if statement must run at least one command
+			return stmts, false
+		}
+
+		return collapseNewLines(kept), true
+	})
+}
+
+// removeUnusedHelpers removes the helper functions that are no longer called after their calls were folded
+func removeUnusedHelpers(script *shast.Script) bool {
+	called := map[string]bool{}
+	shast.Walk(visitFunc(func(node shast.Node) {
+		if exec, ok := node.(*shast.Exec); ok {
+			called[exec.Command] = true
+		}
+	}), script)
+
+	kept := []shast.Stmt{}
+	for _, stmt := range script.Statements {
+		function, ok := stmt.(*shast.Function)
+		if ok && !called[function.Name] {
+			continue
+		}
+
+		kept = append(kept, stmt)
+	}
+
+	if len(kept) == len(script.Statements) {
+		return false
+	}
+
+	script.Statements = collapseNewLines(kept)
+	return true
+}
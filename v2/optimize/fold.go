@@ -0,0 +1,274 @@
+package optimize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/yok/ast/shast"
+)
+
+// intLiteral matches the integer literals that can be folded. Numbers with a leading zero
+// are octal in sh arithmetic so they are never folded
+var intLiteral = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+
+// foldMath folds arithmetic on integer literals. Arithmetic commands that only use literals are
+// replaced by their result, and literal sub expressions of other arithmetic commands are folded in place
+//
+// Example:
+//
+//	$(( 10 / 2 * ( 3 + 8 ) - 7 )) -> 48
+//	$(( $A + 2 * 3 ))             -> $(( $A + 6 ))
+func foldMath(script *shast.Script) bool {
+	return rewriteExprs(script, func(expr shast.Expr, arith bool) shast.Expr {
+		arithmetic, ok := expr.(*shast.ArithmeticCommand)
+		if !ok || arith {
+			return expr
+		}
+
+		folded, changed := foldArithmetic(arithmetic.Expression)
+		if str, ok := folded.(*shast.String); ok {
+			return str
+		}
+		if changed {
+			return &shast.ArithmeticCommand{Expression: folded}
+		}
+
+		return expr
+	})
+}
+
+// foldArithmetic folds the literal sub expressions of an arithmetic expression
+func foldArithmetic(expr shast.Expr) (shast.Expr, bool) {
+	switch e := expr.(type) {
+	case *shast.GroupExpr:
+		inner, changed := foldArithmetic(e.Expression)
+		if str, ok := inner.(*shast.String); ok {
+			return str, true
+		}
+		if changed {
+			return &shast.GroupExpr{Expression: inner}, true
+		}
+
+		return e, false
+	case *shast.InfixExpr:
+		left, leftChanged := foldArithmetic(e.Left)
+		right, rightChanged := foldArithmetic(e.Right)
+		if value, ok := evalInfix(left, e.Operator, right); ok {
+			return &shast.String{Value: strconv.Itoa(value)}, true
+		}
+		if leftChanged || rightChanged {
+			return &shast.InfixExpr{Left: left, Operator: e.Operator, Right: right}, true
+		}
+
+		return e, false
+	default:
+		return e, false
+	}
+}
+
+// evalInfix evaluates the operator if both sides are integer literals. Division by zero is left for sh to report
+func evalInfix(left shast.Expr, operator string, right shast.Expr) (int, bool) {
+	l, ok := intValue(left)
+	if !ok {
+		return 0, false
+	}
+
+	r, ok := intValue(right)
+	if !ok {
+		return 0, false
+	}
+
+	switch operator {
+	case "+":
+		return l + r, true
+	case "-":
+		return l - r, true
+	case "*":
+		return l * r, true
+	case "/":
+		if r == 0 {
+			return 0, false
+		}
+		return l / r, true
+	case "%":
+		if r == 0 {
+			return 0, false
+		}
+		return l % r, true
+	default:
+		return 0, false
+	}
+}
+
+// intValue returns the value of the expression if it's an integer literal
+func intValue(expr shast.Expr) (int, bool) {
+	str, ok := expr.(*shast.String)
+	if !ok || !intLiteral.MatchString(str.Value) {
+		return 0, false
+	}
+
+	value, err := strconv.Atoi(str.Value)
+	return value, err == nil
+}
+
+// foldStrings folds string operations on literals. Paramater expansions of temporaries that were assigned
+// a literal and calls to helper functions with literal arguments are replaced by their result.
+// Only ASCII values are folded since the result of some operations depends on the locale of the shell
+//
+// Example:
+//
+//	_TMP1=hello
+//	printf '%s\n' "${#_TMP1}" -> printf '%s\n' 5
+//	"$(_yok_upper abc)"       -> ABC
+func foldStrings(script *shast.Script) bool {
+	temps := tempValues(script)
+	return rewriteExprs(script, func(expr shast.Expr, arith bool) shast.Expr {
+		switch e := expr.(type) {
+		case *shast.ParamaterExpansion:
+			if value, ok := foldParamater(e.Expression, temps); ok {
+				return &shast.String{Value: value}
+			}
+		case *shast.CommandSub:
+			if value, ok := foldHelperCall(e.Expression); ok {
+				return &shast.String{Value: value}
+			}
+		}
+
+		return expr
+	})
+}
+
+// tempValues returns the values of all the temporaries that are assigned an ASCII string literal
+func tempValues(script *shast.Script) map[string]string {
+	values := map[string]string{}
+	shast.Walk(visitFunc(func(node shast.Node) {
+		assign, ok := node.(*shast.Assign)
+		if !ok || !isTemp(assign.Identifier) {
+			return
+		}
+
+		if str, ok := assign.Value.(*shast.String); ok && isASCII(str.Value) {
+			values[assign.Identifier] = str.Value
+		}
+	}), script)
+
+	return values
+}
+
+// foldParamater folds a paramater expansion of a temporary with a known value
+func foldParamater(expr shast.ParamaterExpr, temps map[string]string) (string, bool) {
+	switch e := expr.(type) {
+	case *shast.ParameterLength:
+		value, ok := temps[e.Paramater.Value]
+		if !ok {
+			return "", false
+		}
+
+		return strconv.Itoa(len(value)), true
+	case *shast.ParamaterRemoveFix:
+		value, ok := temps[e.Paramater.Value]
+		if !ok {
+			return "", false
+		}
+
+		// patterns can match many strings so only literal strings are folded
+		remove, ok := e.Remove.(*shast.String)
+		if !ok || !isASCII(remove.Value) {
+			return "", false
+		}
+
+		if e.RemovePrefix {
+			return strings.TrimPrefix(value, remove.Value), true
+		}
+		return strings.TrimSuffix(value, remove.Value), true
+	default:
+		return "", false
+	}
+}
+
+// helperFuncs are the Go equivalents of the compiler's helper functions. They must print exactly what
+// the helper function would print
+var helperFuncs = map[string]func(args []string) string{
+	"_yok_replace": func(args []string) string {
+		return strings.Replace(args[0], args[1], args[2], 1)
+	},
+	"_yok_replace_all": func(args []string) string {
+		if args[1] == "" {
+			return args[0]
+		}
+		return strings.ReplaceAll(args[0], args[1], args[2])
+	},
+	"_yok_split": func(args []string) string {
+		if args[1] == "" {
+			return args[0]
+		}
+		return strings.ReplaceAll(args[0], args[1], "\n")
+	},
+	"_yok_upper": func(args []string) string {
+		return strings.ToUpper(args[0])
+	},
+	"_yok_lower": func(args []string) string {
+		return strings.ToLower(args[0])
+	},
+	"_yok_trim": func(args []string) string {
+		return strings.Trim(args[0], " \t\n\v\f\r")
+	},
+	"_yok_contains": func(args []string) string {
+		return strconv.FormatBool(strings.Contains(args[0], args[1]))
+	},
+	"_yok_starts_with": func(args []string) string {
+		return strconv.FormatBool(strings.HasPrefix(args[0], args[1]))
+	},
+	"_yok_ends_with": func(args []string) string {
+		return strconv.FormatBool(strings.HasSuffix(args[0], args[1]))
+	},
+}
+
+// foldHelperCall folds a call to a helper function if all of the arguments are ASCII string literals
+func foldHelperCall(expr shast.Expr) (string, bool) {
+	exec, ok := expr.(*shast.Exec)
+	if !ok || len(exec.Redirects) > 0 || len(exec.Env) > 0 {
+		return "", false
+	}
+
+	fn, ok := helperFuncs[exec.Command]
+	if !ok {
+		return "", false
+	}
+
+	args := []string{}
+	for _, arg := range exec.Arguments {
+		str, ok := arg.(*shast.String)
+		if !ok || !isASCII(str.Value) {
+			return "", false
+		}
+		args = append(args, str.Value)
+	}
+
+	// command substitution removes all the trailing new lines from the output
+	return strings.TrimRight(fn(args), "\n"), true
+}
+
+// isASCII returns true if the string only contains ASCII characters
+func isASCII(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] >= 0x80 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// visitFunc is a shast.Visitor that calls the function for every node in the AST
+type visitFunc func(node shast.Node)
+
+// Visit implements the shast.Visitor interface
+func (f visitFunc) Visit(node shast.Node) shast.Visitor {
+	if node != nil {
+		f(node)
+	}
+
+	return f
+}
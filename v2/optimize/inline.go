@@ -0,0 +1,136 @@
+package optimize
+
+import "github.com/bjatkin/yok/ast/shast"
+
+// inlineTemps inlines temporaries that are used exactly once back into the statement that uses them.
+// Only values without side effects are inlined, and only if the statements between the temporary and it's use
+// are other temporaries, so moving the value can never change the result of the script
+//
+// Example:
+//
+//	_TMP1=$(( $A + 1 ))
+//	B=$(( $_TMP1 * 2 )) -> B=$(( ( $A + 1 ) * 2 ))
+func inlineTemps(script *shast.Script) bool {
+	uses := countUsage(script)
+	return rewriteStmts(script, func(stmts []shast.Stmt, nested bool) ([]shast.Stmt, bool) {
+		inlined := map[int]bool{}
+		for i, stmt := range stmts {
+			assign, ok := stmt.(*shast.Assign)
+			if !ok || !isTemp(assign.Identifier) || uses[assign.Identifier] != 1 || !isPure(assign.Value) {
+				continue
+			}
+
+			for j := i + 1; j < len(stmts); j++ {
+				if inlineInto(stmts[j], assign.Identifier, assign.Value) {
+					inlined[i] = true
+					break
+				}
+
+				if !isPureTemp(stmts[j]) {
+					break
+				}
+			}
+		}
+
+		if len(inlined) == 0 {
+			return stmts, false
+		}
+
+		kept := []shast.Stmt{}
+		for i, stmt := range stmts {
+			if !inlined[i] {
+				kept = append(kept, stmt)
+			}
+		}
+
+		return kept, true
+	})
+}
+
+// isPureTemp returns true if the statement assigns a value without side effects to a temporary
+func isPureTemp(stmt shast.Stmt) bool {
+	assign, ok := stmt.(*shast.Assign)
+	return ok && isTemp(assign.Identifier) && isPure(assign.Value)
+}
+
+// isPure returns true if the expression has no side effects. Commands are never pure
+func isPure(expr shast.Expr) bool {
+	switch e := expr.(type) {
+	case *shast.String, *shast.Pattern, *shast.Identifier:
+		return true
+	case *shast.ArithmeticCommand:
+		return isPure(e.Expression)
+	case *shast.InfixExpr:
+		return isPure(e.Left) && isPure(e.Right)
+	case *shast.GroupExpr:
+		return isPure(e.Expression)
+	case *shast.ParamaterExpansion:
+		switch p := e.Expression.(type) {
+		case *shast.ParamaterDefault:
+			return isPure(p.Default)
+		case *shast.ParamaterRemoveFix:
+			return isPure(p.Remove)
+		default:
+			return true
+		}
+	default:
+		return false
+	}
+}
+
+// inlineInto replaces the use of the temporary in the statement with its value. Only the expressions that are
+// evaluated before the statement runs are searched, the bodies of if statements are never changed.
+// It returns true if the temporary was inlined
+func inlineInto(stmt shast.Stmt, name string, value shast.Expr) bool {
+	done := false
+	inline := func(expr shast.Expr, arith bool) shast.Expr {
+		ident, ok := expr.(*shast.Identifier)
+		if done || !ok || ident.Value != name {
+			return expr
+		}
+
+		replaced, ok := inlineValue(value, ident, arith)
+		if ok {
+			done = true
+			return replaced
+		}
+
+		return expr
+	}
+
+	switch s := stmt.(type) {
+	case *shast.Assign, *shast.StmtExpr:
+		rewriteExprs(s, inline)
+	case *shast.If:
+		rewriteExprs(s.Test, inline)
+		for _, elseIf := range s.ElseIfs {
+			rewriteExprs(elseIf.Test, inline)
+		}
+	}
+
+	return done
+}
+
+// inlineValue returns the value that replaces the use of a temporary. Values in arithmetic commands
+// can not be quoted so only numbers, variables and other arithmetic can be inlined there
+func inlineValue(value shast.Expr, use *shast.Identifier, arith bool) (shast.Expr, bool) {
+	if !arith {
+		if ident, ok := value.(*shast.Identifier); ok {
+			return &shast.Identifier{Value: ident.Value, Quoted: use.Quoted}, true
+		}
+
+		return value, true
+	}
+
+	switch v := value.(type) {
+	case *shast.String:
+		_, ok := intValue(v)
+		return v, ok
+	case *shast.Identifier:
+		return &shast.Identifier{Value: v.Value}, true
+	case *shast.ArithmeticCommand:
+		return &shast.GroupExpr{Expression: v.Expression}, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,51 @@
+package optimize
+
+import "github.com/bjatkin/yok/ast/shast"
+
+// Level is the optimization level, it's set with the -O flag
+type Level int
+
+const (
+	// O0 disables all optimizations, the script is generated exactly as it was compiled
+	O0 = Level(iota)
+	// O1 folds constants, removes dead code and inlines temporary variables
+	O1
+)
+
+// pass is a single optimization, it returns true if it changed the script
+type pass func(script *shast.Script) bool
+
+// passes are the optimizations run at O1, in the order they are run
+var passes = []pass{
+	foldMath,
+	foldStrings,
+	inlineTemps,
+	removeDeadBranches,
+	removeUnusedAssigns,
+	removeUnusedHelpers,
+}
+
+// maxRounds limits the number of times the passes are run. Each pass can open up new
+// optimizations for the others so they're run until the script stops changing
+const maxRounds = 10
+
+// Optimize optimizes the script in place. The script must come from the compiler since the
+// optimizations rely on the compiler's conventions (e.g. names of temporary variables and helpers)
+func Optimize(script *shast.Script, level Level) {
+	if level < O1 {
+		return
+	}
+
+	for range maxRounds {
+		changed := false
+		for _, optimize := range passes {
+			if optimize(script) {
+				changed = true
+			}
+		}
+
+		if !changed {
+			return
+		}
+	}
+}
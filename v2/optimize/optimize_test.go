@@ -0,0 +1,171 @@
+package optimize
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjatkin/yok/ast/shast"
+	"github.com/bjatkin/yok/codegen/gensh"
+	"github.com/bjatkin/yok/compiler"
+	"github.com/bjatkin/yok/diff"
+	"github.com/bjatkin/yok/parser"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(diff.RunWithOrphanCheck(m, "testdata", "*.sh"))
+}
+
+func TestPasses(t *testing.T) {
+	tests := []struct {
+		name    string
+		yokFile string
+		shFile  string
+		pass    pass
+	}{
+		{
+			name:    "fold math",
+			yokFile: "fold_math.yok",
+			shFile:  "fold_math.sh",
+			pass:    foldMath,
+		},
+		{
+			name:    "fold strings",
+			yokFile: "fold_strings.yok",
+			shFile:  "fold_strings.sh",
+			pass:    foldStrings,
+		},
+		{
+			name:    "inline temporaries",
+			yokFile: "inline_temps.yok",
+			shFile:  "inline_temps.sh",
+			pass:    inlineTemps,
+		},
+		{
+			name:    "remove dead branches",
+			yokFile: "dead_branches.yok",
+			shFile:  "dead_branches.sh",
+			pass:    removeDeadBranches,
+		},
+		{
+			name:    "remove unused assignments",
+			yokFile: "unused_assigns.yok",
+			shFile:  "unused_assigns.sh",
+			pass:    removeUnusedAssigns,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := mustCompile(t, tt.yokFile)
+			if !tt.pass(script) {
+				t.Errorf("%s did not change the script", tt.name)
+			}
+
+			got := gensh.Generate(script)
+			wantFile := filepath.Join("testdata", tt.shFile)
+			if diffs := diff.AgainstFile(t, got, wantFile); diffs != "" {
+				t.Errorf("%s generated code does not match %s:\n%s", tt.name, tt.shFile, diffs)
+			}
+		})
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name    string
+		yokFile string
+		want    string
+	}{
+		{
+			name:    "fold math",
+			yokFile: "fold_math.yok",
+			want:    "48\n56\n",
+		},
+		{
+			name:    "fold strings",
+			yokFile: "fold_strings.yok",
+			want:    "HELLO world padded\na+b+c 5\n1.2.3 main\nYOK\n",
+		},
+		{
+			name:    "inline temporaries",
+			yokFile: "inline_temps.yok",
+			want:    "2345\nv1.2.\n4\n",
+		},
+		{
+			name:    "remove dead branches",
+			yokFile: "dead_branches.yok",
+			want:    "always\ncontains b\na is one\n",
+		},
+		{
+			name:    "remove unused assignments",
+			yokFile: "unused_assigns.yok",
+			want:    "1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := mustCompile(t, tt.yokFile)
+			Optimize(script, O1)
+
+			got := runSh(t, gensh.Generate(script))
+			if got != tt.want {
+				t.Errorf("Optimize() script printed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptimize_O0(t *testing.T) {
+	script := mustCompile(t, "fold_math.yok")
+	want := gensh.Generate(script)
+
+	Optimize(script, O0)
+	if got := gensh.Generate(script); got != want {
+		t.Errorf("Optimize() changed the script at O0:\n%s", got)
+	}
+}
+
+func mustCompile(t *testing.T, yokFile string) *shast.Script {
+	t.Helper()
+
+	source, err := os.ReadFile(filepath.Join("..", "testdata", yokFile))
+	if err != nil {
+		t.Fatal("failed to read source file", err)
+	}
+
+	p := parser.New(source)
+	script, err := p.Parse()
+	if err != nil {
+		t.Fatalf("failed to parse source: %v %v", err, p.Errors)
+	}
+
+	c := compiler.New(source)
+	shScript, err := c.Compile(script)
+	if err != nil {
+		t.Fatalf("failed to compile source: %v %v", err, c.Errors())
+	}
+
+	return shScript
+}
+
+func runSh(t *testing.T, script string) string {
+	t.Helper()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not available", err)
+	}
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	cmd := exec.Command(sh, "-c", script)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run script: %v\n%s\n%s", err, stderr.String(), script)
+	}
+
+	return stdout.String()
+}
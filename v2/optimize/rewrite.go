@@ -0,0 +1,146 @@
+package optimize
+
+import (
+	"strings"
+
+	"github.com/bjatkin/yok/ast/shast"
+)
+
+// tempPrefix is the prefix of the temporary variables created by the compiler's fixer
+const tempPrefix = "_TMP"
+
+// isTemp returns true if the variable is a temporary created by the compiler.
+// Temporaries are assigned exactly once, right before the statement that uses them
+func isTemp(name string) bool {
+	return strings.HasPrefix(name, tempPrefix)
+}
+
+// rewriteExprs rewrites every expression in the node, arith is set for expressions that are inside an
+// arithmetic command. Paramaters of paramater expansions must stay identifiers and the tests of if statements
+// must stay test commands so they are never rewritten. It returns true if any expression was replaced
+func rewriteExprs(node shast.Node, rewrite func(expr shast.Expr, arith bool) shast.Expr) bool {
+	changed := false
+	arith := 0
+	shast.Apply(node, func(c *shast.Cursor) bool {
+		expr, ok := c.Node().(shast.Expr)
+		if ok && c.Parent() != nil && !fixedField(c.Name()) {
+			if replaced := rewrite(expr, arith > 0); replaced != expr {
+				c.Replace(replaced)
+				changed = true
+			}
+		}
+
+		if _, ok := c.Node().(*shast.ArithmeticCommand); ok {
+			arith++
+		}
+
+		return true
+	}, func(c *shast.Cursor) bool {
+		if _, ok := c.Node().(*shast.ArithmeticCommand); ok {
+			arith--
+		}
+
+		return true
+	})
+
+	return changed
+}
+
+// fixedField returns true if the field can only hold a single kind of expression
+func fixedField(name string) bool {
+	switch name {
+	case "Paramater", "Test", "ElseIfs.Test":
+		return true
+	default:
+		return false
+	}
+}
+
+// rewriteStmts rewrites every list of statements in the script, including the bodies of if statements.
+// nested is set for the bodies of if statements. It returns true if any of the lists were changed
+func rewriteStmts(script *shast.Script, rewrite func(stmts []shast.Stmt, nested bool) ([]shast.Stmt, bool)) bool {
+	var walk func(stmts []shast.Stmt, nested bool) ([]shast.Stmt, bool)
+	walk = func(stmts []shast.Stmt, nested bool) ([]shast.Stmt, bool) {
+		changed := false
+		for _, stmt := range stmts {
+			ifStmt, ok := stmt.(*shast.If)
+			if !ok {
+				continue
+			}
+
+			var c bool
+			ifStmt.Statements, c = walk(ifStmt.Statements, true)
+			changed = changed || c
+			for i := range ifStmt.ElseIfs {
+				ifStmt.ElseIfs[i].Statements, c = walk(ifStmt.ElseIfs[i].Statements, true)
+				changed = changed || c
+			}
+			if ifStmt.ElseStatements != nil {
+				ifStmt.ElseStatements, c = walk(ifStmt.ElseStatements, true)
+				changed = changed || c
+			}
+		}
+
+		stmts, c := rewrite(stmts, nested)
+		return stmts, changed || c
+	}
+
+	var changed bool
+	script.Statements, changed = walk(script.Statements, false)
+	return changed
+}
+
+// isEmpty returns true if there are no commands in the statements. The body of an if
+// statement can not be empty in sh, blank lines and comments are not enough
+func isEmpty(stmts []shast.Stmt) bool {
+	for _, stmt := range stmts {
+		switch stmt.(type) {
+		case *shast.NewLine, *shast.Comment:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// collapseNewLines removes blank lines that were left behind by removed statements.
+// Blank lines at the start of the statements and repeated blank lines are removed
+func collapseNewLines(stmts []shast.Stmt) []shast.Stmt {
+	collapsed := []shast.Stmt{}
+	for _, stmt := range stmts {
+		if _, ok := stmt.(*shast.NewLine); ok && (len(collapsed) == 0 || isNewLine(collapsed[len(collapsed)-1])) {
+			continue
+		}
+
+		collapsed = append(collapsed, stmt)
+	}
+
+	return collapsed
+}
+
+// isNewLine returns true if the statement is a blank line
+func isNewLine(stmt shast.Stmt) bool {
+	_, ok := stmt.(*shast.NewLine)
+	return ok
+}
+
+// usage counts the number of times each variable is used in the script
+type usage map[string]int
+
+// countUsage counts the number of times each variable is used in the script.
+// Function bodies are raw sh code so they are not counted
+func countUsage(script *shast.Script) usage {
+	u := usage{}
+	shast.Walk(u, script)
+	return u
+}
+
+// Visit implements the shast.Visitor interface
+func (u usage) Visit(node shast.Node) shast.Visitor {
+	if ident, ok := node.(*shast.Identifier); ok {
+		u[ident.Value]++
+	}
+
+	return u
+}
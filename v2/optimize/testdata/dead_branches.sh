@@ -0,0 +1,22 @@
+#!/bin/sh
+
+_yok_contains() {
+    case $1 in
+        *"$2"*) printf true ;;
+        *) printf false ;;
+    esac
+}
+
+# branches that can never run are removed
+printf '%s\n' always
+
+if [ "$(_yok_contains abc b)" = true ]; then
+    printf '%s\n' "contains b"
+else
+    printf '%s\n' "no b"
+fi
+
+YOK_A=1
+if [ "$YOK_A" = 1 ]; then
+    printf '%s\n' "a is one"
+fi
\ No newline at end of file
@@ -0,0 +1,9 @@
+#!/bin/sh
+
+# math on literals is done by the compiler
+YOK_A=48
+printf '%s\n' "$YOK_A"
+
+# literals are folded even when they are mixed with variables
+YOK_B=$(( $YOK_A + 6 + 2 ))
+printf '%s\n' "$YOK_B"
\ No newline at end of file
@@ -0,0 +1,44 @@
+#!/bin/sh
+
+_yok_replace_all() {
+    if [ -z "$2" ]; then
+        printf '%s' "$1"
+        return
+    fi
+    _yok_rest=$1
+    while :; do
+        case $_yok_rest in
+            *"$2"*)
+                printf '%s%s' "${_yok_rest%%"$2"*}" "$3"
+                _yok_rest=${_yok_rest#*"$2"}
+                ;;
+            *) break ;;
+        esac
+    done
+    printf '%s' "$_yok_rest"
+}
+
+_yok_upper() {
+    printf '%s' "$1" | tr '[:lower:]' '[:upper:]'
+}
+
+_yok_lower() {
+    printf '%s' "$1" | tr '[:upper:]' '[:lower:]'
+}
+
+_yok_trim() {
+    _yok_str=${1#"${1%%[![:space:]]*}"}
+    printf '%s' "${_yok_str%"${_yok_str##*[![:space:]]}"}"
+}
+
+# string builtins with literal arguments are done by the compiler
+printf '%s %s %s\n' HELLO world padded
+_TMP1=hello
+printf '%s %s\n' a+b+c 5
+_TMP2=v1.2.3
+_TMP3=main.go
+printf '%s %s\n' 1.2.3 main
+
+# values that are only known at runtime are left as is
+YOK_NAME=yok
+printf '%s\n' "$(_yok_upper "$YOK_NAME")"
\ No newline at end of file
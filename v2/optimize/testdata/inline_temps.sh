@@ -0,0 +1,13 @@
+#!/bin/sh
+
+YOK_A=5
+YOK_VERSION=v1.2.3
+
+# temporaries used once are inlined back into the statement that uses them
+_TMP1=12345
+printf '%s\n' "${_TMP1##$(( $YOK_A - 4 ))}"
+printf '%s\n' "${YOK_VERSION%%$(( $YOK_A - 2 ))}"
+
+# temporaries that must be used as a variable are kept
+_TMP4="${YOK_VERSION%%.3}"
+printf '%s\n' "${#_TMP4}"
\ No newline at end of file
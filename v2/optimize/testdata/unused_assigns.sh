@@ -0,0 +1,10 @@
+#!/bin/sh
+
+# assignments that are never used are removed
+YOK_A=1
+export C=exported
+
+# commands always run even if the result is not used
+YOK_D="$(echo "side effect")"
+
+printf '%s\n' "$YOK_A"
\ No newline at end of file
@@ -39,6 +39,11 @@ func encodeTokens(tokens []token.Token, source []byte) repr.Array {
 	return array
 }
 
+// Encode converts a yok script into a repr string, it's used to debug the parser
+func Encode(script *yokast.Script, source []byte) string {
+	return encodeScript(script, source)
+}
+
 // encodeScript converts a yok script into a repr string
 func encodeScript(script *yokast.Script, source []byte) string {
 	array := repr.Array{}
@@ -63,20 +68,67 @@ func encodeNode(node yokast.Node, source []byte) repr.Value {
 		return repr.NewObject("NewLine")
 	case *yokast.Assign:
 		identifier := encodeNode(node.Identifier, source)
-		value := encodeNode(node.Value, source)
-		return repr.NewObject(
+		assign := repr.NewObject(
 			"Assign",
 			repr.NewField("Identifier", identifier),
+		)
+		if node.Type != nil {
+			assign.AddFields(repr.NewField("Type", repr.String(node.Type.Name(source))))
+		}
+		if node.Value != nil {
+			assign.AddFields(repr.NewField("Value", encodeNode(node.Value, source)))
+		}
+		if node.Export {
+			assign.AddFields(repr.NewField("Export", repr.Bool(true)))
+		}
+		if node.Const {
+			assign.AddFields(repr.NewField("Const", repr.Bool(true)))
+		}
+		addTrailingComment(&assign, node.Comment, source)
+		return assign
+	case *yokast.Reassign:
+		identifier := encodeNode(node.Identifier, source)
+		value := encodeNode(node.Value, source)
+		reassign := repr.NewObject(
+			"Reassign",
+			repr.NewField("Identifier", identifier),
+			repr.NewField("Value", value),
+		)
+		addTrailingComment(&reassign, node.Comment, source)
+		return reassign
+	case *yokast.EnvAssign:
+		variable := encodeNode(node.Variable, source)
+		value := encodeNode(node.Value, source)
+		assign := repr.NewObject(
+			"EnvAssign",
+			repr.NewField("Variable", variable),
 			repr.NewField("Value", value),
 		)
+		addTrailingComment(&assign, node.Comment, source)
+		return assign
 	case *yokast.StmtExpr:
-		return encodeNode(node.Expression, source)
+		if node.Comment == nil {
+			return encodeNode(node.Expression, source)
+		}
+
+		expression := encodeNode(node.Expression, source)
+		stmt := repr.NewObject(
+			"StmtExpr",
+			repr.NewField("Expression", expression),
+		)
+		addTrailingComment(&stmt, node.Comment, source)
+		return stmt
 	case *yokast.String:
 		safeValue := strings.ReplaceAll(node.Value(source), "\"", "\\\"")
 		return repr.NewObject(
 			"String",
 			repr.NewField("Value", repr.String(safeValue)),
 		)
+	case *yokast.Pattern:
+		return repr.NewObject(
+			"Pattern",
+			repr.NewField("Value", repr.String(node.Value(source))),
+		)
 	case *yokast.Atom:
 		return repr.NewObject(
 			"Atom",
@@ -85,17 +137,48 @@ func encodeNode(node yokast.Node, source []byte) repr.Value {
 	case *yokast.Call:
 		identifier := encodeNode(node.Identifier, source)
 		args := encodeExprs(node.Arguments, source)
-		return repr.NewObject(
+		call := repr.NewObject(
 			"FunctionCall",
 			repr.NewField("Identifier", identifier),
 			repr.NewField("Arguments", args),
 		)
+		if len(node.NamedArguments) > 0 {
+			namedArgs := repr.Array{}
+			for _, arg := range node.NamedArguments {
+				namedArgs.AddValue(repr.NewObject(
+					"NamedArg",
+					repr.NewField("Name", encodeNode(arg.Name, source)),
+					repr.NewField("Value", encodeNode(arg.Value, source)),
+				))
+			}
+			call.AddFields(repr.NewField("NamedArguments", namedArgs))
+		}
+		return call
+	case *yokast.Dict:
+		entries := repr.Array{}
+		for _, entry := range node.Entries {
+			entries.AddValue(repr.NewObject(
+				"DictEntry",
+				repr.NewField("Key", encodeToken(entry.Key, source)),
+				repr.NewField("Value", encodeNode(entry.Value, source)),
+			))
+		}
+		return repr.NewObject(
+			"Dict",
+			repr.NewField("Entries", entries),
+		)
 	case *yokast.Identifier:
 		token := encodeToken(node.Token, source)
 		return repr.NewObject(
 			"Identifier",
 			repr.NewField("Token", token),
 		)
+	case *yokast.EnvVar:
+		name := encodeToken(node.Name, source)
+		return repr.NewObject(
+			"EnvVar",
+			repr.NewField("Name", name),
+		)
 	case *yokast.InfixExpr:
 		operator := encodeToken(node.Operator, source)
 		left := encodeNode(node.Left, source)
@@ -112,18 +195,28 @@ func encodeNode(node yokast.Node, source []byte) repr.Value {
 			"GroupedExpression",
 			repr.NewField("Expression", expression),
 		)
+	case *yokast.PrefixExpr:
+		operator := encodeToken(node.Token, source)
+		expression := encodeNode(node.Expression, source)
+		return repr.NewObject(
+			"PrefixExpression",
+			repr.NewField("Operator", operator),
+			repr.NewField("Expression", expression),
+		)
 	case *yokast.If:
 		test := encodeNode(node.Test.(yokast.Node), source)
 		body := encodeNode(node.Body, source)
 		elseIfs := encodeElseIfs(node.ElseIfs, source)
 		elseBody := encodeNode(node.ElseBody, source)
-		return repr.NewObject(
+		ifStmt := repr.NewObject(
 			"IfStatement",
 			repr.NewField("Test", test),
 			repr.NewField("Body", body),
 			repr.NewField("ElseIfs", elseIfs),
 			repr.NewField("ElseBody", elseBody),
 		)
+		addTrailingComment(&ifStmt, node.Comment, source)
+		return ifStmt
 	case *yokast.Block:
 		if node == nil {
 			return repr.Nil{}
@@ -139,6 +232,15 @@ func encodeNode(node yokast.Node, source []byte) repr.Value {
 	}
 }
 
+// addTrailingComment adds the trailing comment to the encoded statement if the statement has one
+func addTrailingComment(object *repr.Object, comment *yokast.Comment, source []byte) {
+	if comment == nil {
+		return
+	}
+
+	object.AddFields(repr.NewField("Comment", encodeNode(comment, source)))
+}
+
 // encodeElseIfs encodes a slice of ElseIf nodes into a repr.Array
 func encodeElseIfs(elseIfs []yokast.ElseIf, source []byte) repr.Array {
 	array := repr.Array{}
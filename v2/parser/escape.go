@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bjatkin/yok/errors"
+)
+
+// unescape decodes all the escape sequences in the body of a yok string literal.
+// The supported escape sequences are:
+//
+//	\n       new line
+//	\t       tab
+//	\r       carriage return
+//	\"       double quote
+//	\\       backslash
+//	\$       dollar sign
+//	\u{...}  unicode code point written in hex (e.g. \u{1F600})
+func unescape(literal string) (string, error) {
+	if !strings.Contains(literal, "\\") {
+		return literal, nil
+	}
+
+	b := strings.Builder{}
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '\\' {
+			b.WriteByte(literal[i])
+			continue
+		}
+
+		i++
+		if i >= len(literal) {
+			return "", errors.New("string literal can not end with an unescaped '\\'")
+		}
+
+		switch literal[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\', '$':
+			b.WriteByte(literal[i])
+		case 'u':
+			r, n, err := unescapeUnicode(literal[i+1:])
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+			i += n
+		default:
+			return "", errors.New("unknown escape sequence in string literal: \\" + string(literal[i]))
+		}
+	}
+
+	return b.String(), nil
+}
+
+// unescapeUnicode decodes the {...} part of a unicode escape sequence.
+// It returns the decoded rune and the number of bytes that were consumed
+func unescapeUnicode(literal string) (rune, int, error) {
+	if !strings.HasPrefix(literal, "{") {
+		return 0, 0, errors.New("unicode escape sequence must use the form \\u{...}")
+	}
+
+	end := strings.Index(literal, "}")
+	if end < 0 {
+		return 0, 0, errors.New("unclosed unicode escape sequence: \\u" + literal)
+	}
+
+	hex := literal[1:end]
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) == 0 || len(hex) > 6 {
+		return 0, 0, errors.New("invalid unicode escape sequence: \\u{" + hex + "}")
+	}
+
+	r := rune(code)
+	if r == 0 || !utf8.ValidRune(r) {
+		return 0, 0, errors.New("unicode escape sequence is not a valid character: \\u{" + hex + "}")
+	}
+
+	return r, end + 1, nil
+}
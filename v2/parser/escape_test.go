@@ -0,0 +1,74 @@
+package parser
+
+import "testing"
+
+func Test_unescape(t *testing.T) {
+	tests := []struct {
+		name    string
+		literal string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "no escapes",
+			literal: "hello world",
+			want:    "hello world",
+		},
+		{
+			name:    "simple escapes",
+			literal: `a\tb\nc\r`,
+			want:    "a\tb\nc\r",
+		},
+		{
+			name:    "escaped quotes and backslashes",
+			literal: `say \"hi\" \\ \$HOME`,
+			want:    `say "hi" \ $HOME`,
+		},
+		{
+			name:    "unicode",
+			literal: `\u{48}i \u{1F600}`,
+			want:    "Hi \U0001F600",
+		},
+		{
+			name:    "unknown escape",
+			literal: `\q`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing backslash",
+			literal: `abc\`,
+			wantErr: true,
+		},
+		{
+			name:    "unclosed unicode",
+			literal: `\u{48`,
+			wantErr: true,
+		},
+		{
+			name:    "unicode without braces",
+			literal: `\u48`,
+			wantErr: true,
+		},
+		{
+			name:    "nul character",
+			literal: `\u{0}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid code point",
+			literal: `\u{D800}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unescape(tt.literal)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unescape() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("unescape() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
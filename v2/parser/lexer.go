@@ -36,21 +36,21 @@ func (l *lexer) peek() token.Token {
 func (l *lexer) take() token.Token {
 	currentToken := l.nextToken
 
+	for l.pos < len(l.source) && isWhitespace(l.source[l.pos]) {
+		l.pos++
+	}
+
 	// given there are no more tokens to consume, this is the end of the file
 	if len(l.source[l.pos:]) == 0 {
 		l.nextToken = token.NewToken(token.EOF, l.pos, 0)
 		return currentToken
 	}
 
-	for isWhitespace(l.source[l.pos]) {
-		l.pos++
-	}
-
 	// check for tokens that match a single byte
 	singleTok, foundSingle := matchSingleToken(l.source[l.pos], l.pos)
 
 	// check for tokens that match exactly two bytes
-	if len(l.source) >= 2 {
+	if len(l.source[l.pos:]) >= 2 {
 		tok, found := matchDoubleToken(l.source[l.pos:l.pos+2], l.pos)
 		if found {
 			l.nextToken = tok
@@ -156,6 +156,8 @@ func matchSingleToken(char byte, pos int) (token.Token, bool) {
 		t = token.OpenParen
 	case ')':
 		t = token.CloseParen
+	case '.':
+		t = token.Dot
 	case '\n':
 		t = token.NewLine
 	default:
@@ -281,6 +283,12 @@ func matchKeyword(identifier []byte, pos int) (token.Token, bool) {
 		t = token.IfKeyword
 	case "else":
 		t = token.ElseKeyword
+	case "env":
+		t = token.EnvKeyword
+	case "export":
+		t = token.ExportKeyword
+	case "const":
+		t = token.ConstKeyword
 	default:
 		return token.Token{}, false
 	}
@@ -289,8 +297,9 @@ func matchKeyword(identifier []byte, pos int) (token.Token, bool) {
 }
 
 // matchPatternLiteral returns a pattern literal token if one is found
-// it can also return an Invalid token if a pattern literal is started but contains
-// invalid characters or is
+// it can also return an Invalid token if a pattern literal is started but is not closed
+// before the end of the line. The pattern itself is validated by the parser so that it
+// can report targeted errors
 func matchPatternLiteral(chars []byte, pos int) (token.Token, bool) {
 	if chars[0] != '\'' {
 		return token.Token{}, false
@@ -298,35 +307,16 @@ func matchPatternLiteral(chars []byte, pos int) (token.Token, bool) {
 
 	i := 1
 	for ; i < len(chars); i++ {
-		// this byte is an upper or lower case letter or a number
-		if isAlpha(chars[i]) || isNumeric(chars[i]) {
-			continue
-		}
-
-		// these are the special characters supported by `sh` according to `man sh` on Ubuntu 24
-		if chars[i] == '!' ||
-			chars[i] == '*' ||
-			chars[i] == '?' ||
-			chars[i] == '[' ||
-			chars[i] == '-' ||
-			chars[i] == ']' {
-			continue
+		if chars[i] == '\n' || chars[i] == '\r' {
+			break
 		}
 
 		if chars[i] == '\'' {
-			// TODO: I could validate that pattern here and detect possible issues
-			// (e.g. unclosed [) is this the right place to do that or should I defer until the parser?
 			return token.NewToken(token.PatternLiteral, pos, i+1), true
 		}
-
-		break
 	}
 
-	// TODO: I could detect common issues here (e.g. it's a regex, not a pattern)
-	// is this the right place to do that or should I defer until the parser?
-
-	// invalid token, pattern was opened but was either not closed
-	// or contained an illegal token before being closed
+	// invalid token, pattern was opened but was not closed
 	return token.NewToken(token.Invalid, pos, i), true
 }
 
@@ -340,21 +330,23 @@ func matchStringLiteral(chars []byte, pos int) (token.Token, bool) {
 	i := 1
 	escape := false
 	for ; i < len(chars); i++ {
-		if chars[i] == '\\' {
-			escape = true
-			continue
-		}
-
-		if !escape && chars[i] == '"' {
-			return token.NewToken(token.StringLiteral, pos, i+1), true
+		if chars[i] == '\r' || chars[i] == '\n' {
+			break
 		}
 
+		// the escaped character is skipped so that '\\' does not escape the next character
 		if escape {
 			escape = false
+			continue
 		}
 
-		if chars[i] == '\r' || chars[i] == '\n' {
-			break
+		if chars[i] == '\\' {
+			escape = true
+			continue
+		}
+
+		if chars[i] == '"' {
+			return token.NewToken(token.StringLiteral, pos, i+1), true
 		}
 	}
 
@@ -370,20 +362,15 @@ func matchAtomLiteral(chars []byte, pos int) (token.Token, bool) {
 		return token.Token{}, false
 	}
 
+	// a ':' that is not followed by any atom characters is a colon (e.g. {CC: "clang"})
+	if len(chars) == 1 || !isAtomChar(chars[1]) {
+		return token.NewToken(token.Colon, pos, 1), true
+	}
+
 	i := 1
 	for ; i < len(chars); i++ {
 		char := chars[i]
-		// valid special characters that can show up in an atom. Most of these are supported
-		// so you can use atoms for basic file paths
-		if char == '/' ||
-			char == '.' ||
-			char == '_' ||
-			char == '-' {
-			continue
-		}
-
-		// this byte is an upper or lower case letter
-		if isAlpha(char) || isNumeric(char) {
+		if isAtomChar(char) {
 			continue
 		}
 
@@ -407,6 +394,21 @@ func matchAtomLiteral(chars []byte, pos int) (token.Token, bool) {
 	return token.NewToken(token.Atom, pos, i), true
 }
 
+// isAtomChar returns true if the character can be part of an atom
+func isAtomChar(char byte) bool {
+	// valid special characters that can show up in an atom. Most of these are supported
+	// so you can use atoms for basic file paths
+	if char == '/' ||
+		char == '.' ||
+		char == '_' ||
+		char == '-' {
+		return true
+	}
+
+	// this byte is an upper or lower case letter or a number
+	return isAlpha(char) || isNumeric(char)
+}
+
 // matchComment returns a comment token if one is found
 func matchComment(chars []byte, pos int) (token.Token, bool) {
 	if chars[0] != '#' {
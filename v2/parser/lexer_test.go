@@ -48,6 +48,15 @@ func Test_matchSingleToken(t *testing.T) {
 			want:   token.Token{Type: token.Pipe, Pos: 5, Len: 1},
 			wantOk: true,
 		},
+		{
+			name: "dot",
+			args: args{
+				char: '.',
+				pos:  3,
+			},
+			want:   token.Token{Type: token.Dot, Pos: 3, Len: 1},
+			wantOk: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -213,6 +222,24 @@ func Test_matchKeyword(t *testing.T) {
 			want:   token.Token{Type: token.SwitchKeyword, Pos: 23, Len: 6},
 			wantOk: true,
 		},
+		{
+			name: "const keyword",
+			args: args{
+				identifier: []byte("const"),
+				pos:        3,
+			},
+			want:   token.Token{Type: token.ConstKeyword, Pos: 3, Len: 5},
+			wantOk: true,
+		},
+		{
+			name: "env keyword",
+			args: args{
+				identifier: []byte("env"),
+				pos:        4,
+			},
+			want:   token.Token{Type: token.EnvKeyword, Pos: 4, Len: 3},
+			wantOk: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -274,6 +301,24 @@ func Test_matchPatternLiteral(t *testing.T) {
 			want:   token.Token{Type: token.PatternLiteral, Pos: 38, Len: 3},
 			wantOk: true,
 		},
+		{
+			name: "pattern with special characters",
+			args: args{
+				chars: []byte("'*.log' "),
+				pos:   4,
+			},
+			want:   token.Token{Type: token.PatternLiteral, Pos: 4, Len: 7},
+			wantOk: true,
+		},
+		{
+			name: "pattern ended by a new line",
+			args: args{
+				chars: []byte("'*.log\n'"),
+				pos:   0,
+			},
+			want:   token.Token{Type: token.Invalid, Pos: 0, Len: 6},
+			wantOk: true,
+		},
 		{
 			name: "valid empty pattern",
 			args: args{
@@ -344,6 +389,15 @@ func Test_matchStringLiteral(t *testing.T) {
 			want:   token.Token{Type: token.StringLiteral, Pos: 10, Len: 13},
 			wantOk: true,
 		},
+		{
+			name: "string literal ending with an escaped backslash",
+			args: args{
+				chars: []byte(`"C:\\" + "x"`),
+				pos:   2,
+			},
+			want:   token.Token{Type: token.StringLiteral, Pos: 2, Len: 6},
+			wantOk: true,
+		},
 		{
 			name: "string literal with escape chars",
 			args: args{
@@ -423,6 +477,24 @@ func Test_matchAtomLiteral(t *testing.T) {
 			want:   token.Token{Type: token.Atom, Pos: 10, Len: 13},
 			wantOk: true,
 		},
+		{
+			name: "colon",
+			args: args{
+				chars: []byte(": \"clang\""),
+				pos:   4,
+			},
+			want:   token.Token{Type: token.Colon, Pos: 4, Len: 1},
+			wantOk: true,
+		},
+		{
+			name: "colon before string",
+			args: args{
+				chars: []byte(":\"clang\""),
+				pos:   4,
+			},
+			want:   token.Token{Type: token.Colon, Pos: 4, Len: 1},
+			wantOk: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -516,6 +588,21 @@ func TestLexFile(t *testing.T) {
 			sourceFile: "nested_expressions.yok",
 			tokenFile:  "nested_expressions_tokens.txt",
 		},
+		{
+			name:       "names",
+			sourceFile: "names.yok",
+			tokenFile:  "names_tokens.txt",
+		},
+		{
+			name:       "env",
+			sourceFile: "env.yok",
+			tokenFile:  "env_tokens.txt",
+		},
+		{
+			name:       "patterns",
+			sourceFile: "patterns.yok",
+			tokenFile:  "patterns_tokens.txt",
+		},
 	}
 
 	for _, tt := range tests {
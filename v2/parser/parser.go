@@ -12,6 +12,7 @@ type precedence int
 const (
 	Unknown = precedence(iota)
 	Lowest
+	LogicalOr
 	Equals
 	LessOrGreater
 	Sum
@@ -22,6 +23,7 @@ const (
 
 // precedenceMap maps yok tokens to their precedence
 var precedenceMap = map[token.Type]precedence{
+	token.OrKeyword:    LogicalOr,
 	token.EqualEqual:   Equals,
 	token.NotEqual:     Equals,
 	token.LessThan:     LessOrGreater,
@@ -32,6 +34,7 @@ var precedenceMap = map[token.Type]precedence{
 	token.Minus:        Sum,
 	token.Divide:       Product,
 	token.Multiply:     Product,
+	token.Mod:          Product,
 	token.OpenParen:    Call,
 }
 
@@ -52,7 +55,7 @@ type Parser struct {
 
 // New creates a new parser for the given yok source code
 func New(source []byte) *Parser {
-	if source[len(source)-1] != '\n' {
+	if len(source) == 0 || source[len(source)-1] != '\n' {
 		// TODO: this is silly, we really don't need to support windows line endings for a language
 		// that transpiles to POSIX shell. We only need to do this because I'm currently developing
 		// primarily on windows. At some point I need to move over to
@@ -65,11 +68,14 @@ func New(source []byte) *Parser {
 	}
 
 	p.prefixParseFn = map[token.Type]prefixParseFn{
-		token.StringLiteral: p.parseStringLiteral,
-		token.Atom:          p.parseAtom,
-		token.Identifier:    p.parseIdentifier,
-		token.Minus:         p.parsePrefixExpr,
-		token.OpenParen:     p.parseGroupExpr,
+		token.StringLiteral:  p.parseStringLiteral,
+		token.Atom:           p.parseAtom,
+		token.PatternLiteral: p.parsePatternLiteral,
+		token.Identifier:     p.parseIdentifier,
+		token.Minus:          p.parsePrefixExpr,
+		token.OpenParen:      p.parseGroupExpr,
+		token.EnvKeyword:     p.parseEnvVar,
+		token.OpenBrace:      p.parseDict,
 	}
 
 	p.infixParseFn = map[token.Type]infixParseFn{
@@ -85,6 +91,7 @@ func New(source []byte) *Parser {
 		token.LessEqual:    p.parseInfix,
 		token.EqualEqual:   p.parseInfix,
 		token.NotEqual:     p.parseInfix,
+		token.OrKeyword:    p.parseInfix,
 	}
 
 	return p
@@ -151,14 +158,31 @@ func (p *Parser) parseStmt() yokast.Stmt {
 		}
 	case token.NewLine:
 		// we treat empty new lines as statements because they need to show up in the generated code
-		_ = p.take()
-		return &yokast.NewLine{}
-	case token.LetKeyword:
+		newLine := p.take()
+		return &yokast.NewLine{Pos: newLine.Pos}
+	case token.LetKeyword, token.ConstKeyword:
 		return p.parseAssignStmt()
+	case token.ExportKeyword:
+		return p.parseExportStmt()
 	case token.IfKeyword:
 		return p.parseIfStmt()
 	default:
+		pos := p.peek().Pos
 		expr := p.parseExpr(Lowest)
+		if identifier, ok := expr.(*yokast.Identifier); ok && p.peek().Type == token.Assign {
+			// env is only an identifier when it names the env argument of a call, it can't be assigned to
+			if p.getValue(identifier.Token) == "env" {
+				p.Errors = append(p.Errors, errors.New("env can not be assigned, use env.NAME = value to set an environment variable"))
+				return nil
+			}
+
+			return p.parseReassignStmt(pos, identifier)
+		}
+		if envVar, ok := expr.(*yokast.EnvVar); ok && p.peek().Type == token.Assign {
+			return p.parseEnvAssignStmt(pos, envVar)
+		}
+
+		comment := p.parseTrailingComment()
 
 		// All statements must end with a new line
 		if p.peek().Type != token.NewLine {
@@ -168,42 +192,138 @@ func (p *Parser) parseStmt() yokast.Stmt {
 
 		_ = p.take()
 		return &yokast.StmtExpr{
+			Pos:        pos,
 			Expression: expr,
+			Comment:    comment,
 		}
 	}
 }
 
-// parseAssignStmt parses a yok let statement
+// parseAssignStmt parses a yok let or const statement
 // Examples:
 //
 //	let a = 10
 //	let b = myFunc()
 //	let c = 10 * 20
+//	const d = :10 * :20
+//	let e int = :20
+//	let f str
 func (p *Parser) parseAssignStmt() *yokast.Assign {
-	// discard the 'let' token
-	_ = p.take()
+	// discard the 'let' or 'const' token
+	let := p.take()
+	keyword := p.getValue(let)
 
 	ident := p.take()
 
-	if p.peek().Type != token.Assign {
-		p.Errors = append(p.Errors, errors.New("let statement must include an '=' after the identifier"))
+	var typeName *yokast.Identifier
+	if p.peek().Type == token.Identifier {
+		typeName = &yokast.Identifier{Token: p.take()}
+	}
+
+	// typed let statements can leave out the value to use the zero value of the type
+	var value yokast.Expr
+	switch {
+	case p.peek().Type == token.Assign:
+		// discard the '=' token
+		_ = p.take()
+		value = p.parseExpr(Lowest)
+	case typeName == nil || let.Type != token.LetKeyword:
+		p.Errors = append(p.Errors, errors.New(keyword+" statement must include an '=' after the identifier"))
+		return nil
+	}
+
+	comment := p.parseTrailingComment()
+
+	if p.peek().Type != token.NewLine {
+		p.Errors = append(p.Errors, errors.New(keyword+" statement must end with a new line"))
+		return nil
+	}
+	// discard the new line
+	_ = p.take()
+
+	return &yokast.Assign{
+		Pos:        let.Pos,
+		Identifier: &yokast.Identifier{Token: ident},
+		Value:      value,
+		Comment:    comment,
+		Const:      let.Type == token.ConstKeyword,
+		Type:       typeName,
+	}
+}
+
+// parseExportStmt parses a yok export let statement
+// Examples:
+//
+//	export let port = :8080
+func (p *Parser) parseExportStmt() *yokast.Assign {
+	// discard the 'export' token
+	export := p.take()
+
+	if p.peek().Type != token.LetKeyword {
+		p.Errors = append(p.Errors, errors.New("export must be followed by a let statement"))
 		return nil
 	}
+
+	assign := p.parseAssignStmt()
+	if assign == nil {
+		return nil
+	}
+
+	assign.Pos = export.Pos
+	assign.Export = true
+	return assign
+}
+
+// parseReassignStmt parses a yok reassignment statement, the identifier has already been parsed
+// Examples:
+//
+//	a = 20
+//	b = a + 1
+func (p *Parser) parseReassignStmt(pos token.Pos, identifier *yokast.Identifier) *yokast.Reassign {
 	// discard the '=' token
 	_ = p.take()
 
 	value := p.parseExpr(Lowest)
+	comment := p.parseTrailingComment()
 
 	if p.peek().Type != token.NewLine {
-		p.Errors = append(p.Errors, errors.New("let statement must end with a new line"))
+		p.Errors = append(p.Errors, errors.New("assignment must end with a new line"))
 		return nil
 	}
 	// discard the new line
 	_ = p.take()
 
-	return &yokast.Assign{
-		Identifier: &yokast.Identifier{Token: ident},
+	return &yokast.Reassign{
+		Pos:        pos,
+		Identifier: identifier,
 		Value:      value,
+		Comment:    comment,
+	}
+}
+
+// parseEnvAssignStmt parses an assignment to an environment variable, the variable has already been parsed
+// Examples:
+//
+//	env.PATH = "/usr/local/bin"
+func (p *Parser) parseEnvAssignStmt(pos token.Pos, envVar *yokast.EnvVar) *yokast.EnvAssign {
+	// discard the '=' token
+	_ = p.take()
+
+	value := p.parseExpr(Lowest)
+	comment := p.parseTrailingComment()
+
+	if p.peek().Type != token.NewLine {
+		p.Errors = append(p.Errors, errors.New("assignment must end with a new line"))
+		return nil
+	}
+	// discard the new line
+	_ = p.take()
+
+	return &yokast.EnvAssign{
+		Pos:      pos,
+		Variable: envVar,
+		Value:    value,
+		Comment:  comment,
 	}
 }
 
@@ -213,7 +333,7 @@ func (p *Parser) parseAssignStmt() *yokast.Assign {
 // if a > 10 { ... }
 func (p *Parser) parseIfStmt() *yokast.If {
 	// discard the 'if' token
-	_ = p.take()
+	ifToken := p.take()
 
 	test := p.parseExpr(Lowest)
 	body := p.parseBlock()
@@ -229,6 +349,8 @@ func (p *Parser) parseIfStmt() *yokast.If {
 		elseIfs = append(elseIfs, *elseIf)
 	}
 
+	comment := p.parseTrailingComment()
+
 	// ensure the final token is a new line or we have some random syntax to deal with...
 	if p.peek().Type != token.NewLine {
 		p.Errors = append(p.Errors, errors.New("if body must end with '}' on it's own line"))
@@ -239,10 +361,27 @@ func (p *Parser) parseIfStmt() *yokast.If {
 	_ = p.take()
 
 	return &yokast.If{
+		Pos:      ifToken.Pos,
 		Test:     test,
 		Body:     body,
 		ElseIfs:  elseIfs,
 		ElseBody: elseBody,
+		Comment:  comment,
+	}
+}
+
+// parseTrailingComment parses an optional comment at the end of a statement
+//
+// Example:
+//
+//	let a = :10 # this is a trailing comment
+func (p *Parser) parseTrailingComment() *yokast.Comment {
+	if p.peek().Type != token.Comment {
+		return nil
+	}
+
+	return &yokast.Comment{
+		Token: p.take(),
 	}
 }
 
@@ -251,7 +390,7 @@ func (p *Parser) parseElseIf() (*yokast.ElseIf, *yokast.Block) {
 	if p.peek().Type != token.ElseKeyword {
 		return nil, nil
 	}
-	_ = p.take()
+	elseToken := p.take()
 
 	// check if this is just the final `else` block
 	if p.peek().Type != token.IfKeyword {
@@ -264,6 +403,7 @@ func (p *Parser) parseElseIf() (*yokast.ElseIf, *yokast.Block) {
 	test := p.parseExpr(Lowest)
 	body := p.parseBlock()
 	return &yokast.ElseIf{
+		Pos:  elseToken.Pos,
 		Test: test,
 		Body: body,
 	}, nil
@@ -297,6 +437,10 @@ func (p *Parser) parseBlock() *yokast.Block {
 
 		stmt := p.parseStmt()
 		if stmt == nil {
+			// consume a token to get back on track, but leave the closing '}' so the block can still end
+			if p.peek().Type != token.CloseBrace {
+				_ = p.take()
+			}
 			continue
 		}
 
@@ -328,7 +472,8 @@ func (p *Parser) parseExpr(leftPrecedence precedence) yokast.Expr {
 			break
 		}
 
-		if leftPrecedence > tokenPrecedence(p.peek()) {
+		// operators with the same precedence are left associative (e.g. a - b - c is (a - b) - c)
+		if leftPrecedence >= tokenPrecedence(p.peek()) {
 			break
 		}
 
@@ -338,6 +483,10 @@ func (p *Parser) parseExpr(leftPrecedence precedence) yokast.Expr {
 		}
 
 		left = infix(left)
+		if left == nil {
+			// the infix function failed without consuming its token, stop here so we don't loop forever
+			break
+		}
 	}
 
 	return left
@@ -362,9 +511,36 @@ func (p *Parser) parseStringLiteral() yokast.Expr {
 		panic("token is not a string literal: " + p.getValue(p.peek()))
 	}
 
-	return &yokast.String{
-		Token: p.take(),
+	literal := p.take()
+	value := p.getValue(literal)
+	decoded, err := unescape(value[1 : len(value)-1])
+	if err != nil {
+		p.Errors = append(p.Errors, err)
+		return nil
 	}
+
+	return yokast.NewString(decoded, literal)
+}
+
+// parsePatternLiteral parses a glob pattern literal in yok, the pattern is validated so that common
+// mistakes (e.g. using a regex instead of a glob) are reported with a targeted error
+//
+// Example:
+//
+//	'*.log'
+//	'[a-z]?'
+func (p *Parser) parsePatternLiteral() yokast.Expr {
+	if p.peek().Type != token.PatternLiteral {
+		panic("token is not a pattern literal: " + p.getValue(p.peek()))
+	}
+
+	pattern := &yokast.Pattern{Token: p.take()}
+	if err := validatePattern(pattern.Value(p.lexer.source), pattern.Token.Pos+1); err != nil {
+		p.Errors = append(p.Errors, err)
+		return nil
+	}
+
+	return pattern
 }
 
 // parseAtom parses an atom in yok
@@ -386,8 +562,149 @@ func (p *Parser) parseAtom() yokast.Expr {
 // parsePrefixExpr parses prefix yok expressions
 func (p *Parser) parsePrefixExpr() yokast.Expr {
 	return &yokast.PrefixExpr{
-		Token: p.take(),
-		Expr:  p.parseExpr(Prefix),
+		Token:      p.take(),
+		Expression: p.parseExpr(Prefix),
+	}
+}
+
+// parseEnvVar parses an explicit environment variable
+//
+// Examples:
+//
+//	env.PATH
+//	env.HOME
+func (p *Parser) parseEnvVar() yokast.Expr {
+	// take the 'env' keyword
+	env := p.take()
+
+	if p.peek().Type == token.OpenParen {
+		return p.parseEnvCall(env)
+	}
+
+	// env is also the name of the named argument used to set the environment for a call (e.g. make(env={...})),
+	// so it's parsed as the identifier for the name of the argument
+	if p.peek().Type == token.Assign {
+		return &yokast.Identifier{Token: token.NewToken(token.Identifier, int(env.Pos), env.Len)}
+	}
+
+	if p.peek().Type != token.Dot {
+		p.Errors = append(p.Errors, errors.New("env must be followed by '.' and the name of the environment variable"))
+		return nil
+	}
+	// discard the '.' token
+	_ = p.take()
+
+	if p.peek().Type != token.Identifier {
+		p.Errors = append(p.Errors, errors.New("invalid environment variable name: "+p.getValue(p.peek())))
+		return nil
+	}
+
+	return &yokast.EnvVar{
+		Token: env,
+		Name:  p.take(),
+	}
+}
+
+// parseEnvCall parses the function form of an environment variable, the 'env' token has already been taken
+// Examples:
+//
+//	env("HOME")
+func (p *Parser) parseEnvCall(env token.Token) yokast.Expr {
+	// take the initial '('
+	_ = p.take()
+
+	name := p.take()
+	if name.Type != token.StringLiteral {
+		p.Errors = append(p.Errors, errors.New("env() takes the name of the environment variable as a string: "+p.getValue(name)))
+		return nil
+	}
+
+	envVar := &yokast.EnvVar{Token: env, Name: name}
+	if !isEnvName(envVar.VarName(p.lexer.source)) {
+		p.Errors = append(p.Errors, errors.New("invalid environment variable name: "+p.getValue(name)))
+		return nil
+	}
+
+	if p.peek().Type != token.CloseParen {
+		p.Errors = append(p.Errors, errors.New("env() takes a single argument"))
+		return nil
+	}
+	// take the final ')'
+	_ = p.take()
+
+	return envVar
+}
+
+// isEnvName returns true if the name is a valid sh variable name
+func isEnvName(name string) bool {
+	if name == "" || !(isAlpha(name[0]) || name[0] == '_') {
+		return false
+	}
+
+	for i := 1; i < len(name); i++ {
+		if !isAlpha(name[i]) && !isNumeric(name[i]) && name[i] != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseDict parses a dictionary literal
+// Examples:
+//
+//	{CC: "clang", CFLAGS: "-O2"}
+func (p *Parser) parseDict() yokast.Expr {
+	// take the initial '{'
+	open := p.take()
+
+	entries := []yokast.DictEntry{}
+	for p.peek().Type != token.CloseBrace {
+		if p.peek().Type == token.NewLine {
+			_ = p.take()
+			continue
+		}
+
+		key := p.take()
+		if key.Type != token.Identifier {
+			p.Errors = append(p.Errors, errors.New("invalid dict key: "+p.getValue(key)))
+			return nil
+		}
+
+		if p.peek().Type != token.Colon {
+			p.Errors = append(p.Errors, errors.New("dict key must be followed by ':' "+p.getValue(p.peek())))
+			return nil
+		}
+		// discard the ':' token
+		_ = p.take()
+
+		value := p.parseExpr(Lowest)
+		if value == nil {
+			return nil
+		}
+		entries = append(entries, yokast.DictEntry{Key: key, Value: value})
+
+		if p.peek().Type == token.Comma {
+			_ = p.take()
+			continue
+		}
+
+		if p.peek().Type == token.NewLine {
+			continue
+		}
+
+		if p.peek().Type != token.CloseBrace {
+			p.Errors = append(p.Errors, errors.New("unclosed dict: "+p.getValue(p.peek())))
+			return nil
+		}
+	}
+
+	// take the final '}'
+	_ = p.take()
+
+	return &yokast.Dict{
+		Token:   open,
+		Entries: entries,
 	}
 }
 
@@ -436,6 +753,7 @@ func (p *Parser) parseCall(ident yokast.Expr) yokast.Expr {
 	_ = p.take()
 
 	args := []yokast.Expr{}
+	namedArgs := []yokast.NamedArg{}
 	for p.peek().Type != token.EOF {
 		// just skip new lines when parsing arguments
 		if p.peek().Type == token.NewLine {
@@ -449,7 +767,22 @@ func (p *Parser) parseCall(ident yokast.Expr) yokast.Expr {
 			return nil
 		}
 
-		args = append(args, expr)
+		if name, ok := expr.(*yokast.Identifier); ok && p.peek().Type == token.Assign {
+			// discard the '=' token
+			_ = p.take()
+
+			value := p.parseExpr(Lowest)
+			if value == nil {
+				return nil
+			}
+
+			namedArgs = append(namedArgs, yokast.NamedArg{Name: name, Value: value})
+		} else if len(namedArgs) > 0 {
+			p.Errors = append(p.Errors, errors.New("positional arguments must come before named arguments"))
+			return nil
+		} else {
+			args = append(args, expr)
+		}
 
 		expectNextArg := false
 		if p.peek().Type == token.Comma {
@@ -479,8 +812,9 @@ func (p *Parser) parseCall(ident yokast.Expr) yokast.Expr {
 	}
 
 	return &yokast.Call{
-		Identifier: identifier,
-		Arguments:  args,
+		Identifier:     identifier,
+		Arguments:      args,
+		NamedArguments: namedArgs,
 	}
 }
 
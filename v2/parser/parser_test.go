@@ -5,10 +5,16 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/bjatkin/yok/ast/yokast"
 	"github.com/bjatkin/yok/diff"
 )
 
+func TestMain(m *testing.M) {
+	os.Exit(diff.RunWithOrphanCheck(m, "testdata", "*.txt"))
+}
+
 func TestParser_Parse(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -45,6 +51,36 @@ func TestParser_Parse(t *testing.T) {
 			sourceFile: "nested_expressions.yok",
 			astFile:    "nested_expressions_ast.txt",
 		},
+		{
+			name:       "names",
+			sourceFile: "names.yok",
+			astFile:    "names_ast.txt",
+		},
+		{
+			name:       "env",
+			sourceFile: "env.yok",
+			astFile:    "env_ast.txt",
+		},
+		{
+			name:       "patterns",
+			sourceFile: "patterns.yok",
+			astFile:    "patterns_ast.txt",
+		},
+		{
+			name:       "consts",
+			sourceFile: "consts.yok",
+			astFile:    "consts_ast.txt",
+		},
+		{
+			name:       "types",
+			sourceFile: "types.yok",
+			astFile:    "types_ast.txt",
+		},
+		{
+			name:       "strict",
+			sourceFile: "strict.yok",
+			astFile:    "strict_ast.txt",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -71,3 +107,125 @@ func TestParser_Parse(t *testing.T) {
 		})
 	}
 }
+
+// groupInfix writes the expression with each infix expression wrapped in parentheses so the grouping is visible
+func groupInfix(expr yokast.Expr, source []byte) string {
+	switch e := expr.(type) {
+	case *yokast.InfixExpr:
+		return "(" + groupInfix(e.Left, source) + " " + e.Operator.Value(source) + " " + groupInfix(e.Right, source) + ")"
+	case *yokast.Identifier:
+		return e.Token.Value(source)
+	default:
+		return fmt.Sprintf("%T", expr)
+	}
+}
+
+func TestParser_Parse_Precedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "subtraction is left associative",
+			source: "let x = a - b - c\n",
+			want:   "((a - b) - c)",
+		},
+		{
+			name:   "division is left associative",
+			source: "let x = a / b / c\n",
+			want:   "((a / b) / c)",
+		},
+		{
+			name:   "modulo binds tighter than addition",
+			source: "let x = a + b % c\n",
+			want:   "(a + (b % c))",
+		},
+		{
+			name:   "modulo is left associative with multiplication",
+			source: "let x = a % b * c\n",
+			want:   "((a % b) * c)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := []byte(tt.source)
+			parser := New(source)
+			script, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Parser.Parse() error = %v %v", err, parser.Errors)
+			}
+
+			assign, ok := script.Statements[0].(*yokast.Assign)
+			if !ok {
+				t.Fatalf("Parser.Parse() statement = %T, want *yokast.Assign", script.Statements[0])
+			}
+
+			if got := groupInfix(assign.Value, source); got != tt.want {
+				t.Errorf("Parser.Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_Parse_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		wantFirst string
+	}{
+		{
+			name:      "unsupported literal in an if test",
+			source:    "if 10 < 100 {\n\tprint(\"all is well\")\n}\n",
+			wantFirst: "missing prefix function for token: 10",
+		},
+		{
+			name:      "unsupported statement in a block",
+			source:    "if a {\n\tfor i in range(:0, :10) {\n\t\tprint(i)\n\t}\n}\n",
+			wantFirst: "missing prefix function for token: for",
+		},
+		{
+			name:      "unclosed group",
+			source:    "let a = (:1 +\n",
+			wantFirst: "missing prefix function for token: \n",
+		},
+		{
+			name:      "trailing comma in a call",
+			source:    "print(:a, )\n",
+			wantFirst: "missing prefix function for token: )",
+		},
+		{
+			name:      "assign to env",
+			source:    "env = :x\n",
+			wantFirst: "env can not be assigned, use env.NAME = value to set an environment variable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := New([]byte(tt.source))
+
+			// the parser must recover from the error and stop, not loop on the same token
+			done := make(chan error)
+			go func() {
+				_, err := parser.Parse()
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				if err == nil {
+					t.Fatalf("Parser.Parse() expected an error parsing %q", tt.source)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Parser.Parse() did not finish parsing %q", tt.source)
+			}
+
+			if len(parser.Errors) == 0 {
+				t.Fatalf("Parser.Errors is empty, want %q", tt.wantFirst)
+			}
+			if got := parser.Errors[0].Error(); got != tt.wantFirst {
+				t.Errorf("Parser.Errors[0] = %q, want %q", got, tt.wantFirst)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/token"
+)
+
+// bracketUnsafeChars are characters that can not be used inside a bracket expression because
+// they would need to be escaped in the generated sh code
+const bracketUnsafeChars = "\"$`\\}"
+
+// validatePattern checks that a glob pattern is valid and reports common mistakes like
+// using regex syntax in a pattern. pos is the position of the first character of the pattern
+func validatePattern(pattern string, pos token.Pos) error {
+	for i := 0; i < len(pattern); i++ {
+		char := pattern[i]
+		switch char {
+		case '[':
+			end, err := validateBracket(pattern, i, pos)
+			if err != nil {
+				return err
+			}
+			i = end
+		case '\\':
+			msg := "backslash escapes are not supported in patterns, use a bracket expression like '[*]' to match a special character"
+			return errors.NewPos(pos+token.Pos(i), msg)
+		case '+', '|', '(', ')':
+			msg := fmt.Sprintf("'%c' is a regex operator and is not supported in patterns, use '*' to match any characters", char)
+			return errors.NewPos(pos+token.Pos(i), msg)
+		case '^':
+			return errors.NewPos(pos+token.Pos(i), "'^' is a regex anchor, patterns always match the whole string")
+		case '$':
+			if i == len(pattern)-1 {
+				return errors.NewPos(pos+token.Pos(i), "'$' is a regex anchor, patterns always match the whole string")
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateBracket validates the bracket expression that starts at the given index
+// and returns the index of the closing ']'
+func validateBracket(pattern string, start int, pos token.Pos) (int, error) {
+	i := start + 1
+	if strings.HasPrefix(pattern[i:], "^") {
+		return 0, errors.NewPos(pos+token.Pos(i), "use '[!...]' rather than '[^...]' to negate a bracket expression in a pattern")
+	}
+
+	if strings.HasPrefix(pattern[i:], "!") {
+		i++
+	}
+
+	// a ']' at the start of the bracket expression is matched literally
+	if strings.HasPrefix(pattern[i:], "]") {
+		i++
+	}
+
+	for ; i < len(pattern); i++ {
+		switch {
+		case pattern[i] == ']':
+			return i, nil
+		case strings.HasPrefix(pattern[i:], "[:"):
+			// character classes like [:alpha:] are part of the bracket expression
+			end := strings.Index(pattern[i+2:], ":]")
+			if end < 0 {
+				return 0, errors.NewPos(pos+token.Pos(i), "unclosed character class in pattern")
+			}
+			i += end + 3
+		case strings.IndexByte(bracketUnsafeChars, pattern[i]) >= 0:
+			msg := fmt.Sprintf("'%c' can not be used in a bracket expression", pattern[i])
+			return 0, errors.NewPos(pos+token.Pos(i), msg)
+		}
+	}
+
+	return 0, errors.NewPos(pos+token.Pos(start), "unclosed '[' in pattern")
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bjatkin/yok/errors"
+)
+
+func Test_validatePattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr string
+	}{
+		{
+			name:    "glob",
+			pattern: "*.log",
+		},
+		{
+			name:    "bracket expressions",
+			pattern: "[a-z]?[!0-9][]x][[:space:]]",
+		},
+		{
+			name:    "dollar sign in the middle",
+			pattern: "$*",
+		},
+		{
+			name:    "unclosed bracket",
+			pattern: "ab[cd",
+			wantErr: "test.yok:1:4: unclosed '[' in pattern",
+		},
+		{
+			name:    "bracket with only a close bracket",
+			pattern: "[]",
+			wantErr: "test.yok:1:2: unclosed '[' in pattern",
+		},
+		{
+			name:    "unclosed character class",
+			pattern: "[[:alpha]",
+			wantErr: "test.yok:1:3: unclosed character class in pattern",
+		},
+		{
+			name:    "regex plus",
+			pattern: "[0-9]+",
+			wantErr: "test.yok:1:7: '+' is a regex operator and is not supported in patterns, use '*' to match any characters",
+		},
+		{
+			name:    "regex alternative",
+			pattern: "a|b",
+			wantErr: "test.yok:1:3: '|' is a regex operator and is not supported in patterns, use '*' to match any characters",
+		},
+		{
+			name:    "regex anchors",
+			pattern: "^abc",
+			wantErr: "test.yok:1:2: '^' is a regex anchor, patterns always match the whole string",
+		},
+		{
+			name:    "regex end anchor",
+			pattern: "abc$",
+			wantErr: "test.yok:1:5: '$' is a regex anchor, patterns always match the whole string",
+		},
+		{
+			name:    "regex negated bracket",
+			pattern: "[^a]",
+			wantErr: "test.yok:1:3: use '[!...]' rather than '[^...]' to negate a bracket expression in a pattern",
+		},
+		{
+			name:    "backslash",
+			pattern: `\*`,
+			wantErr: "test.yok:1:2: backslash escapes are not supported in patterns, use a bracket expression like '[*]' to match a special character",
+		},
+		{
+			name:    "unsafe bracket character",
+			pattern: "[$]",
+			wantErr: "test.yok:1:3: '$' can not be used in a bracket expression",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the pattern starts after the opening quote
+			source := []byte("'" + tt.pattern + "'")
+			err := validatePattern(tt.pattern, 1)
+
+			got := ""
+			if err != nil {
+				got = errors.Format(err, "test.yok", source)
+			}
+			if got != tt.wantErr {
+				t.Errorf("validatePattern() error = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
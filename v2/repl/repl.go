@@ -0,0 +1,171 @@
+// Package repl implements an interactive read eval print loop for yok. Each input is compiled to sh
+// and run in a long running shell so variables are kept between inputs
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bjatkin/yok/compiler"
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/parser"
+)
+
+const (
+	// prompt is shown when the REPL is waiting for a new input
+	prompt = "yok> "
+	// continuePrompt is shown when the input is not complete yet (e.g. a block was not closed)
+	continuePrompt = "...> "
+	// replFile is the file name used in error messages
+	replFile = "<repl>"
+)
+
+// help is printed by the :help command
+const help = `:sh    toggle printing the generated sh code before it's run
+:ast   toggle printing the yok and sh ASTs of each input
+:help  print this help message
+:quit  exit the REPL
+`
+
+// REPL reads yok code, compiles it and runs it in a shell
+type REPL struct {
+	in      *bufio.Scanner
+	out     io.Writer
+	session *Session
+	shell   *Shell
+
+	// showSh prints the generated sh code before it's run
+	showSh bool
+	// showAst prints the yok and sh ASTs of each input
+	showAst bool
+}
+
+// New creates a new REPL that reads input from in and runs it with the shell.
+// Prompts, errors and the output of the :sh and :ast commands are written to out
+func New(in io.Reader, out io.Writer, shell *Shell) *REPL {
+	return &REPL{
+		in:      bufio.NewScanner(in),
+		out:     out,
+		session: NewSession(),
+		shell:   shell,
+	}
+}
+
+// Run runs the REPL until the input is closed or the :quit command is entered
+func (r *REPL) Run() error {
+	for {
+		input, ok := r.readInput()
+		if !ok {
+			fmt.Fprintln(r.out)
+			return r.in.Err()
+		}
+
+		switch strings.TrimSpace(input) {
+		case "":
+			continue
+		case ":quit", ":q":
+			return nil
+		case ":help":
+			fmt.Fprint(r.out, help)
+			continue
+		case ":sh":
+			r.showSh = !r.showSh
+			fmt.Fprintln(r.out, "show sh:", r.showSh)
+			continue
+		case ":ast":
+			r.showAst = !r.showAst
+			fmt.Fprintln(r.out, "show ast:", r.showAst)
+			continue
+		}
+
+		err := r.eval(input)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readInput reads lines until the input is complete. It returns false if the input was closed
+func (r *REPL) readInput() (string, bool) {
+	fmt.Fprint(r.out, prompt)
+
+	lines := []string{}
+	for r.in.Scan() {
+		lines = append(lines, r.in.Text())
+		input := strings.Join(lines, "\n")
+		if !isIncomplete(input) {
+			return input, true
+		}
+
+		fmt.Fprint(r.out, continuePrompt)
+	}
+
+	if len(lines) > 0 {
+		return strings.Join(lines, "\n"), true
+	}
+
+	return "", false
+}
+
+// eval compiles the input and runs it. Compile errors are printed and the input is discarded,
+// an error is only returned if the shell could not run the code
+func (r *REPL) eval(input string) error {
+	chunk, err := r.session.Compile(input)
+	if err != nil {
+		for _, e := range r.session.Errors {
+			fmt.Fprintln(r.out, errors.Format(e, replFile, []byte(input)))
+		}
+		if len(r.session.Errors) == 0 {
+			fmt.Fprintln(r.out, err)
+		}
+		return nil
+	}
+
+	if r.showAst {
+		fmt.Fprintln(r.out, "yok ast:")
+		fmt.Fprintln(r.out, parser.Encode(chunk.Yok, []byte(chunk.Input)))
+		fmt.Fprintln(r.out, "sh ast:")
+		fmt.Fprintln(r.out, compiler.Encode(chunk.Sh))
+	}
+	if r.showSh {
+		fmt.Fprintln(r.out, chunk.Code)
+	}
+
+	r.session.Commit(chunk)
+	return r.shell.Run(chunk.Code)
+}
+
+// isIncomplete returns true if the input has more open braces or parens than closing ones.
+// Brackets inside strings, patterns and comments are ignored
+func isIncomplete(input string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(input); i++ {
+		char := input[i]
+		switch {
+		case quote != 0 && char == '\\':
+			// skip the escaped character
+			i++
+		case quote != 0 && char == quote:
+			quote = 0
+		case quote != 0:
+			// brackets inside strings are ignored
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '#':
+			end := strings.IndexByte(input[i:], '\n')
+			if end < 0 {
+				return depth > 0
+			}
+			i += end
+		case char == '{' || char == '(':
+			depth++
+		case char == '}' || char == ')':
+			depth--
+		}
+	}
+
+	return depth > 0
+}
@@ -0,0 +1,76 @@
+package repl
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestREPL_Run(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not available", err)
+	}
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	shell, err := StartShell(sh, &stdout, &stderr)
+	if err != nil {
+		t.Fatal("failed to start the shell", err)
+	}
+	defer shell.Close()
+
+	input := strings.Join([]string{
+		"let name = :yok",
+		"if len(name) == :3 {",
+		`    print("hello", name)`,
+		"}",
+		"print(missing)",
+		":sh",
+		"print(upper(name))",
+		":quit",
+		"print(:unreachable)",
+	}, "\n")
+
+	out := bytes.Buffer{}
+	err = New(strings.NewReader(input), &out, shell).Run()
+	if err != nil {
+		t.Fatalf("REPL.Run() error = %v", err)
+	}
+
+	wantStdout := "hello yok\nYOK\n"
+	if stdout.String() != wantStdout {
+		t.Errorf("REPL.Run() stdout = %q, want %q", stdout.String(), wantStdout)
+	}
+
+	wantOut := []string{"<repl>:1:7: 'missing' is not declared", "show sh: true", `printf '%s\n' "$(_yok_upper "$YOK_NAME")"`}
+	for _, want := range wantOut {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("REPL.Run() output = %q, want it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func Test_isIncomplete(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "statement", input: "print(:hi)", want: false},
+		{name: "open block", input: "if a == :1 {", want: true},
+		{name: "open call", input: "print(\n:hi,", want: true},
+		{name: "closed block", input: "if a == :1 {\n\tprint(a)\n}", want: false},
+		{name: "brace in a string", input: `print("{")`, want: false},
+		{name: "escaped quote", input: `print("\"{")`, want: false},
+		{name: "brace in a comment", input: "print(:hi) # {", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isIncomplete(tt.input); got != tt.want {
+				t.Errorf("isIncomplete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package repl
+
+import (
+	"strings"
+
+	"github.com/bjatkin/yok/ast/shast"
+	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/codegen/gensh"
+	"github.com/bjatkin/yok/codegen/target"
+	"github.com/bjatkin/yok/compiler"
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/parser"
+	"github.com/bjatkin/yok/token"
+)
+
+// Session compiles yok inputs one at a time. Every input is compiled along with all the inputs that came before it,
+// so variables declared by earlier inputs can be used, but only the sh code for the new input is returned
+type Session struct {
+	// Errors are the errors from the last call to Compile, positions are relative to the start of the input
+	Errors []error
+
+	// history is the source code of all the committed inputs
+	history []byte
+	// functions are the names of the functions that have already been committed
+	functions map[string]bool
+}
+
+// NewSession creates a new empty session
+func NewSession() *Session {
+	return &Session{
+		functions: map[string]bool{},
+	}
+}
+
+// Chunk is a single compiled input
+type Chunk struct {
+	// Yok is the yok AST of the input, positions are relative to the start of the input
+	Yok *yokast.Script
+	// Sh contains the sh statements that were compiled from the input, along with any new helper functions
+	Sh *shast.Script
+	// Code is the generated sh code for Sh, it does not include a shebang
+	Code string
+	// Input is the yok source code of the input, it always ends with a new line
+	Input string
+
+	functions []string
+}
+
+// Compile compiles the input. If there are errors they're added to Session.Errors.
+// The input is not added to the session until the chunk is committed
+func (s *Session) Compile(input string) (*Chunk, error) {
+	s.Errors = nil
+	if !strings.HasSuffix(input, "\n") {
+		input += "\n"
+	}
+
+	p := parser.New([]byte(input))
+	yokAst, err := p.Parse()
+	if err != nil {
+		s.Errors = p.Errors
+		return nil, err
+	}
+
+	// the history is compiled again so the names and symbols of earlier inputs are the same as before
+	offset := token.Pos(len(s.history))
+	source := append([]byte(string(s.history)), input...)
+	script, err := parser.New(source).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	c := compiler.New(source)
+	shAst, err := c.Compile(script)
+	if err != nil {
+		for _, e := range c.Errors() {
+			s.Errors = append(s.Errors, relativeError(e, offset))
+		}
+		return nil, err
+	}
+
+	chunk := &Chunk{Yok: yokAst, Sh: &shast.Script{}, Input: input}
+	for _, stmt := range shAst.Statements {
+		if function, ok := stmt.(*shast.Function); ok {
+			if !s.functions[function.Name] {
+				chunk.Sh.Statements = append(chunk.Sh.Statements, function)
+				chunk.functions = append(chunk.functions, function.Name)
+			}
+			continue
+		}
+
+		pos, ok := stmtPos(stmt)
+		if ok && pos >= offset {
+			chunk.Sh.Statements = append(chunk.Sh.Statements, stmt)
+		}
+	}
+
+	code := gensh.Generate(chunk.Sh)
+	chunk.Code = strings.TrimLeft(strings.TrimPrefix(code, target.POSIX.Shebang()), "\n")
+	return chunk, nil
+}
+
+// Commit adds the chunk to the session so later inputs can use it
+func (s *Session) Commit(chunk *Chunk) {
+	s.history = append(s.history, chunk.Input...)
+	for _, name := range chunk.functions {
+		s.functions[name] = true
+	}
+}
+
+// stmtPos returns the position of the yok code that the statement was compiled from. Helper functions, the new lines
+// that separate them and statements added by the compiler were not compiled from yok code so they have no position
+func stmtPos(stmt shast.Stmt) (token.Pos, bool) {
+	switch s := stmt.(type) {
+	case *shast.Comment:
+		return s.Pos, true
+	case *shast.NewLine:
+		return s.Pos, s.Pos > 0
+	case *shast.Assign:
+		return s.Pos, true
+	case *shast.If:
+		return s.Pos, true
+	case *shast.StmtExpr:
+		return s.Pos, s.Pos != token.NoPos
+	default:
+		return 0, false
+	}
+}
+
+// relativeError moves the position of the error so it's relative to the start of the input
+func relativeError(err error, offset token.Pos) error {
+	posErr, ok := err.(errors.PosErr)
+	if !ok || posErr.Pos < offset {
+		return err
+	}
+
+	return errors.NewPos(posErr.Pos-offset, posErr.Error())
+}
@@ -0,0 +1,79 @@
+package repl
+
+import (
+	"testing"
+
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/token"
+)
+
+func TestSession_Compile(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantCode string
+	}{
+		{
+			name:     "declare a variable",
+			input:    "let name = :yok",
+			wantCode: "YOK_NAME=yok",
+		},
+		{
+			name:     "use a variable from an earlier input",
+			input:    "print(upper(name))",
+			wantCode: "_yok_upper() {\n    printf '%s' \"$1\" | tr '[:lower:]' '[:upper:]'\n}\nprintf '%s\\n' \"$(_yok_upper \"$YOK_NAME\")\"",
+		},
+		{
+			name:     "helper functions are only added once",
+			input:    "let loud = upper(name)",
+			wantCode: "YOK_LOUD=\"$(_yok_upper \"$YOK_NAME\")\"",
+		},
+		{
+			name:     "hoisted temporaries",
+			input:    "print(len(trim(loud)))",
+			wantCode: "_yok_trim() {\n    _yok_str=${1#\"${1%%[![:space:]]*}\"}\n    printf '%s' \"${_yok_str%\"${_yok_str##*[![:space:]]}\"}\"\n}\n_TMP1=\"$(_yok_trim \"$YOK_LOUD\")\"\nprintf '%s\\n' \"${#_TMP1}\"",
+		},
+	}
+
+	// each input uses the inputs that came before it
+	s := NewSession()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunk, err := s.Compile(tt.input)
+			if err != nil {
+				t.Fatalf("Session.Compile() error = %v, %v", err, s.Errors)
+			}
+
+			if chunk.Code != tt.wantCode {
+				t.Errorf("Session.Compile() code = %q, want %q", chunk.Code, tt.wantCode)
+			}
+			s.Commit(chunk)
+		})
+	}
+}
+
+func TestSession_Compile_Errors(t *testing.T) {
+	s := NewSession()
+	chunk, err := s.Compile("let a = :1\n")
+	if err != nil {
+		t.Fatalf("Session.Compile() error = %v, %v", err, s.Errors)
+	}
+	s.Commit(chunk)
+
+	// the input with the error is not committed so b is still undeclared afterwards
+	for range 2 {
+		_, err = s.Compile("let b = c")
+		if err == nil {
+			t.Fatal("Session.Compile() expected an error for an undeclared variable")
+		}
+
+		if len(s.Errors) != 1 {
+			t.Fatalf("Session.Compile() errors = %v, want 1 error", s.Errors)
+		}
+
+		posErr, ok := s.Errors[0].(errors.PosErr)
+		if !ok || posErr.Pos != token.Pos(8) {
+			t.Errorf("Session.Compile() error = %#v, want an error at the start of 'c'", s.Errors[0])
+		}
+	}
+}
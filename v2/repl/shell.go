@@ -0,0 +1,105 @@
+package repl
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os/exec"
+)
+
+// Shell is a long running shell process. Code is written to the stdin of the shell so
+// variables and functions that are defined by one call to Run are available to the next
+type Shell struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.Reader
+	out    io.Writer
+	// marker is printed by the shell after each chunk of code so Run knows when the code has finished
+	marker []byte
+}
+
+// StartShell starts the shell at path. The output of the shell is written to stdout and stderr
+func StartShell(path string, stdout, stderr io.Writer) (*Shell, error) {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(path)
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	shellStdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Shell{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: shellStdout,
+		out:    stdout,
+		marker: []byte("__yok_repl_" + hex.EncodeToString(id) + "__"),
+	}, nil
+}
+
+// Run runs the code in the shell and waits for it to finish. The code is run in a group with stdin redirected
+// from /dev/null so commands can not read the code that is sent to the shell after it.
+// The group starts with the ':' command so it's never empty, even if the code only contains comments
+func (s *Shell) Run(code string) error {
+	_, err := io.WriteString(s.stdin, "{ :\n"+code+"\n} </dev/null\nprintf '%s' '"+string(s.marker)+"'\n")
+	if err != nil {
+		return err
+	}
+
+	return s.wait()
+}
+
+// wait copies the output of the shell until the marker is printed
+func (s *Shell) wait() error {
+	buf := []byte{}
+	chunk := make([]byte, 4096)
+	for {
+		n, err := s.stdout.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+
+		if i := bytes.Index(buf, s.marker); i >= 0 {
+			_, err := s.out.Write(buf[:i])
+			return err
+		}
+
+		// the end of the buffer is kept in case the marker was split between two reads
+		keep := max(0, len(buf)-len(s.marker)+1)
+		_, writeErr := s.out.Write(buf[:keep])
+		if writeErr != nil {
+			return writeErr
+		}
+		buf = buf[keep:]
+
+		if err != nil {
+			s.out.Write(buf)
+			return err
+		}
+	}
+}
+
+// Close stops the shell and waits for it to exit
+func (s *Shell) Close() error {
+	err := s.stdin.Close()
+	if err != nil {
+		return err
+	}
+
+	return s.cmd.Wait()
+}
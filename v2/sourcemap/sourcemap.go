@@ -0,0 +1,235 @@
+package sourcemap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/token"
+)
+
+// Line maps a single line of generated sh code back to the yok code that generated it
+type Line struct {
+	ShLine int
+	Yok    token.FullPosition
+}
+
+// Map maps lines in a generated sh script back to their original yok source
+type Map struct {
+	lines []Line
+}
+
+// New creates a new Map from the positions returned by the sh code generator.
+// The yokFile and source are used to convert the positions into full file positions
+func New(yokFile string, source []byte, positions map[int]token.Pos) Map {
+	lines := []Line{}
+	for shLine, pos := range positions {
+		lines = append(lines, Line{
+			ShLine: shLine,
+			Yok:    token.GetFullPosition(yokFile, source, pos),
+		})
+	}
+
+	slices.SortFunc(lines, func(a, b Line) int {
+		return a.ShLine - b.ShLine
+	})
+
+	return Map{lines: lines}
+}
+
+// Lookup finds the yok position for the given sh line. If the line was not generated
+// directly by yok code the closest mapped line before it is used instead
+func (m Map) Lookup(shLine int) (token.FullPosition, bool) {
+	found := false
+	pos := token.FullPosition{}
+	for _, line := range m.lines {
+		if line.ShLine > shLine {
+			break
+		}
+
+		pos = line.Yok
+		found = true
+	}
+
+	return pos, found
+}
+
+// Encode encodes the map into the sidecar file format. Each line of the file
+// contains the sh line number followed by the yok file position
+//
+// Example:
+//
+//	3 hello.yok:1:1
+//	4 hello.yok:2:1
+func (m Map) Encode() []byte {
+	buf := bytes.Buffer{}
+	for _, line := range m.lines {
+		fmt.Fprintf(&buf, "%d %s\n", line.ShLine, line.Yok)
+	}
+
+	return buf.Bytes()
+}
+
+// Decode decodes a map from the sidecar file format created by Map.Encode
+func Decode(data []byte) (Map, error) {
+	lines := []Line{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+
+		line, err := decodeLine(text)
+		if err != nil {
+			return Map{}, err
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Map{}, err
+	}
+
+	return Map{lines: lines}, nil
+}
+
+// decodeLine decodes a single line from the sidecar file format
+func decodeLine(text string) (Line, error) {
+	shLineText, position, ok := strings.Cut(text, " ")
+	if !ok {
+		return Line{}, errors.New("invalid source map line: " + text)
+	}
+
+	shLine, err := strconv.Atoi(shLineText)
+	if err != nil {
+		return Line{}, errors.New("invalid sh line number in source map: " + text)
+	}
+
+	// file names may contain ':' so the line and column are parsed from the end
+	rest, colText, ok := cutLast(position, ":")
+	if !ok {
+		return Line{}, errors.New("invalid yok position in source map: " + text)
+	}
+	fileName, lineText, ok := cutLast(rest, ":")
+	if !ok {
+		return Line{}, errors.New("invalid yok position in source map: " + text)
+	}
+
+	lineNumber, err := strconv.Atoi(lineText)
+	if err != nil {
+		return Line{}, errors.New("invalid yok line number in source map: " + text)
+	}
+	colNumber, err := strconv.Atoi(colText)
+	if err != nil {
+		return Line{}, errors.New("invalid yok column number in source map: " + text)
+	}
+
+	return Line{
+		ShLine: shLine,
+		Yok: token.FullPosition{
+			FileName:   fileName,
+			LineNumber: lineNumber,
+			ColNumber:  colNumber,
+		},
+	}, nil
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
+
+// Translate rewrites the line numbers in sh error messages for the given sh file into yok positions.
+// Both the dash (script.sh: 12: ...) and bash (script.sh: line 12: ...) message formats are supported
+//
+// Example:
+//
+//	/tmp/script.sh: 12: foo: not found -> hello.yok:5:3: foo: not found
+func (m Map) Translate(shFile, msg string) string {
+	return m.translate(errorPattern(shFile), msg)
+}
+
+// errorPattern returns a regexp that matches the file and line number in sh error messages
+func errorPattern(shFile string) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(shFile) + `: (?:line )?(\d+):`)
+}
+
+// translate rewrites all the matches of pattern in msg into yok positions
+func (m Map) translate(pattern *regexp.Regexp, msg string) string {
+	return pattern.ReplaceAllStringFunc(msg, func(match string) string {
+		groups := pattern.FindStringSubmatch(match)
+		shLine, err := strconv.Atoi(groups[1])
+		if err != nil {
+			return match
+		}
+
+		pos, ok := m.Lookup(shLine)
+		if !ok {
+			return match
+		}
+
+		return pos.String() + ":"
+	})
+}
+
+// Writer is an io.Writer that translates sh error messages into yok positions
+// before writing them to the underlying writer
+type Writer struct {
+	w       io.Writer
+	pattern *regexp.Regexp
+	m       Map
+	buf     []byte
+}
+
+// NewWriter creates a new Writer that translates errors for the given sh file
+func NewWriter(w io.Writer, shFile string, m Map) *Writer {
+	return &Writer{
+		w:       w,
+		pattern: errorPattern(shFile),
+		m:       m,
+	}
+}
+
+// Write implements the io.Writer interface. Output is buffered until a full line is written
+func (w *Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := w.m.translate(w.pattern, string(w.buf[:i+1]))
+		w.buf = w.buf[i+1:]
+		if _, err := io.WriteString(w.w, line); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush writes any remaining partial line to the underlying writer
+func (w *Writer) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	line := w.m.translate(w.pattern, string(w.buf))
+	w.buf = nil
+	_, err := io.WriteString(w.w, line)
+	return err
+}
@@ -0,0 +1,124 @@
+package sourcemap
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/yok/token"
+)
+
+func TestNew(t *testing.T) {
+	source := []byte("let a = :10\n\nprint(a)\n")
+	positions := map[int]token.Pos{
+		5: 13,
+		3: 0,
+	}
+
+	got := New("test.yok", source, positions)
+	want := Map{
+		lines: []Line{
+			{ShLine: 3, Yok: token.FullPosition{FileName: "test.yok", LineNumber: 1, ColNumber: 1}},
+			{ShLine: 5, Yok: token.FullPosition{FileName: "test.yok", LineNumber: 3, ColNumber: 1}},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("New() = %v, want %v", got, want)
+	}
+}
+
+func TestMap_Translate(t *testing.T) {
+	m := Map{
+		lines: []Line{
+			{ShLine: 3, Yok: token.FullPosition{FileName: "test.yok", LineNumber: 1, ColNumber: 1}},
+			{ShLine: 5, Yok: token.FullPosition{FileName: "test.yok", LineNumber: 3, ColNumber: 5}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{
+			name: "dash error",
+			msg:  "/tmp/test.sh: 3: foo: not found",
+			want: "test.yok:1:1: foo: not found",
+		},
+		{
+			name: "bash error",
+			msg:  "/tmp/test.sh: line 5: foo: command not found",
+			want: "test.yok:3:5: foo: command not found",
+		},
+		{
+			name: "unmapped line uses previous mapping",
+			msg:  "/tmp/test.sh: 7: bar: not found",
+			want: "test.yok:3:5: bar: not found",
+		},
+		{
+			name: "line before first mapping",
+			msg:  "/tmp/test.sh: 1: bar: not found",
+			want: "/tmp/test.sh: 1: bar: not found",
+		},
+		{
+			name: "different file",
+			msg:  "/tmp/other.sh: 3: foo: not found",
+			want: "/tmp/other.sh: 3: foo: not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Translate("/tmp/test.sh", tt.msg); got != tt.want {
+				t.Errorf("Map.Translate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	m := Map{
+		lines: []Line{
+			{ShLine: 3, Yok: token.FullPosition{FileName: "test.yok", LineNumber: 1, ColNumber: 1}},
+			{ShLine: 12, Yok: token.FullPosition{FileName: "c:/my:dir/test.yok", LineNumber: 5, ColNumber: 3}},
+		},
+	}
+
+	got, err := Decode(m.Encode())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Decode() = %v, want %v", got, m)
+	}
+
+	_, err = Decode([]byte("12 test.yok"))
+	if err == nil {
+		t.Errorf("Decode() expected an error for a missing line number")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	m := Map{
+		lines: []Line{
+			{ShLine: 3, Yok: token.FullPosition{FileName: "test.yok", LineNumber: 2, ColNumber: 1}},
+		},
+	}
+
+	buf := bytes.Buffer{}
+	w := NewWriter(&buf, "/tmp/test.sh", m)
+	for _, chunk := range []string{"/tmp/te", "st.sh: 3: foo: not found\n/tmp/test.sh", ": 4: bar"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Writer.Write() error = %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Writer.Flush() error = %v", err)
+	}
+
+	want := "test.yok:2:1: foo: not found\ntest.yok:2:1: bar"
+	if got := buf.String(); got != want {
+		t.Errorf("Writer output = %q, want %q", got, want)
+	}
+}
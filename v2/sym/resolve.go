@@ -0,0 +1,219 @@
+package sym
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/errors"
+)
+
+// unresolved is an identifier that did not refer to any symbol when it was used
+type unresolved struct {
+	ident *yokast.Identifier
+	scope *Scope
+	// declaredLater is set if a matching symbol was declared after the identifier was used
+	declaredLater bool
+}
+
+// Resolver resolves all the variables in a yok script and builds it's symbol table
+type Resolver struct {
+	source   []byte
+	Errors   []error
+	Warnings []error
+
+	table      *Table
+	scope      *Scope
+	unresolved []*unresolved
+}
+
+// New creates a new resolver for the given yok source code
+func New(source []byte) *Resolver {
+	return &Resolver{
+		source: source,
+	}
+}
+
+// Resolve builds the symbol table for the script.
+// If an error is returned the Resolver.Errors field will contain all the resolution errors.
+// Resolver.Warnings contains issues, like unused variables, that do not prevent the script from compiling
+func (r *Resolver) Resolve(script *yokast.Script) (*Table, error) {
+	r.table = newTable()
+	r.scope = r.table.Global
+
+	r.resolveStmts(script.Statements)
+
+	for _, u := range r.unresolved {
+		name := u.ident.Name(r.source)
+		if u.declaredLater {
+			r.addError(u.ident, fmt.Sprintf("'%s' is used before it is declared", name))
+			continue
+		}
+		r.addError(u.ident, fmt.Sprintf("'%s' is not declared", name))
+	}
+
+	for _, symbol := range r.table.symbols {
+		// exported variables are used by the commands that the script runs
+		if len(symbol.Refs) == 0 && !symbol.Exported {
+			r.Warnings = append(r.Warnings, errors.NewPos(symbol.Pos(), fmt.Sprintf("'%s' is declared but never used", symbol.Name)))
+		}
+	}
+
+	sortByPos(r.Errors)
+	sortByPos(r.Warnings)
+
+	if len(r.Errors) > 0 {
+		return r.table, errors.New("there were errors while resolving the script")
+	}
+
+	return r.table, nil
+}
+
+// addError adds a new error at the position of the identifier
+func (r *Resolver) addError(ident *yokast.Identifier, msg string) {
+	r.Errors = append(r.Errors, errors.NewPos(ident.Token.Pos, msg))
+}
+
+// sortByPos sorts positioned errors by their position in the source
+func sortByPos(errs []error) {
+	slices.SortStableFunc(errs, func(a, b error) int {
+		aPos, aOk := a.(errors.PosErr)
+		bPos, bOk := b.(errors.PosErr)
+		if !aOk || !bOk {
+			return 0
+		}
+
+		return int(aPos.Pos) - int(bPos.Pos)
+	})
+}
+
+// resolveStmts resolves each of the statements in the current scope
+func (r *Resolver) resolveStmts(stmts []yokast.Stmt) {
+	for _, stmt := range stmts {
+		r.resolveStmt(stmt)
+	}
+}
+
+// resolveBlock resolves the statements in the block inside a new block scope
+func (r *Resolver) resolveBlock(block *yokast.Block) {
+	if block == nil {
+		return
+	}
+
+	r.scope = newScope(BlockScope, r.scope)
+	r.resolveStmts(block.Statements)
+	r.scope = r.scope.Parent
+}
+
+// resolveStmt resolves all the identifiers in the statement
+func (r *Resolver) resolveStmt(stmt yokast.Stmt) {
+	switch s := stmt.(type) {
+	case *yokast.Assign:
+		// the value is resolved first so 'let a = a + 1' refers to the outer 'a'
+		r.resolveExpr(s.Value)
+		symbol := r.declare(s.Identifier)
+		symbol.Exported = s.Export
+		symbol.Const = s.Const
+	case *yokast.Reassign:
+		r.resolveExpr(s.Value)
+		r.write(s.Identifier)
+	case *yokast.EnvAssign:
+		r.resolveExpr(s.Value)
+		r.env(s.Variable)
+	case *yokast.StmtExpr:
+		r.resolveExpr(s.Expression)
+	case *yokast.If:
+		r.resolveExpr(s.Test)
+		r.resolveBlock(s.Body)
+		for _, elseIf := range s.ElseIfs {
+			r.resolveExpr(elseIf.Test)
+			r.resolveBlock(elseIf.Body)
+		}
+		r.resolveBlock(s.ElseBody)
+	case *yokast.Block:
+		r.resolveBlock(s)
+	}
+}
+
+// resolveExpr resolves all the identifiers in the expression
+func (r *Resolver) resolveExpr(expr yokast.Expr) {
+	switch e := expr.(type) {
+	case *yokast.Identifier:
+		r.read(e)
+	case *yokast.EnvVar:
+		r.env(e)
+	case *yokast.Call:
+		// the call identifier is a command or builtin, not a variable, so only the arguments are resolved
+		for _, arg := range e.Arguments {
+			r.resolveExpr(arg)
+		}
+		// named argument names are not variables either
+		for _, arg := range e.NamedArguments {
+			r.resolveExpr(arg.Value)
+		}
+	case *yokast.Dict:
+		for _, entry := range e.Entries {
+			r.resolveExpr(entry.Value)
+		}
+	case *yokast.NestedCall:
+		r.resolveExpr(e.Call)
+	case *yokast.InfixExpr:
+		r.resolveExpr(e.Left)
+		r.resolveExpr(e.Right)
+	case *yokast.PrefixExpr:
+		r.resolveExpr(e.Expression)
+	case *yokast.GroupExpr:
+		r.resolveExpr(e.Expression)
+	}
+}
+
+// declare declares a new symbol in the current scope
+func (r *Resolver) declare(ident *yokast.Identifier) *Symbol {
+	name := ident.Name(r.source)
+	symbol := r.scope.declare(name, ident)
+	r.table.symbols = append(r.table.symbols, symbol)
+	r.table.idents[ident] = symbol
+
+	for _, u := range r.unresolved {
+		if u.ident.Name(r.source) == name && u.scope.within(r.scope) {
+			u.declaredLater = true
+		}
+	}
+
+	return symbol
+}
+
+// env records an environment variable that is used by the script
+func (r *Resolver) env(envVar *yokast.EnvVar) {
+	r.table.env[envVar.VarName(r.source)] = true
+}
+
+// read resolves an identifier that reads the value of a symbol
+func (r *Resolver) read(ident *yokast.Identifier) {
+	name := ident.Name(r.source)
+	symbol, ok := r.scope.Lookup(name)
+	if !ok {
+		r.unresolved = append(r.unresolved, &unresolved{ident: ident, scope: r.scope})
+		return
+	}
+
+	symbol.Refs = append(symbol.Refs, ident)
+	r.table.idents[ident] = symbol
+}
+
+// write resolves an identifier that reassigns the value of a symbol
+func (r *Resolver) write(ident *yokast.Identifier) {
+	name := ident.Name(r.source)
+	symbol, ok := r.scope.Lookup(name)
+	if !ok {
+		r.addError(ident, fmt.Sprintf("can not assign to '%s' because it is not declared, use 'let %s = ...' to declare it", name, name))
+		return
+	}
+
+	if symbol.Const {
+		r.addError(ident, fmt.Sprintf("can not assign to '%s' because it is a constant", name))
+	}
+
+	symbol.Writes = append(symbol.Writes, ident)
+	r.table.idents[ident] = symbol
+}
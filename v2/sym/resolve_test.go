@@ -0,0 +1,171 @@
+package sym
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/parser"
+	"github.com/bjatkin/yok/token"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name:   "declared and used",
+			source: "let a = :1\nprint(a)\n",
+		},
+		{
+			name:       "undeclared variable",
+			source:     "print(a)\n",
+			wantErrors: []string{"test.yok:1:7: 'a' is not declared"},
+		},
+		{
+			name:       "use before declaration",
+			source:     "print(a)\nlet a = :1\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:7: 'a' is used before it is declared"},
+		},
+		{
+			name:       "use in its own declaration",
+			source:     "let a = a + :1\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:9: 'a' is used before it is declared"},
+		},
+		{
+			name:   "reassign declared variable",
+			source: "let a = :1\na = a + :1\nprint(a)\n",
+		},
+		{
+			name:         "reassign undeclared variable",
+			source:       "let b = :1\na = :2\n",
+			wantErrors:   []string{"test.yok:2:1: can not assign to 'a' because it is not declared, use 'let a = ...' to declare it"},
+			wantWarnings: []string{"test.yok:1:5: 'b' is declared but never used"},
+		},
+		{
+			name:         "unused variables",
+			source:       "let a = :1\nlet b = a\n",
+			wantWarnings: []string{"test.yok:2:5: 'b' is declared but never used"},
+		},
+		{
+			name:         "reassigned but never read",
+			source:       "let a = :1\na = :2\n",
+			wantWarnings: []string{"test.yok:1:5: 'a' is declared but never used"},
+		},
+		{
+			name:   "exported variables are used by commands",
+			source: "export let a = :1\nmake(:all)\n",
+		},
+		{
+			name:   "outer variable used in block",
+			source: "let a = :1\nif a > :0 {\n\tprint(a)\n}\n",
+		},
+		{
+			name:       "block variable is not visible outside the block",
+			source:     "if :1 > :0 {\n\tlet a = :1\n\tprint(a)\n}\nprint(a)\n",
+			wantErrors: []string{"test.yok:5:7: 'a' is not declared"},
+		},
+		{
+			name:       "else body has it's own scope",
+			source:     "if :1 > :0 {\n\tlet a = :1\n\tprint(a)\n} else {\n\tprint(a)\n}\n",
+			wantErrors: []string{"test.yok:5:8: 'a' is not declared"},
+		},
+		{
+			name:       "reassign a constant",
+			source:     "const a = :1\na = :2\nprint(a)\n",
+			wantErrors: []string{"test.yok:2:1: can not assign to 'a' because it is a constant"},
+		},
+		{
+			name:   "call names are not variables",
+			source: "let a = \"hi\"\necho(len(a))\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := []byte(tt.source)
+			script := mustParse(t, source)
+
+			r := New(source)
+			_, err := r.Resolve(script)
+			if (err != nil) != (len(tt.wantErrors) > 0) {
+				t.Errorf("Resolver.Resolve() error = %v, want errors %v", err, tt.wantErrors)
+			}
+
+			if got := formatAll(r.Errors, source); !reflect.DeepEqual(got, tt.wantErrors) {
+				t.Errorf("Resolver.Resolve() errors = %v, want %v", got, tt.wantErrors)
+			}
+
+			if got := formatAll(r.Warnings, source); !reflect.DeepEqual(got, tt.wantWarnings) {
+				t.Errorf("Resolver.Resolve() warnings = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestTable(t *testing.T) {
+	source := []byte("let a = :1\nif a > :0 {\n\tlet a = :2\n\tprint(a)\n}\na = :3\n")
+	script := mustParse(t, source)
+
+	r := New(source)
+	table, err := r.Resolve(script)
+	if err != nil {
+		t.Fatalf("Resolver.Resolve() error = %v", err)
+	}
+
+	symbols := table.Symbols()
+	if len(symbols) != 2 {
+		t.Fatalf("Table.Symbols() = %d symbols, want 2", len(symbols))
+	}
+	outer, inner := symbols[0], symbols[1]
+
+	if outer.Scope != table.Global || inner.Scope.Parent != table.Global || inner.Scope.Kind != BlockScope {
+		t.Errorf("Table.Symbols() symbols were declared in the wrong scopes")
+	}
+
+	if len(outer.Refs) != 1 || len(outer.Writes) != 1 {
+		t.Errorf("outer symbol refs = %d, writes = %d, want 1, 1", len(outer.Refs), len(outer.Writes))
+	}
+
+	// the 'a' in 'print(a)' is shadowed by the inner declaration
+	printArg := token.Pos(bytes.Index(source, []byte("print(a)")) + len("print("))
+	got, ok := table.SymbolAt(printArg)
+	if !ok || got != inner {
+		t.Errorf("Table.SymbolAt() = %v, want the inner symbol", got)
+	}
+
+	if _, ok := table.SymbolAt(2); ok {
+		t.Errorf("Table.SymbolAt() found a symbol for the 'let' keyword")
+	}
+
+	assign := script.Statements[0].(*yokast.Assign)
+	got, ok = table.Lookup(assign.Identifier)
+	if !ok || got != outer {
+		t.Errorf("Table.Lookup() = %v, want the outer symbol", got)
+	}
+}
+
+func mustParse(t *testing.T, source []byte) *yokast.Script {
+	t.Helper()
+
+	p := parser.New(source)
+	script, err := p.Parse()
+	if err != nil {
+		t.Fatalf("failed to parse source: %v %v", err, p.Errors)
+	}
+
+	return script
+}
+
+func formatAll(errs []error, source []byte) []string {
+	var formatted []string
+	for _, err := range errs {
+		formatted = append(formatted, errors.Format(err, "test.yok", source))
+	}
+
+	return formatted
+}
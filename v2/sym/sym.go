@@ -0,0 +1,166 @@
+package sym
+
+import (
+	"slices"
+
+	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/token"
+)
+
+// ScopeKind is the kind of lexical scope
+type ScopeKind int
+
+const (
+	// ScriptScope is the top level scope of a yok script
+	ScriptScope = ScopeKind(iota)
+	// BlockScope is the scope created by a { ... } block
+	BlockScope
+)
+
+// Symbol is a variable that was declared in a yok script
+type Symbol struct {
+	Name string
+	// Decl is the identifier in the let statement that declared the symbol
+	Decl *yokast.Identifier
+	// Refs are all the identifiers that read the value of the symbol
+	Refs []*yokast.Identifier
+	// Writes are all the identifiers that reassign the value of the symbol
+	Writes []*yokast.Identifier
+	Scope  *Scope
+	// Exported is set if the symbol was declared with 'export let'
+	Exported bool
+	// Const is set if the symbol was declared with 'const'
+	Const bool
+}
+
+// Pos returns the position where the symbol was declared
+func (s *Symbol) Pos() token.Pos {
+	return s.Decl.Token.Pos
+}
+
+// Scope is a lexical scope in a yok script
+type Scope struct {
+	Kind     ScopeKind
+	Parent   *Scope
+	Children []*Scope
+	symbols  map[string]*Symbol
+	// order is the order that the symbols were declared in the scope
+	order []*Symbol
+}
+
+// newScope creates a new scope that is a child of the parent scope
+func newScope(kind ScopeKind, parent *Scope) *Scope {
+	scope := &Scope{
+		Kind:    kind,
+		Parent:  parent,
+		symbols: map[string]*Symbol{},
+	}
+
+	if parent != nil {
+		parent.Children = append(parent.Children, scope)
+	}
+
+	return scope
+}
+
+// declare adds a new symbol to the scope, any symbol with the same name in this scope is shadowed
+func (s *Scope) declare(name string, decl *yokast.Identifier) *Symbol {
+	symbol := &Symbol{
+		Name:  name,
+		Decl:  decl,
+		Scope: s,
+	}
+	s.symbols[name] = symbol
+	s.order = append(s.order, symbol)
+
+	return symbol
+}
+
+// LookupLocal finds the symbol with the given name in this scope only
+func (s *Scope) LookupLocal(name string) (*Symbol, bool) {
+	symbol, ok := s.symbols[name]
+	return symbol, ok
+}
+
+// Lookup finds the symbol with the given name in this scope or any of it's parent scopes
+func (s *Scope) Lookup(name string) (*Symbol, bool) {
+	for scope := s; scope != nil; scope = scope.Parent {
+		if symbol, ok := scope.LookupLocal(name); ok {
+			return symbol, true
+		}
+	}
+
+	return nil, false
+}
+
+// within returns true if the scope is the same as, or nested inside of, the parent scope
+func (s *Scope) within(parent *Scope) bool {
+	for scope := s; scope != nil; scope = scope.Parent {
+		if scope == parent {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Symbols returns all the symbols declared in this scope in declaration order
+func (s *Scope) Symbols() []*Symbol {
+	return s.order
+}
+
+// Table is the symbol table for a yok script
+type Table struct {
+	// Global is the top level scope of the script
+	Global *Scope
+	// idents maps every resolved identifier to the symbol it refers to
+	idents map[*yokast.Identifier]*Symbol
+	// symbols are all the symbols in the script in declaration order
+	symbols []*Symbol
+	// env is the set of environment variables that are explicitly used in the script
+	env map[string]bool
+}
+
+// newTable creates a new empty symbol table
+func newTable() *Table {
+	return &Table{
+		Global: newScope(ScriptScope, nil),
+		idents: map[*yokast.Identifier]*Symbol{},
+		env:    map[string]bool{},
+	}
+}
+
+// Lookup returns the symbol that the identifier refers to
+func (t *Table) Lookup(ident *yokast.Identifier) (*Symbol, bool) {
+	symbol, ok := t.idents[ident]
+	return symbol, ok
+}
+
+// SymbolAt returns the symbol referenced by the identifier at the given position in the source
+func (t *Table) SymbolAt(pos token.Pos) (*Symbol, bool) {
+	for ident, symbol := range t.idents {
+		start := ident.Token.Pos
+		end := start + token.Pos(ident.Token.Len)
+		if pos >= start && pos < end {
+			return symbol, true
+		}
+	}
+
+	return nil, false
+}
+
+// Symbols returns all the symbols in the script in declaration order
+func (t *Table) Symbols() []*Symbol {
+	return t.symbols
+}
+
+// EnvVars returns the sorted names of all the environment variables that are explicitly used in the script
+func (t *Table) EnvVars() []string {
+	names := []string{}
+	for name := range t.env {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
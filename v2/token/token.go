@@ -1,6 +1,7 @@
 package token
 
 import (
+	"fmt"
 	"unicode/utf8"
 )
 
@@ -37,6 +38,9 @@ const (
 	BodyKeyword
 	IfKeyword
 	ElseKeyword
+	EnvKeyword
+	ExportKeyword
+	ConstKeyword
 
 	// Literals
 	StringExpression
@@ -65,6 +69,8 @@ const (
 	CloseBrace
 	OpenParen
 	CloseParen
+	Dot
+	Colon
 )
 
 var stringerMap = map[Type]string{
@@ -94,6 +100,9 @@ var stringerMap = map[Type]string{
 	BodyKeyword:      "body",
 	IfKeyword:        "if",
 	ElseKeyword:      "else",
+	EnvKeyword:       "env",
+	ExportKeyword:    "export",
+	ConstKeyword:     "const",
 	StringExpression: "string_expression",
 	PatternLiteral:   "pattern",
 	StringLiteral:    "string",
@@ -118,6 +127,8 @@ var stringerMap = map[Type]string{
 	CloseBrace:       "close_brace",
 	OpenParen:        "open_paren",
 	CloseParen:       "close_paren",
+	Dot:              "dot",
+	Colon:            "colon",
 }
 
 // String implements the stringer interface for all token types
@@ -133,6 +144,10 @@ func (t Type) String() string {
 // Pos is the position of the token in the src code
 type Pos uint64
 
+// NoPos is the position of code that was created by the compiler and does not come from the src code.
+// Pos 0 can not be used for this because it's the start of the src code
+const NoPos = ^Pos(0)
+
 // FullPosition is the full position of a token in a yok file
 type FullPosition struct {
 	FileName   string
@@ -140,26 +155,32 @@ type FullPosition struct {
 	ColNumber  int
 }
 
+// String returns the position in the standard file:line:col format
+func (p FullPosition) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.FileName, p.LineNumber, p.ColNumber)
+}
+
 // GetFullPosition converts a basic source file Pos into a FullPosition
 func GetFullPosition(sourceFile string, source []byte, pos Pos) FullPosition {
 	lineNumber := 1
-	ColNumber := 0
-	for i := Pos(0); i < pos; {
+	colNumber := 1
+	for i := Pos(0); i < pos && int(i) < len(source); {
 		r, size := utf8.DecodeRune(source[i:])
 		i += Pos(size)
 
 		if r == '\n' {
-			ColNumber = 0
+			colNumber = 1
 			lineNumber++
+			continue
 		}
 
-		ColNumber++
+		colNumber++
 	}
 
 	return FullPosition{
 		FileName:   sourceFile,
 		LineNumber: lineNumber,
-		ColNumber:  ColNumber,
+		ColNumber:  colNumber,
 	}
 }
 
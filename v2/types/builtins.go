@@ -0,0 +1,28 @@
+package types
+
+// signature is the type signature of a yok builtin function
+type signature struct {
+	// params are the types that each positional argument accepts, nil means the builtin takes any arguments
+	params [][]Type
+	result Type
+}
+
+// builtins are the type signatures of all the yok builtin functions
+var builtins = map[string]signature{
+	"print":         {result: Unknown},
+	"eprint":        {result: Unknown},
+	"printf":        {result: Unknown},
+	"len":           {params: [][]Type{{Str}}, result: Int},
+	"remove_prefix": {params: [][]Type{{Str}, {Str, Pattern}}, result: Str},
+	"remove_suffix": {params: [][]Type{{Str}, {Str, Pattern}}, result: Str},
+	"replace":       {params: [][]Type{{Str}, {Str}, {Str}}, result: Str},
+	"replace_all":   {params: [][]Type{{Str}, {Str}, {Str}}, result: Str},
+	"split":         {params: [][]Type{{Str}, {Str}}, result: Str},
+	"upper":         {params: [][]Type{{Str}}, result: Str},
+	"lower":         {params: [][]Type{{Str}}, result: Str},
+	"trim":          {params: [][]Type{{Str}}, result: Str},
+	"contains":      {params: [][]Type{{Str}, {Str}}, result: Bool},
+	"starts_with":   {params: [][]Type{{Str}, {Str}}, result: Bool},
+	"ends_with":     {params: [][]Type{{Str}, {Str}}, result: Bool},
+	"matches":       {params: [][]Type{{Str}, {Str, Pattern}}, result: Bool},
+}
@@ -0,0 +1,342 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/sym"
+	"github.com/bjatkin/yok/token"
+)
+
+// Info contains the types that were inferred for a yok script
+type Info struct {
+	exprs map[yokast.Expr]Type
+	vars  map[*sym.Symbol]Type
+}
+
+// TypeOf returns the type of the expression, expressions that were not checked are Unknown
+func (i *Info) TypeOf(expr yokast.Expr) Type {
+	if nested, ok := expr.(*yokast.NestedCall); ok {
+		expr = nested.Call
+	}
+
+	return i.exprs[expr]
+}
+
+// VarType returns the type of the variable
+func (i *Info) VarType(symbol *sym.Symbol) Type {
+	return i.vars[symbol]
+}
+
+// Checker infers the types of all the values in a yok script and reports type errors
+type Checker struct {
+	source  []byte
+	symbols *sym.Table
+	Errors  []error
+
+	info *Info
+}
+
+// New creates a new type checker, the symbol table must come from resolving the same script
+func New(source []byte, symbols *sym.Table) *Checker {
+	return &Checker{
+		source:  source,
+		symbols: symbols,
+	}
+}
+
+// Check infers the types in the script. If an error is returned the Checker.Errors field
+// will contain all the type errors. Values whose type can not be inferred are never errors
+func (c *Checker) Check(script *yokast.Script) (*Info, error) {
+	c.info = &Info{
+		exprs: map[yokast.Expr]Type{},
+		vars:  map[*sym.Symbol]Type{},
+	}
+
+	c.checkStmts(script.Statements)
+
+	if len(c.Errors) > 0 {
+		return c.info, errors.New("there were type errors in the script")
+	}
+
+	return c.info, nil
+}
+
+// addError adds a new type error at the given position
+func (c *Checker) addError(pos token.Pos, msg string) {
+	c.Errors = append(c.Errors, errors.NewPos(pos, msg))
+}
+
+// checkStmts checks each of the statements
+func (c *Checker) checkStmts(stmts []yokast.Stmt) {
+	for _, stmt := range stmts {
+		c.checkStmt(stmt)
+	}
+}
+
+// checkBlock checks the statements in the block
+func (c *Checker) checkBlock(block *yokast.Block) {
+	if block == nil {
+		return
+	}
+
+	c.checkStmts(block.Statements)
+}
+
+// checkStmt checks the types used in the statement
+func (c *Checker) checkStmt(stmt yokast.Stmt) {
+	switch s := stmt.(type) {
+	case *yokast.Assign:
+		t := Unknown
+		if s.Value != nil {
+			t = c.checkExpr(s.Value)
+		}
+
+		if s.Type != nil {
+			declared, ok := Parse(s.Type.Name(c.source))
+			if !ok {
+				c.addError(s.Type.Token.Pos, UnknownTypeMsg(s.Type.Name(c.source)))
+			}
+			c.checkAssign(s.Identifier, declared, t)
+			t = declared
+		}
+
+		if symbol, ok := c.symbols.Lookup(s.Identifier); ok {
+			c.info.vars[symbol] = t
+		}
+	case *yokast.Reassign:
+		t := c.checkExpr(s.Value)
+		if symbol, ok := c.symbols.Lookup(s.Identifier); ok {
+			c.checkAssign(s.Identifier, c.info.vars[symbol], t)
+		}
+	case *yokast.EnvAssign:
+		c.checkExpr(s.Value)
+	case *yokast.StmtExpr:
+		c.checkExpr(s.Expression)
+	case *yokast.If:
+		c.checkTest(s.Test)
+		c.checkBlock(s.Body)
+		for _, elseIf := range s.ElseIfs {
+			c.checkTest(elseIf.Test)
+			c.checkBlock(elseIf.Body)
+		}
+		c.checkBlock(s.ElseBody)
+	case *yokast.Block:
+		c.checkBlock(s)
+	}
+}
+
+// checkAssign checks that a value of type got can be assigned to the variable
+func (c *Checker) checkAssign(ident *yokast.Identifier, want, got Type) {
+	if assignable(want, got) {
+		return
+	}
+
+	msg := fmt.Sprintf("can not assign %s to '%s' of type %s", got, ident.Name(c.source), want)
+	c.addError(ident.Token.Pos, msg)
+}
+
+// checkTest checks that the test of an if statement is a bool
+func (c *Checker) checkTest(test yokast.Expr) {
+	t := c.checkExpr(test)
+	if t != Unknown && t != Bool {
+		c.addError(exprPos(test), fmt.Sprintf("if test must be a bool but got %s", t))
+	}
+}
+
+// checkExpr infers the type of the expression and checks the types of any sub expressions
+func (c *Checker) checkExpr(expr yokast.Expr) Type {
+	t := c.inferExpr(expr)
+	c.info.exprs[expr] = t
+	return t
+}
+
+// inferExpr infers the type of the expression
+func (c *Checker) inferExpr(expr yokast.Expr) Type {
+	switch e := expr.(type) {
+	case *yokast.String:
+		return Str
+	case *yokast.Atom:
+		return atomType(strings.TrimPrefix(e.Token.Value(c.source), ":"))
+	case *yokast.Pattern:
+		return Pattern
+	case *yokast.EnvVar:
+		return Str
+	case *yokast.Identifier:
+		symbol, ok := c.symbols.Lookup(e)
+		if !ok {
+			return Unknown
+		}
+
+		return c.info.vars[symbol]
+	case *yokast.GroupExpr:
+		return c.checkExpr(e.Expression)
+	case *yokast.PrefixExpr:
+		t := c.checkExpr(e.Expression)
+		if e.Token.Type == token.Minus && !assignable(Int, t) {
+			c.addError(e.Token.Pos, fmt.Sprintf("'-' only works with int values, but got %s", t))
+		}
+
+		return Int
+	case *yokast.InfixExpr:
+		return c.inferInfix(e)
+	case *yokast.Call:
+		return c.inferCall(e)
+	case *yokast.NestedCall:
+		return c.checkExpr(e.Call)
+	case *yokast.Dict:
+		for _, entry := range e.Entries {
+			c.checkExpr(entry.Value)
+		}
+
+		return Unknown
+	default:
+		return Unknown
+	}
+}
+
+// atomType infers the type of an atom from it's value
+func atomType(value string) Type {
+	if value == "true" || value == "false" {
+		return Bool
+	}
+
+	if _, err := strconv.Atoi(value); err == nil {
+		return Int
+	}
+
+	return Str
+}
+
+// inferInfix infers the type of an infix expression and checks the types of it's operands
+func (c *Checker) inferInfix(expr *yokast.InfixExpr) Type {
+	left := c.checkExpr(expr.Left)
+	right := c.checkExpr(expr.Right)
+	op := expr.Operator.Value(c.source)
+
+	switch expr.Operator.Type {
+	case token.Plus, token.Minus, token.Multiply, token.Divide, token.Mod:
+		if t := firstNotInt(left, right); t != Unknown {
+			c.addError(expr.Operator.Pos, fmt.Sprintf("'%s' only works with int values, but got %s", op, t))
+		}
+
+		return Int
+	case token.GreaterThan, token.GreaterEqual, token.LessThan, token.LessEqual:
+		if t := firstNotInt(left, right); t != Unknown {
+			msg := fmt.Sprintf("'%s' only works with int values, but got %s. Use == or != to compare %s values", op, t, t)
+			c.addError(expr.Operator.Pos, msg)
+		}
+
+		return Bool
+	case token.EqualEqual, token.NotEqual:
+		if !assignable(left, right) && !assignable(right, left) {
+			c.addError(expr.Operator.Pos, fmt.Sprintf("can not compare %s and %s with '%s'", left, right, op))
+		}
+
+		return Bool
+	case token.OrKeyword:
+		if !assignable(left, right) {
+			c.addError(expr.Operator.Pos, fmt.Sprintf("both sides of 'or' must have the same type, but got %s and %s", left, right))
+		}
+
+		return left
+	default:
+		return Unknown
+	}
+}
+
+// firstNotInt returns the first type that is not an int, or Unknown if all the types are ints
+func firstNotInt(types ...Type) Type {
+	for _, t := range types {
+		if !assignable(Int, t) {
+			return t
+		}
+	}
+
+	return Unknown
+}
+
+// inferCall infers the type of a call, builtins have fixed signatures and commands always return their output as a str
+func (c *Checker) inferCall(call *yokast.Call) Type {
+	args := []Type{}
+	for _, arg := range call.Arguments {
+		args = append(args, c.checkExpr(arg))
+	}
+	for _, arg := range call.NamedArguments {
+		c.checkExpr(arg.Value)
+	}
+
+	name := call.Identifier.Name(c.source)
+	sig, ok := builtins[name]
+	if !ok {
+		return Str
+	}
+
+	// the compiler reports the wrong number of arguments so only the types are checked here
+	for i, arg := range args {
+		if sig.params == nil || i >= len(sig.params) {
+			break
+		}
+
+		if !acceptsAny(sig.params[i], arg) {
+			msg := fmt.Sprintf("%s() argument %d must be %s but got %s", name, i+1, typeList(sig.params[i]), arg)
+			c.addError(exprPos(call.Arguments[i]), msg)
+		}
+	}
+
+	return sig.result
+}
+
+// acceptsAny returns true if the type can be used as any of the wanted types
+func acceptsAny(want []Type, got Type) bool {
+	for _, t := range want {
+		if assignable(t, got) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// typeList formats a list of types for an error message (e.g. str or pattern)
+func typeList(types []Type) string {
+	names := []string{}
+	for _, t := range types {
+		names = append(names, t.String())
+	}
+
+	return strings.Join(names, " or ")
+}
+
+// exprPos returns the position of the expression in the source
+func exprPos(expr yokast.Expr) token.Pos {
+	switch e := expr.(type) {
+	case *yokast.String:
+		return e.Token.Pos
+	case *yokast.Atom:
+		return e.Token.Pos
+	case *yokast.Pattern:
+		return e.Token.Pos
+	case *yokast.Identifier:
+		return e.Token.Pos
+	case *yokast.EnvVar:
+		return e.Token.Pos
+	case *yokast.Call:
+		return e.Identifier.Token.Pos
+	case *yokast.NestedCall:
+		return exprPos(e.Call)
+	case *yokast.InfixExpr:
+		return exprPos(e.Left)
+	case *yokast.GroupExpr:
+		return exprPos(e.Expression)
+	case *yokast.PrefixExpr:
+		return e.Token.Pos
+	case *yokast.Dict:
+		return e.Token.Pos
+	default:
+		return 0
+	}
+}
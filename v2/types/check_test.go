@@ -0,0 +1,154 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/yok/ast/yokast"
+	"github.com/bjatkin/yok/errors"
+	"github.com/bjatkin/yok/parser"
+	"github.com/bjatkin/yok/sym"
+)
+
+func TestChecker_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		wantErrors []string
+	}{
+		{
+			name:   "inferred types",
+			source: "let a = :1\nlet b = \"hi\"\nlet c = a + :2\nprint(a, b, c)\n",
+		},
+		{
+			name:   "typed declarations",
+			source: "let a int = :1\nlet b str\nlet c bool = :true\nprint(a, b, c)\n",
+		},
+		{
+			name:   "ints can be used as strs",
+			source: "let a str = :1\nprint(a)\n",
+		},
+		{
+			name:       "unknown type",
+			source:     "let a float = :1\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:7: unknown type 'float', expected int, str or bool"},
+		},
+		{
+			name:       "assign str to int",
+			source:     "let a int = \"hi\"\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:5: can not assign str to 'a' of type int"},
+		},
+		{
+			name:       "reassign with the wrong type",
+			source:     "let a = :1\na = \"hi\"\nprint(a)\n",
+			wantErrors: []string{"test.yok:2:1: can not assign str to 'a' of type int"},
+		},
+		{
+			name:       "math on strings",
+			source:     "let a = \"hi\" + :1\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:14: '+' only works with int values, but got str"},
+		},
+		{
+			name:       "less than on strings",
+			source:     "if \"a\" < \"b\" {\n\tprint(\"yes\")\n}\n",
+			wantErrors: []string{"test.yok:1:8: '<' only works with int values, but got str. Use == or != to compare str values"},
+		},
+		{
+			name:       "compare bool and int",
+			source:     "if :true == :1 {\n\tprint(\"yes\")\n}\n",
+			wantErrors: []string{"test.yok:1:10: can not compare bool and int with '=='"},
+		},
+		{
+			name:       "if test is not a bool",
+			source:     "let a = :1\nif a {\n\tprint(a)\n}\n",
+			wantErrors: []string{"test.yok:2:4: if test must be a bool but got int"},
+		},
+		{
+			name:   "builtin results",
+			source: "let a = len(\"hi\") + :1\nif contains(\"hi\", \"h\") {\n\tprint(a)\n}\n",
+		},
+		{
+			name:       "builtin argument types",
+			source:     "let a = upper(:true)\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:15: upper() argument 1 must be str but got bool"},
+		},
+		{
+			name:       "command output is a str",
+			source:     "let a = :1 + cat(\"file\")\nprint(a)\n",
+			wantErrors: []string{"test.yok:1:12: '+' only works with int values, but got str"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := []byte(tt.source)
+			script := mustParse(t, source)
+
+			r := sym.New(source)
+			table, err := r.Resolve(script)
+			if err != nil {
+				t.Fatalf("failed to resolve source: %v %v", err, r.Errors)
+			}
+
+			c := New(source, table)
+			_, err = c.Check(script)
+			if (err != nil) != (len(tt.wantErrors) > 0) {
+				t.Errorf("Checker.Check() error = %v, want errors %v", err, tt.wantErrors)
+			}
+
+			if got := formatAll(c.Errors, source); !reflect.DeepEqual(got, tt.wantErrors) {
+				t.Errorf("Checker.Check() errors = %v, want %v", got, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestInfo_TypeOf(t *testing.T) {
+	source := []byte("let a = :1\nlet b = a == :2\nprint(b)\n")
+	script := mustParse(t, source)
+
+	table, err := sym.New(source).Resolve(script)
+	if err != nil {
+		t.Fatalf("failed to resolve source: %v", err)
+	}
+
+	info, err := New(source, table).Check(script)
+	if err != nil {
+		t.Fatalf("Checker.Check() error = %v", err)
+	}
+
+	assign := script.Statements[1].(*yokast.Assign)
+	if got := info.TypeOf(assign.Value); got != Bool {
+		t.Errorf("Info.TypeOf() = %v, want %v", got, Bool)
+	}
+
+	infix := assign.Value.(*yokast.InfixExpr)
+	if got := info.TypeOf(infix.Left); got != Int {
+		t.Errorf("Info.TypeOf() = %v, want %v", got, Int)
+	}
+
+	symbol, _ := table.Lookup(assign.Identifier)
+	if got := info.VarType(symbol); got != Bool {
+		t.Errorf("Info.VarType() = %v, want %v", got, Bool)
+	}
+}
+
+func mustParse(t *testing.T, source []byte) *yokast.Script {
+	t.Helper()
+
+	p := parser.New(source)
+	script, err := p.Parse()
+	if err != nil {
+		t.Fatalf("failed to parse source: %v %v", err, p.Errors)
+	}
+
+	return script
+}
+
+func formatAll(errs []error, source []byte) []string {
+	var formatted []string
+	for _, err := range errs {
+		formatted = append(formatted, errors.Format(err, "test.yok", source))
+	}
+
+	return formatted
+}
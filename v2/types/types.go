@@ -0,0 +1,77 @@
+package types
+
+import "fmt"
+
+// Type is the type of a yok value. sh represents every value as a string so types
+// only exist at compile time, they are used to catch mistakes and pick the right sh operators
+type Type int
+
+const (
+	// Unknown is used when the type of a value can not be inferred, unknown values are never type errors
+	Unknown = Type(iota)
+	// Str is a string value
+	Str
+	// Int is an integer value
+	Int
+	// Bool is either :true or :false
+	Bool
+	// Pattern is a glob pattern literal
+	Pattern
+)
+
+// String returns the name of the type as it's written in yok code
+func (t Type) String() string {
+	switch t {
+	case Str:
+		return "str"
+	case Int:
+		return "int"
+	case Bool:
+		return "bool"
+	case Pattern:
+		return "pattern"
+	default:
+		return "unknown"
+	}
+}
+
+// Parse returns the type with the given name, only types that can be used in type annotations are supported
+func Parse(name string) (Type, bool) {
+	switch name {
+	case "str":
+		return Str, true
+	case "int":
+		return Int, true
+	case "bool":
+		return Bool, true
+	default:
+		return Unknown, false
+	}
+}
+
+// UnknownTypeMsg is the error message for a type annotation that uses an unknown type
+func UnknownTypeMsg(name string) string {
+	return fmt.Sprintf("unknown type '%s', expected int, str or bool", name)
+}
+
+// Zero returns the sh value used for variables of this type that are declared without a value
+func (t Type) Zero() string {
+	switch t {
+	case Int:
+		return "0"
+	case Bool:
+		return "false"
+	default:
+		return ""
+	}
+}
+
+// assignable returns true if a value of type got can be used where a value of type want is expected.
+// Every int is also a valid str so ints can be used anywhere a str is expected
+func assignable(want, got Type) bool {
+	if want == Unknown || got == Unknown || want == got {
+		return true
+	}
+
+	return want == Str && got == Int
+}